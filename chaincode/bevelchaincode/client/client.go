@@ -0,0 +1,192 @@
+// Package client is a Go client of the chaincode, for the platform server and other applications.
+//
+// It encodes requests as the chaincode parses them, JSON in base64 without padding, computes the hashes of
+// immutable contracts as the chaincode does, and decodes responses and errors into typed values.
+// Transactions are submitted through a Submitter, such as a contract of the Fabric Gateway client:
+//
+//	c := client.New(network.GetContract("dnn"))
+//	req, err := client.NewRequest(*ic).NewAsset()
+//	if err == nil {
+//		res, err = c.CreateAsset(req)
+//	}
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/Subskribo-BV/dnn-fabric-chaincode/common/contract"
+	"github.com/Subskribo-BV/dnn-fabric-chaincode/service"
+)
+
+// Submitter submits and evaluates the transactions of the chaincode, returning the payload of its response.
+// The Contract of the Fabric Gateway client implements it, when obtained without a contract name;
+// the functions of the chaincode are invoked with their contract name, as "lifecycle:CreateAsset".
+type Submitter interface {
+	SubmitTransaction(name string, args ...string) ([]byte, error)
+	EvaluateTransaction(name string, args ...string) ([]byte, error)
+}
+
+// TransientSubmitter is a Submitter which also submits transactions with transient data, which the peers pass to
+// the chaincode without recording it on the channel. The Contract of the Fabric Gateway client implements it
+// through a proposal with transient data.
+type TransientSubmitter interface {
+	Submitter
+	SubmitTransient(name string, transient map[string][]byte, args ...string) ([]byte, error)
+}
+
+// Client invokes the transactions of the chaincode through a Submitter.
+type Client struct {
+	submitter Submitter
+	encoding  string
+}
+
+// New returns a client submitting the transactions through s.
+func New(s Submitter) *Client {
+	return &Client{submitter: s}
+}
+
+// WithEncoding sends the requests in a request envelope with the encoding, such as service.EncodingGzipBase64
+// for large contracts. Without an encoding, requests are sent as base64 without padding, which every version
+// of the chaincode accepts.
+func (c *Client) WithEncoding(encoding string) *Client {
+	c.encoding = encoding
+	return c
+}
+
+// CreateAsset anchors an immutable contract.
+// An immutable contract stored in a private data collection is sent in the transient data of the proposal,
+// which requires a TransientSubmitter.
+func (c *Client) CreateAsset(req *service.NewAssetReq) (*service.CreateAssetResponse, error) {
+	res := new(service.CreateAssetResponse)
+	if req.Store == nil || req.Store.Collection == "" {
+		return res, c.SubmitRequest(lifecycle("CreateAsset"), req, res)
+	}
+
+	ts, ok := c.submitter.(TransientSubmitter)
+	if !ok {
+		return nil, fmt.Errorf("storing a contract in the private data collection %q requires a TransientSubmitter", req.Store.Collection)
+	}
+
+	icData, err := c.encode(req.ImmutableContract)
+	if err != nil {
+		return nil, err
+	}
+
+	public := *req
+	public.ImmutableContract = contract.ImmutableContract{}
+
+	data, err := c.encode(public)
+	if err != nil {
+		return nil, err
+	}
+
+	transient := map[string][]byte{service.ImmutableContractTransientKey: []byte(icData)}
+	payload, err := ts.SubmitTransient(lifecycle("CreateAsset"), transient, data)
+
+	return res, decodeResponse(lifecycle("CreateAsset"), payload, err, res)
+}
+
+// VoidAsset voids an active contract.
+func (c *Client) VoidAsset(req *service.VoidAssetReq) (*service.VoidResponse, error) {
+	res := new(service.VoidResponse)
+	return res, c.SubmitRequest(lifecycle("VoidAsset"), req, res)
+}
+
+// ExpireAsset expires an active contract.
+func (c *Client) ExpireAsset(req *service.ExpireAssetReq) (*service.ExpireResponse, error) {
+	res := new(service.ExpireResponse)
+	return res, c.SubmitRequest(lifecycle("ExpireAsset"), req, res)
+}
+
+// ReleaseAsset releases the content of an active contract.
+func (c *Client) ReleaseAsset(req *service.ReleaseAssetReq) (*service.ReleaseResponse, error) {
+	res := new(service.ReleaseResponse)
+	return res, c.SubmitRequest(lifecycle("ReleaseAsset"), req, res)
+}
+
+// ReadAsset returns the contract anchored with the id.
+func (c *Client) ReadAsset(contractId int64) (*service.Contract, error) {
+	res := new(service.Contract)
+	return res, c.Evaluate(query("ReadAsset"), res, strconv.FormatInt(contractId, 10))
+}
+
+// ReadImmutableContract returns the immutable contract stored on ledger with the contract.
+func (c *Client) ReadImmutableContract(contractId int64) (*contract.ImmutableContract, error) {
+	res := new(contract.ImmutableContract)
+	return res, c.Evaluate(query("ReadImmutableContract"), res, strconv.FormatInt(contractId, 10))
+}
+
+// ValidateContract runs the checks of the action on a request without submitting it, see service.ValidationReport.
+func (c *Client) ValidateContract(req any, action string) (*service.ValidationReport, error) {
+	data, err := c.encode(req)
+	if err != nil {
+		return nil, err
+	}
+
+	res := new(service.ValidationReport)
+	return res, c.Evaluate(query("ValidateContract"), res, data, action)
+}
+
+// WhoAmI returns the identity and roles the chaincode resolves for the client.
+func (c *Client) WhoAmI() (*service.Caller, error) {
+	res := new(service.Caller)
+	return res, c.Evaluate(query("WhoAmI"), res)
+}
+
+// SubmitRequest submits a transaction taking an encoded request, and decodes its response into res.
+func (c *Client) SubmitRequest(function string, req any, res any) error {
+	data, err := c.encode(req)
+	if err != nil {
+		return err
+	}
+
+	return c.Submit(function, res, data)
+}
+
+// Submit submits a transaction, and decodes its response into res unless res is nil.
+// An error of the chaincode is returned as an *Error.
+func (c *Client) Submit(function string, res any, args ...string) error {
+	payload, err := c.submitter.SubmitTransaction(function, args...)
+	return decodeResponse(function, payload, err, res)
+}
+
+// Evaluate evaluates a transaction without submitting it for ordering, and decodes its response into res unless res is nil.
+// An error of the chaincode is returned as an *Error.
+func (c *Client) Evaluate(function string, res any, args ...string) error {
+	payload, err := c.submitter.EvaluateTransaction(function, args...)
+	return decodeResponse(function, payload, err, res)
+}
+
+func (c *Client) encode(req any) (string, error) {
+	if c.encoding == "" {
+		return Encode(req)
+	}
+
+	return service.EncodeRequest(req, c.encoding)
+}
+
+func decodeResponse(function string, payload []byte, err error, res any) error {
+	if err != nil {
+		return DecodeError(function, err)
+	}
+
+	if res == nil || len(payload) == 0 {
+		return nil
+	}
+
+	if err := json.Unmarshal(payload, res); err != nil {
+		return fmt.Errorf("invalid response of %s: %v", function, err)
+	}
+
+	return nil
+}
+
+func lifecycle(function string) string {
+	return service.LifecycleContractName + ":" + function
+}
+
+func query(function string) string {
+	return service.QueryContractName + ":" + function
+}
@@ -0,0 +1,293 @@
+package client_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Subskribo-BV/dnn-fabric-chaincode/client"
+	"github.com/Subskribo-BV/dnn-fabric-chaincode/client/clienttest"
+	"github.com/Subskribo-BV/dnn-fabric-chaincode/common/contract"
+	"github.com/Subskribo-BV/dnn-fabric-chaincode/common/ledgertest"
+	"github.com/Subskribo-BV/dnn-fabric-chaincode/service"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+var sealedOn = time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+
+// testClient returns a client of the chaincode on an in-memory ledger, submitting as the platform server
+// of Org1MSP, which is granted the platform-admin and server-submitter roles.
+func testClient(t *testing.T) *client.Client {
+	t.Helper()
+	t.Setenv("CORE_PEER_LOCALMSPID", "Org1MSP")
+
+	l := ledgertest.NewLedger()
+	l.SetTime(sealedOn.Add(72 * time.Hour))
+
+	cc, err := contractapi.NewChaincode(service.Contracts(service.NewSmartContract(), false)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := ledgertest.NewClientIdentity("Org1MSP", "server", map[string]string{"user_id": "c102"})
+	c := client.New(clienttest.NewSubmitter(l, cc, server))
+
+	if err := c.SubmitRequest("admin:InitLedger", service.InitLedgerReq{AdminMSPs: []string{"Org1MSP"}}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	config := new(service.GovernanceConfig)
+	if err := c.Evaluate("query:ReadConfig", config); err != nil {
+		t.Fatal(err)
+	}
+
+	req := service.UpdateConfigReq{
+		Version:      config.Version,
+		AdminMSPs:    config.AdminMSPs,
+		RoleBindings: []service.RoleBinding{{Role: service.RoleServerSubmitter, MSPID: "Org1MSP"}},
+		Rules:        config.Rules,
+	}
+	if err := c.SubmitRequest("admin:UpdateConfig", req, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	return c
+}
+
+func TestRequestBuilder(t *testing.T) {
+	ic, hash, err := ledgertest.NewContract(1001, sealedOn).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := client.NewRequest(*ic).WithNotaryOU("notary").NewAsset()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if req.ImmutableContractHash != hash || req.NotaryOU != "notary" {
+		t.Errorf("unexpected request: hash %s, notary %q", req.ImmutableContractHash, req.NotaryOU)
+	}
+
+	release, err := client.NewRequest(*ic).WithNotaryOU("notary").WithPackage(7, "pkg").ReleaseAsset()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if release.ContractId != 1001 || release.PackageId != 7 || release.PackageHash != "pkg" || release.ImmutableContractHash != hash || release.NotaryOU != "" {
+		t.Errorf("unexpected release request: %+v", release)
+	}
+
+	data, err := client.Encode(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the request decodes as the chaincode parses it
+	parsed := new(service.NewAssetReq)
+	if err := service.ParseRequest(data, parsed); err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.HasSuffix(data, "=") || parsed.ImmutableContractHash != hash {
+		t.Errorf("unexpected encoding %q", data)
+	}
+}
+
+func TestClient(t *testing.T) {
+	c := testClient(t)
+
+	ic, _, err := ledgertest.NewContract(1001, sealedOn).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := client.NewRequest(*ic).NewAsset()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	created, err := c.CreateAsset(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	asset, err := c.ReadAsset(1001)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if asset.State != service.ContractStateActive || created.TxId == "" {
+		t.Errorf("unexpected asset: %+v", asset)
+	}
+
+	expire, err := client.NewRequest(*ic).ExpireAsset()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := c.ValidateContract(expire, string(contract.ActionExpire))
+	if err != nil || !report.Valid {
+		t.Errorf("validation of an expiry: %+v %v", report, err)
+	}
+
+	void, err := client.NewRequest(*ic).VoidAsset()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.VoidAsset(void); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = c.VoidAsset(void)
+
+	var e *client.Error
+	if !errors.As(err, &e) || !e.Has(contract.CodeState, "") || e.Function != "lifecycle:VoidAsset" {
+		t.Errorf("void of a voided contract: %v", err)
+	}
+
+	// a large contract sent compressed in a request envelope
+	ic, _, err = ledgertest.NewContract(1002, sealedOn).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err = client.NewRequest(*ic).NewAsset()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.WithEncoding(service.EncodingGzipBase64).CreateAsset(req); err != nil {
+		t.Fatal(err)
+	}
+
+	caller, err := c.WhoAmI()
+	if err != nil || caller.MSPID != "Org1MSP" || len(caller.Roles) != 2 {
+		t.Errorf("caller: %+v %v", caller, err)
+	}
+}
+
+func TestStoredContract(t *testing.T) {
+	// a contract of a private collection is sent in the transient data
+	for _, collection := range []string{"", "contracts"} {
+		t.Run("collection="+collection, func(t *testing.T) {
+			c := testClient(t)
+
+			ic, _, err := ledgertest.NewContract(1001, sealedOn).Build()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			b := client.NewRequest(*ic).WithStorage(collection)
+
+			req, err := b.NewAsset()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := c.CreateAsset(req); err != nil {
+				t.Fatal(err)
+			}
+
+			stored, err := c.ReadImmutableContract(1001)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if stored.ContractHash != ic.ContractHash || !stored.SealedOnDate.Equal(ic.SealedOnDate) {
+				t.Errorf("unexpected stored contract: %+v", stored)
+			}
+
+			void, err := b.VoidAsset()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if void.ImmutableContract.ContractHash != "" || !void.StoredContract {
+				t.Error("void request does not reference the stored immutable contract")
+			}
+
+			if _, err := c.VoidAsset(void); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+// countingSubmitter counts the transactions it receives, answering each with an empty payload.
+type countingSubmitter struct {
+	count int
+}
+
+func (s *countingSubmitter) SubmitTransaction(name string, args ...string) ([]byte, error) {
+	s.count++
+	return nil, nil
+}
+
+func (s *countingSubmitter) EvaluateTransaction(name string, args ...string) ([]byte, error) {
+	s.count++
+	return nil, nil
+}
+
+func TestRateLimitedSubmitter(t *testing.T) {
+	counter := &countingSubmitter{}
+	c := client.New(client.NewRateLimitedSubmitter(counter, 0.001, 2))
+
+	if err := c.Submit("lifecycle:ActivateDueContracts", nil, "10", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Evaluate("query:WhoAmI", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// the transaction over the rate is not sent for endorsement
+	if err := c.Submit("lifecycle:ActivateDueContracts", nil, "10", ""); !errors.Is(err, client.ErrRateLimited) {
+		t.Errorf("error = %v, want %v", err, client.ErrRateLimited)
+	}
+
+	if counter.count != 2 {
+		t.Errorf("transactions sent = %d, want 2", counter.count)
+	}
+
+	req := &service.NewAssetReq{Store: &service.StoreOptions{Collection: "contracts"}}
+	if _, err := c.CreateAsset(req); err == nil || !strings.Contains(err.Error(), "transient") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestDecodeError(t *testing.T) {
+	// a Fabric Gateway error, with the error of the chaincode in the details
+	var detail []byte
+	detail = protowire.AppendTag(detail, 1, protowire.BytesType)
+	detail = protowire.AppendString(detail, "peer0.org1:7051")
+	detail = protowire.AppendTag(detail, 3, protowire.BytesType)
+	detail = protowire.AppendString(detail, `chaincode response 500, {"errors":[{"code":"mismatch","path":"","message":"invalid immutable contract hash"}]}`)
+
+	err := status.ErrorProto(&spb.Status{
+		Code:    int32(codes.Aborted),
+		Message: "failed to endorse transaction, see attached details for more info",
+		Details: []*anypb.Any{{TypeUrl: "type.googleapis.com/gateway.ErrorDetail", Value: detail}},
+	})
+
+	var e *client.Error
+	if !errors.As(client.DecodeError("lifecycle:CreateAsset", err), &e) || !e.Has(contract.CodeMismatch, "") {
+		t.Fatalf("unexpected error: %v", e)
+	}
+
+	if !errors.Is(e, err) {
+		t.Error("decoded error does not wrap the gateway error")
+	}
+
+	// an error which is not an ErrorResponse
+	if !errors.As(client.DecodeError("query:ReadAsset", errors.New("the asset 1 does not exist")), &e) || len(e.Errors) != 0 {
+		t.Errorf("unexpected error: %v", e)
+	}
+}
@@ -0,0 +1,59 @@
+// Package clienttest provides an in-memory client.Submitter, invoking the chaincode on a ledgertest.Ledger.
+package clienttest
+
+import (
+	"errors"
+
+	"github.com/Subskribo-BV/dnn-fabric-chaincode/client"
+	"github.com/Subskribo-BV/dnn-fabric-chaincode/common/ledgertest"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+// Submitter invokes the chaincode on an in-memory ledger as a client identity.
+// Submitted transactions are committed if they succeed, evaluated transactions never are.
+type Submitter struct {
+	Ledger    *ledgertest.Ledger
+	Chaincode shim.Chaincode
+	Identity  *ledgertest.ClientIdentity
+}
+
+var _ client.TransientSubmitter = (*Submitter)(nil)
+
+// NewSubmitter returns a submitter invoking cc on l as the client id.
+func NewSubmitter(l *ledgertest.Ledger, cc shim.Chaincode, id *ledgertest.ClientIdentity) *Submitter {
+	return &Submitter{Ledger: l, Chaincode: cc, Identity: id}
+}
+
+// As returns a submitter invoking the chaincode on the same ledger as another client.
+func (s *Submitter) As(id *ledgertest.ClientIdentity) *Submitter {
+	return NewSubmitter(s.Ledger, s.Chaincode, id)
+}
+
+func (s *Submitter) SubmitTransaction(name string, args ...string) ([]byte, error) {
+	res, _ := s.Ledger.Invoke(s.Chaincode, ledgertest.WithIdentity(s.Identity), ledgertest.WithArgs(name, args...))
+	if res.Status >= shim.ERRORTHRESHOLD {
+		return nil, errors.New(res.Message)
+	}
+
+	return res.Payload, nil
+}
+
+func (s *Submitter) SubmitTransient(name string, transient map[string][]byte, args ...string) ([]byte, error) {
+	res, _ := s.Ledger.Invoke(s.Chaincode, ledgertest.WithIdentity(s.Identity), ledgertest.WithTransient(transient), ledgertest.WithArgs(name, args...))
+	if res.Status >= shim.ERRORTHRESHOLD {
+		return nil, errors.New(res.Message)
+	}
+
+	return res.Payload, nil
+}
+
+func (s *Submitter) EvaluateTransaction(name string, args ...string) ([]byte, error) {
+	ctx := s.Ledger.NewTx(ledgertest.WithIdentity(s.Identity), ledgertest.WithArgs(name, args...))
+
+	res := s.Chaincode.Invoke(ctx.Stub)
+	if res.Status >= shim.ERRORTHRESHOLD {
+		return nil, errors.New(res.Message)
+	}
+
+	return res.Payload, nil
+}
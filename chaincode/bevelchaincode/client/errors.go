@@ -0,0 +1,125 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Subskribo-BV/dnn-fabric-chaincode/common/contract"
+	"github.com/Subskribo-BV/dnn-fabric-chaincode/service"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// gatewayErrorDetail is the type of the details of the errors of the Fabric Gateway,
+// holding the error returned by the chaincode on each peer.
+const gatewayErrorDetail = "type.googleapis.com/gateway.ErrorDetail"
+
+// Error is an error of a transaction of the chaincode.
+type Error struct {
+	Function string
+	Message  string                    // error message of the chaincode, or of the submitter if the chaincode was not reached
+	Errors   contract.ValidationErrors // the failed checks, if the chaincode rejected the request with an ErrorResponse
+	Err      error                     // the error returned by the submitter
+}
+
+func (e *Error) Error() string {
+	if len(e.Errors) > 0 {
+		return fmt.Sprintf("%s: %v", e.Function, e.Errors)
+	}
+
+	return fmt.Sprintf("%s: %s", e.Function, e.Message)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Has reports whether a check failed with the code, for the value at path.
+func (e *Error) Has(code string, path string) bool {
+	for _, ve := range e.Errors {
+		if ve.Code == code && ve.Path == path {
+			return true
+		}
+	}
+
+	return false
+}
+
+// DecodeError returns the error of a transaction as an *Error. The ErrorResponse of the chaincode is decoded
+// from the message of err, or from the details of a Fabric Gateway error.
+func DecodeError(function string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	e := &Error{Function: function, Message: err.Error(), Err: err}
+
+	messages := []string{err.Error()}
+	if st, ok := status.FromError(err); ok {
+		messages = append(messages, gatewayMessages(st)...)
+	}
+
+	for _, msg := range messages {
+		if errs := decodeErrorResponse(msg); errs != nil {
+			e.Message = msg
+			e.Errors = errs
+			break
+		}
+	}
+
+	return e
+}
+
+// decodeErrorResponse decodes the ErrorResponse in a message, which may be prefixed as by the peer.
+func decodeErrorResponse(msg string) contract.ValidationErrors {
+	i := strings.Index(msg, `{"errors":`)
+	if i < 0 {
+		return nil
+	}
+
+	res := new(service.ErrorResponse)
+	if err := json.NewDecoder(strings.NewReader(msg[i:])).Decode(res); err != nil {
+		return nil
+	}
+
+	return res.Errors
+}
+
+// gatewayMessages returns the messages of the gateway.ErrorDetail details of a status.
+// The message is field 3 of a detail.
+func gatewayMessages(st *status.Status) []string {
+	messages := []string{}
+
+	for _, detail := range st.Proto().GetDetails() {
+		if detail.GetTypeUrl() != gatewayErrorDetail {
+			continue
+		}
+
+		b := detail.GetValue()
+		for len(b) > 0 {
+			num, typ, n := protowire.ConsumeTag(b)
+			if n < 0 {
+				break
+			}
+			b = b[n:]
+
+			if num == 3 && typ == protowire.BytesType {
+				v, n := protowire.ConsumeBytes(b)
+				if n < 0 {
+					break
+				}
+				messages = append(messages, string(v))
+				b = b[n:]
+				continue
+			}
+
+			if n = protowire.ConsumeFieldValue(num, typ, b); n < 0 {
+				break
+			}
+			b = b[n:]
+		}
+	}
+
+	return messages
+}
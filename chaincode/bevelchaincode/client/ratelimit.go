@@ -0,0 +1,57 @@
+package client
+
+import (
+	"errors"
+
+	"github.com/Subskribo-BV/dnn-fabric-chaincode/service"
+)
+
+// ErrRateLimited is returned by a RateLimitedSubmitter for a transaction over its rate, which is not sent.
+var ErrRateLimited = errors.New("rate limit exceeded, retry later")
+
+// RateLimitedSubmitter limits the rate of the transactions of a Submitter before they are sent for endorsement.
+//
+// The rate limit of the chaincode is local to each peer, using its clock and memory, so the peers endorsing a
+// transaction may disagree on it. A client limiting itself keeps its transactions under the limit of every peer.
+type RateLimitedSubmitter struct {
+	submitter Submitter
+	limiter   *service.RateLimiter
+}
+
+var _ TransientSubmitter = (*RateLimitedSubmitter)(nil)
+
+// NewRateLimitedSubmitter returns a submitter sending the transactions through s, at most rate transactions per
+// second on average and up to burst transactions at once.
+func NewRateLimitedSubmitter(s Submitter, rate float64, burst int) *RateLimitedSubmitter {
+	return &RateLimitedSubmitter{submitter: s, limiter: service.NewRateLimiter(rate, burst)}
+}
+
+func (s *RateLimitedSubmitter) SubmitTransaction(name string, args ...string) ([]byte, error) {
+	if !s.limiter.Allow("") {
+		return nil, ErrRateLimited
+	}
+
+	return s.submitter.SubmitTransaction(name, args...)
+}
+
+func (s *RateLimitedSubmitter) EvaluateTransaction(name string, args ...string) ([]byte, error) {
+	if !s.limiter.Allow("") {
+		return nil, ErrRateLimited
+	}
+
+	return s.submitter.EvaluateTransaction(name, args...)
+}
+
+// SubmitTransient submits a transaction with transient data, if the wrapped submitter is a TransientSubmitter.
+func (s *RateLimitedSubmitter) SubmitTransient(name string, transient map[string][]byte, args ...string) ([]byte, error) {
+	ts, ok := s.submitter.(TransientSubmitter)
+	if !ok {
+		return nil, errors.New("the submitter does not submit transient data")
+	}
+
+	if !s.limiter.Allow("") {
+		return nil, ErrRateLimited
+	}
+
+	return ts.SubmitTransient(name, transient, args...)
+}
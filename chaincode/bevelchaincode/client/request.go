@@ -0,0 +1,136 @@
+package client
+
+import (
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/Subskribo-BV/dnn-fabric-chaincode/common/contract"
+	"github.com/Subskribo-BV/dnn-fabric-chaincode/service"
+)
+
+// RequestBuilder builds the requests of the transactions anchoring an immutable contract and changing its state.
+// The hash of the immutable contract is computed when a request is built.
+type RequestBuilder struct {
+	ic          contract.ImmutableContract
+	notaryOU    string
+	packageId   int64
+	packageHash string
+	store       *service.StoreOptions
+}
+
+// NewRequest returns a builder of the requests for the immutable contract.
+func NewRequest(ic contract.ImmutableContract) *RequestBuilder {
+	return &RequestBuilder{ic: ic}
+}
+
+// WithNotaryOU sets the organizational unit of the notary holding the contract, sent with CreateAsset.
+// The chaincode decodes expire and release requests as void requests, so they do not carry it.
+func (b *RequestBuilder) WithNotaryOU(ou string) *RequestBuilder {
+	b.notaryOU = ou
+	return b
+}
+
+// WithPackage sets the package of the contract the change of state applies to.
+func (b *RequestBuilder) WithPackage(id int64, hash string) *RequestBuilder {
+	b.packageId = id
+	b.packageHash = hash
+	return b
+}
+
+// WithStorage stores the immutable contract on ledger with CreateAsset, in the private data collection or in the
+// world state if collection is empty. The requests changing the state of the contract then reference it by its id
+// and hash instead of sending it.
+func (b *RequestBuilder) WithStorage(collection string) *RequestBuilder {
+	b.store = &service.StoreOptions{Collection: collection}
+	return b
+}
+
+// NewAsset builds the request of CreateAsset.
+func (b *RequestBuilder) NewAsset() (*service.NewAssetReq, error) {
+	hash, err := Hash(b.ic)
+	if err != nil {
+		return nil, err
+	}
+
+	return &service.NewAssetReq{
+		ImmutableContract:     b.ic,
+		ImmutableContractHash: hash,
+		NotaryOU:              b.notaryOU,
+		Store:                 b.store,
+	}, nil
+}
+
+// VoidAsset builds the request of VoidAsset.
+func (b *RequestBuilder) VoidAsset() (*service.VoidAssetReq, error) {
+	hash, err := Hash(b.ic)
+	if err != nil {
+		return nil, err
+	}
+
+	return &service.VoidAssetReq{
+		ImmutableContract:     b.changeContract(),
+		ImmutableContractHash: hash,
+		StoredContract:        b.store != nil,
+		ContractId:            b.ic.Contract.ContractID,
+		PackageId:             b.packageId,
+		PackageHash:           b.packageHash,
+	}, nil
+}
+
+// ExpireAsset builds the request of ExpireAsset.
+func (b *RequestBuilder) ExpireAsset() (*service.ExpireAssetReq, error) {
+	hash, err := Hash(b.ic)
+	if err != nil {
+		return nil, err
+	}
+
+	return &service.ExpireAssetReq{
+		ImmutableContract:     b.changeContract(),
+		ImmutableContractHash: hash,
+		StoredContract:        b.store != nil,
+		ContractId:            b.ic.Contract.ContractID,
+		PackageId:             b.packageId,
+		PackageHash:           b.packageHash,
+	}, nil
+}
+
+// ReleaseAsset builds the request of ReleaseAsset.
+func (b *RequestBuilder) ReleaseAsset() (*service.ReleaseAssetReq, error) {
+	hash, err := Hash(b.ic)
+	if err != nil {
+		return nil, err
+	}
+
+	return &service.ReleaseAssetReq{
+		ImmutableContract:     b.changeContract(),
+		ImmutableContractHash: hash,
+		StoredContract:        b.store != nil,
+		ContractId:            b.ic.Contract.ContractID,
+		PackageId:             b.packageId,
+		PackageHash:           b.packageHash,
+	}, nil
+}
+
+// changeContract returns the immutable contract sent with a change of state, empty if it is stored on ledger.
+func (b *RequestBuilder) changeContract() contract.ImmutableContract {
+	if b.store != nil {
+		return contract.ImmutableContract{}
+	}
+
+	return b.ic
+}
+
+// Hash returns the hash of data as the chaincode computes it, the base64 SHA256 hash of its compacted JSON.
+func Hash(data any) (string, error) {
+	return contract.JsonHashS256(data)
+}
+
+// Encode encodes a request as the argument of a transaction, JSON in base64 without padding as ParseRequest expects.
+func Encode(req any) (string, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawStdEncoding.EncodeToString(data), nil
+}
@@ -0,0 +1,168 @@
+// Command contract-verify verifies an exported immutable contract offline.
+//
+// It recomputes the hashes of the contract, runs the validators of its versions, verifies its signatures and
+// certificate chains, and compares it with a ledger record or history export if one is supplied:
+//
+//	contract-verify [-action create] [-hash h] [-record asset.json] [-history lineage.json]
+//		[-config config.json] [-roots ca.pem] [-strict] [-json] contract.json
+//
+// The exit code is 0 if the contract is valid, 1 if a check failed, and 2 if it could not be verified.
+package main
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Subskribo-BV/dnn-fabric-chaincode/common/contract"
+	"github.com/Subskribo-BV/dnn-fabric-chaincode/service"
+)
+
+const (
+	exitValid   = 0
+	exitInvalid = 1
+	exitError   = 2
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("contract-verify", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: contract-verify [flags] <immutable contract JSON file, - for stdin>")
+		flags.PrintDefaults()
+	}
+
+	action := flags.String("action", string(contract.ActionCreate), "change of state to validate the contract for: consent, create, void, expire or release")
+	hash := flags.String("hash", "", "expected immutable contract hash")
+	record := flags.String("record", "", "ledger record of the contract, as returned by ReadAsset")
+	history := flags.String("history", "", "history export of the contract, a JSON array of ledger records such as returned by GetContractLineage")
+	config := flags.String("config", "", "governance configuration providing the validation rules, as returned by ReadConfig; the default rules if not set")
+	roots := flags.String("roots", "", "PEM file of the root certificates of the signature providers; the system roots if not set")
+	strict := flags.Bool("strict", false, "fail signatures which cannot be verified because their key info has no certificate")
+	jsonOut := flags.Bool("json", false, "write the report as JSON")
+
+	if err := flags.Parse(args); err != nil {
+		return exitError
+	}
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return exitError
+	}
+
+	opts := options{action: contract.Action(*action), hash: *hash, strict: *strict}
+
+	ic := new(contract.ImmutableContract)
+	if err := readJSON(flags.Arg(0), stdin, ic); err != nil {
+		return fail(stderr, err)
+	}
+
+	if *config != "" {
+		c := new(service.GovernanceConfig)
+		if err := readJSON(*config, stdin, c); err != nil {
+			return fail(stderr, err)
+		}
+		opts.rules = &c.Rules
+	}
+
+	if *roots != "" {
+		pem, err := os.ReadFile(*roots)
+		if err != nil {
+			return fail(stderr, err)
+		}
+
+		opts.roots = x509.NewCertPool()
+		if !opts.roots.AppendCertsFromPEM(pem) {
+			return fail(stderr, fmt.Errorf("%s: no PEM certificate found", *roots))
+		}
+	}
+
+	if *record != "" {
+		rec := new(service.Contract)
+		if err := readJSON(*record, stdin, rec); err != nil {
+			return fail(stderr, err)
+		}
+		opts.records = append(opts.records, rec)
+	}
+
+	if *history != "" {
+		records := []*service.Contract{}
+		if err := readJSON(*history, stdin, &records); err != nil {
+			return fail(stderr, err)
+		}
+		opts.records = append(opts.records, records...)
+	}
+
+	report, err := verify(ic, opts)
+	if err != nil {
+		return fail(stderr, err)
+	}
+
+	if *jsonOut {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return fail(stderr, err)
+		}
+	} else {
+		writeReport(stdout, report)
+	}
+
+	if !report.Valid {
+		return exitInvalid
+	}
+
+	return exitValid
+}
+
+// writeReport writes the report for reading in a terminal.
+func writeReport(w io.Writer, r *Report) {
+	fmt.Fprintf(w, "contract %d\nimmutable contract hash %s\n\n", r.ContractId, r.ImmutableContractHash)
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, c := range r.Checks {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", strings.ToUpper(string(c.Status)), c.Name, c.Path, c.Message)
+	}
+	tw.Flush()
+
+	if r.Valid {
+		fmt.Fprintln(w, "\nresult: valid")
+	} else {
+		fmt.Fprintf(w, "\nresult: invalid, %d checks failed\n", r.Failed())
+	}
+}
+
+// readJSON decodes the JSON of a file, or of stdin if the name is -.
+func readJSON(name string, stdin io.Reader, v any) error {
+	var data []byte
+	var err error
+
+	if name == "-" {
+		data, err = io.ReadAll(stdin)
+	} else {
+		data, err = os.ReadFile(name)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+
+	return nil
+}
+
+func fail(stderr io.Writer, err error) int {
+	fmt.Fprintln(stderr, "contract-verify:", err)
+	return exitError
+}
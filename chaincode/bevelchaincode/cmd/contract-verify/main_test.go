@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Subskribo-BV/dnn-fabric-chaincode/common/contract"
+	"github.com/Subskribo-BV/dnn-fabric-chaincode/common/ledgertest"
+	"github.com/Subskribo-BV/dnn-fabric-chaincode/service"
+)
+
+var sealedOn = time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+
+// writeFile writes the JSON of v, or v itself if it is a string, to a file of the test directory.
+func writeFile(t *testing.T, name string, v any) string {
+	t.Helper()
+
+	data, ok := v.(string)
+	if !ok {
+		b, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data = string(b)
+	}
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+// verifyReport runs the command with -json, returning its exit code and report.
+func verifyReport(t *testing.T, args ...string) (int, *Report) {
+	t.Helper()
+
+	var stdout, stderr bytes.Buffer
+	code := run(append([]string{"-json"}, args...), nil, &stdout, &stderr)
+	if code == exitError {
+		t.Fatalf("verification error: %s", stderr.String())
+	}
+
+	report := new(Report)
+	if err := json.Unmarshal(stdout.Bytes(), report); err != nil {
+		t.Fatalf("invalid report %s: %v", stdout.String(), err)
+	}
+
+	return code, report
+}
+
+// failed returns the name and path of the failed checks.
+func failed(r *Report) []string {
+	checks := []string{}
+	for _, c := range r.Checks {
+		if c.Status == StatusFail {
+			checks = append(checks, c.Name+" "+c.Path)
+		}
+	}
+
+	return checks
+}
+
+func TestVerifySignedContract(t *testing.T) {
+	signer := ledgertest.NewSigner("Subskribo signer")
+	roots := writeFile(t, "roots.pem", string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: signer.CA.Raw})))
+
+	ic, hash, err := ledgertest.NewContract(1001, sealedOn).SignedBy(signer).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := writeFile(t, "contract.json", ic)
+
+	record := writeFile(t, "asset.json", service.Contract{ContractId: 1001, ContractHash: hash, State: service.ContractStateActive})
+
+	code, report := verifyReport(t, "-roots", roots, "-hash", hash, "-record", record, "-strict", file)
+	if code != exitValid || !report.Valid || report.ImmutableContractHash != hash {
+		t.Fatalf("exit code %d, failed checks %v", code, failed(report))
+	}
+
+	names := map[string]int{}
+	for _, c := range report.Checks {
+		names[c.Name]++
+	}
+	if names["signature"] != 2 || names["certificate"] != 2 || names["ledger"] != 1 {
+		t.Errorf("unexpected checks: %v", names)
+	}
+
+	// the signatures do not chain to the roots of another provider
+	other := writeFile(t, "other.pem", string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ledgertest.NewSigner("other").CA.Raw})))
+	code, report = verifyReport(t, "-roots", other, file)
+	if code != exitInvalid || len(failed(report)) != 2 || !strings.HasPrefix(failed(report)[0], "certificate ") {
+		t.Errorf("exit code %d, failed checks %v", code, failed(report))
+	}
+
+	// a ledger history anchoring another version of the contract
+	history := writeFile(t, "history.json", []service.Contract{{ContractId: 1001, ContractHash: "other", State: service.ContractStateVoided}})
+	code, report = verifyReport(t, "-roots", roots, "-history", history, file)
+	if code != exitInvalid || strings.Join(failed(report), ",") != "ledger " {
+		t.Errorf("exit code %d, failed checks %v", code, failed(report))
+	}
+}
+
+func TestVerifyTamperedContract(t *testing.T) {
+	signer := ledgertest.NewSigner("Subskribo signer")
+	roots := writeFile(t, "roots.pem", string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: signer.CA.Raw})))
+
+	ic, _, err := ledgertest.NewContract(1001, sealedOn).SignedBy(signer).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ic.ContractSignatures.Signatures[1].ContractSignaturePackage.UserFullName = "Eve Impostor"
+
+	code, report := verifyReport(t, "-roots", roots, writeFile(t, "contract.json", ic))
+	if code != exitInvalid {
+		t.Fatalf("tampered contract is valid")
+	}
+
+	checks := strings.Join(failed(report), ",")
+	for _, want := range []string{
+		"hash /contract_signatures/signatures/1/contract_signature_package_hash",
+		"hash /contract_signatures_hash",
+		"signature /contract_signatures/signatures/1/signature",
+	} {
+		if !strings.Contains(checks, want) {
+			t.Errorf("%q not in the failed checks %s", want, checks)
+		}
+	}
+}
+
+func TestVerifyUnsignedContract(t *testing.T) {
+	// the fixture signatures have key infos without certificates
+	ic, _, err := ledgertest.NewContract(1001, sealedOn).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := writeFile(t, "contract.json", ic)
+
+	code, report := verifyReport(t, file)
+	if code != exitValid || report.Checks[len(report.Checks)-1].Status != StatusSkip {
+		t.Errorf("exit code %d, checks %+v", code, report.Checks)
+	}
+
+	if code, _ := verifyReport(t, "-strict", file); code != exitInvalid {
+		t.Errorf("strict exit code %d", code)
+	}
+
+	// the rules of the governance configuration apply
+	rules := contract.DefaultRules()
+	rules.SignatureTypes = []string{"qualified"}
+	config := writeFile(t, "config.json", service.GovernanceConfig{Version: 2, Rules: *rules})
+
+	code, report = verifyReport(t, "-config", config, file)
+	if code != exitInvalid || !strings.Contains(strings.Join(failed(report), ","), "validation /contract/signature_method/signature_type") {
+		t.Errorf("exit code %d, failed checks %v", code, failed(report))
+	}
+}
+
+func TestHumanReport(t *testing.T) {
+	ic, _, err := ledgertest.NewContract(1001, sealedOn).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{writeFile(t, "contract.json", ic)}, nil, &stdout, &stderr); code != exitValid {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+
+	out := stdout.String()
+	if !strings.HasPrefix(out, "contract 1001\n") || !strings.Contains(out, "PASS  hash") || !strings.HasSuffix(out, "result: valid\n") {
+		t.Errorf("unexpected report:\n%s", out)
+	}
+
+	if code := run([]string{"-action", "frobnicate", writeFile(t, "contract.json", ic)}, nil, &stdout, &stderr); code != exitError {
+		t.Errorf("unknown action: exit code %d", code)
+	}
+
+	if code := run(nil, nil, &stdout, &stderr); code != exitError {
+		t.Errorf("missing contract: exit code %d", code)
+	}
+}
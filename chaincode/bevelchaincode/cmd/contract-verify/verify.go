@@ -0,0 +1,270 @@
+package main
+
+import (
+	"crypto/x509"
+	"fmt"
+	"time"
+
+	"github.com/Subskribo-BV/dnn-fabric-chaincode/common/contract"
+	"github.com/Subskribo-BV/dnn-fabric-chaincode/service"
+)
+
+// Status is the outcome of a check.
+type Status string
+
+const (
+	StatusPass Status = "pass"
+	StatusFail Status = "fail"
+	StatusSkip Status = "skip" // the check could not be run, such as a signature without a certificate
+)
+
+// Check is the result of one check of the verification.
+type Check struct {
+	Name    string `json:"name"`           // hash, validation, signature, certificate or ledger
+	Path    string `json:"path,omitempty"` // JSON pointer of the value checked in the immutable contract
+	Status  Status `json:"status"`
+	Message string `json:"message"`
+}
+
+// Report is the result of the verification of an immutable contract.
+type Report struct {
+	ContractId            int64   `json:"contract_id"`
+	ImmutableContractHash string  `json:"immutable_contract_hash"`
+	Valid                 bool    `json:"valid"` // no check failed
+	Checks                []Check `json:"checks"`
+}
+
+func (r *Report) add(name, path string, status Status, format string, a ...any) {
+	r.Checks = append(r.Checks, Check{name, path, status, fmt.Sprintf(format, a...)})
+}
+
+// Failed returns the number of failed checks.
+func (r *Report) Failed() int {
+	n := 0
+	for _, c := range r.Checks {
+		if c.Status == StatusFail {
+			n++
+		}
+	}
+
+	return n
+}
+
+type options struct {
+	action  contract.Action
+	rules   *contract.Rules     // validation rules, DefaultRules if nil
+	roots   *x509.CertPool      // roots of the certificate chains, the system roots if nil
+	strict  bool                // fail signatures which cannot be verified for lack of a certificate
+	hash    string              // expected immutable contract hash, if supplied
+	records []*service.Contract // the ledger record, or the versions of a history export
+}
+
+// verify recomputes the hashes of the immutable contract, runs the validators for the action, verifies its
+// signatures and certificate chains, and compares it with the ledger records.
+func verify(ic *contract.ImmutableContract, opts options) (*Report, error) {
+	icHash, err := contract.JsonHashS256(ic)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Report{ContractId: ic.Contract.ContractID, ImmutableContractHash: icHash, Checks: []Check{}}
+
+	if err := verifyHashes(r, ic); err != nil {
+		return nil, err
+	}
+
+	if opts.hash != "" {
+		if opts.hash == icHash {
+			r.add("hash", "", StatusPass, "immutable contract hash matches the expected hash")
+		} else {
+			r.add("hash", "", StatusFail, "immutable contract hash %s does not match the expected hash %s", icHash, opts.hash)
+		}
+	}
+
+	if err := validate(r, ic, opts); err != nil {
+		return nil, err
+	}
+
+	if err := verifySignatures(r, ic, opts); err != nil {
+		return nil, err
+	}
+
+	if opts.records != nil {
+		compareRecords(r, ic, icHash, opts.records)
+	}
+
+	r.Valid = r.Failed() == 0
+
+	return r, nil
+}
+
+// verifyHashes recomputes the hashes chaining the blocks of the immutable contract.
+func verifyHashes(r *Report, ic *contract.ImmutableContract) error {
+	hashCheck := func(path, name, stored string, data any) error {
+		hash, err := contract.JsonHashS256(data)
+		if err != nil {
+			return err
+		}
+
+		if hash == stored {
+			r.add("hash", path, StatusPass, "%s matches", name)
+		} else {
+			r.add("hash", path, StatusFail, "%s %s does not match the computed hash %s", name, stored, hash)
+		}
+
+		return nil
+	}
+
+	if err := hashCheck("/contract_hash", "contract hash", ic.ContractHash, ic.Contract); err != nil {
+		return err
+	}
+
+	if ic.ContractSignatures.ContractHash == ic.ContractHash {
+		r.add("hash", "/contract_signatures/contract_hash", StatusPass, "signatures block is chained to the contract block")
+	} else {
+		r.add("hash", "/contract_signatures/contract_hash", StatusFail, "signatures block is not chained to the contract block")
+	}
+
+	if ic.Contract.SignatureMethod.PackageMethodId != int64(contract.SignPackageMethodId_Embedded) {
+		for i, sp := range ic.ContractSignatures.Signatures {
+			path := fmt.Sprintf("/contract_signatures/signatures/%d/contract_signature_package_hash", i)
+			name := fmt.Sprintf("signature package hash of user id '%s'", sp.ContractSignaturePackage.UserId)
+
+			if err := hashCheck(path, name, sp.ContractSignaturePackageHash, sp.ContractSignaturePackage); err != nil {
+				return err
+			}
+		}
+	}
+
+	return hashCheck("/contract_signatures_hash", "contract signatures hash", ic.ContractSignaturesHash, ic.ContractSignatures)
+}
+
+// validate runs the validators of the contract versions for the action, as the chaincode does.
+func validate(r *Report, ic *contract.ImmutableContract, opts options) error {
+	err := ic.ValidateFor(opts.action, opts.rules)
+
+	var errs contract.ValidationErrors
+	switch v := err.(type) {
+	case nil:
+		r.add("validation", "", StatusPass, "valid for %s", opts.action)
+		return nil
+	case contract.ValidationErrors:
+		errs = v
+	case *contract.ValidationError:
+		errs = contract.ValidationErrors{v}
+	default:
+		return err
+	}
+
+	for _, e := range errs {
+		r.add("validation", e.Path, StatusFail, "%s: %s", e.Code, e.Message)
+	}
+
+	return nil
+}
+
+// verifySignatures verifies the signature of each signature package and of its content signatures
+// with the certificate of its key info, and the chain of the certificate at the signing date.
+// Signatures of the embedded package method are embedded in the finalized content and not verified.
+func verifySignatures(r *Report, ic *contract.ImmutableContract, opts options) error {
+	if ic.Contract.SignatureMethod.PackageMethodId == int64(contract.SignPackageMethodId_Embedded) {
+		r.add("signature", "/finalized_content", StatusSkip, "signatures are embedded in the finalized content")
+		return nil
+	}
+
+	for i, sp := range ic.ContractSignatures.Signatures {
+		path := fmt.Sprintf("/contract_signatures/signatures/%d", i)
+		pkg := &sp.ContractSignaturePackage
+
+		// the signature is verified against the recomputed hash, so a changed package fails
+		hash, err := contract.JsonHashS256(pkg)
+		if err != nil {
+			return err
+		}
+
+		verifySignature(r, path, fmt.Sprintf("user id '%s'", pkg.UserId), &pkg.KeyInfo, path+"/contract_signature_package/key_info",
+			hash, sp.Signature, pkg.DateSigned, opts)
+
+		for j, cs := range pkg.ContentSignatures {
+			csPath := fmt.Sprintf("%s/contract_signature_package/content_signatures/%d", path, j)
+
+			signee := fmt.Sprintf("content id %d by user id '%s'", cs.ContentId, pkg.UserId)
+
+			// content signatures anchored before they were signed only hold the content id
+			if cs.KeyInfo == nil || cs.DateSigned == nil {
+				status := StatusSkip
+				if opts.strict {
+					status = StatusFail
+				}
+				r.add("signature", csPath+"/signature", status, "signature of %s cannot be verified, it has no key info or date signed", signee)
+				continue
+			}
+
+			verifySignature(r, csPath, signee, cs.KeyInfo, csPath+"/key_info", cs.ContentHash, cs.Signature, *cs.DateSigned, opts)
+		}
+	}
+
+	return nil
+}
+
+func verifySignature(r *Report, path, signee string, k *contract.KeyInfo, keyPath, hash, signature string, signedAt time.Time, opts options) {
+	certs, err := k.Certificates()
+	if err != nil {
+		r.add("certificate", keyPath+"/x509_certificate", StatusFail, "%s: %v", signee, err)
+		return
+	}
+
+	if len(certs) == 0 {
+		status := StatusSkip
+		if opts.strict {
+			status = StatusFail
+		}
+		r.add("signature", path+"/signature", status, "signature of %s cannot be verified, its key info has no certificate", signee)
+		return
+	}
+
+	if err := contract.VerifyHashSignature(certs[0], hash, signature); err != nil {
+		r.add("signature", path+"/signature", StatusFail, "signature of %s: %v", signee, err)
+	} else {
+		r.add("signature", path+"/signature", StatusPass, "signature of %s is valid", signee)
+	}
+
+	if err := contract.VerifyCertificateChain(certs, opts.roots, signedAt); err != nil {
+		r.add("certificate", keyPath+"/x509_certificate", StatusFail, "certificate of %s (%s): %v", signee, certs[0].Subject, err)
+	} else {
+		r.add("certificate", keyPath+"/x509_certificate", StatusPass, "certificate of %s (%s) was valid when signed", signee, certs[0].Subject)
+	}
+}
+
+// compareRecords checks the immutable contract is anchored by a ledger record of the contract.
+// A record anchors it by the immutable contract hash, or by the contract hash while pending consent.
+func compareRecords(r *Report, ic *contract.ImmutableContract, icHash string, records []*service.Contract) {
+	var anchor *service.Contract
+	hashes := []string{}
+
+	for _, rec := range records {
+		if rec.ContractId != ic.Contract.ContractID {
+			continue
+		}
+
+		hashes = append(hashes, rec.ContractHash)
+
+		pending := rec.State == service.ContractStatePendingConsent && rec.ContractHash == ic.ContractHash
+		if rec.ContractHash != icHash && !pending {
+			continue
+		}
+
+		if anchor == nil || rec.UpdatedAt > anchor.UpdatedAt {
+			anchor = rec
+		}
+	}
+
+	switch {
+	case anchor != nil:
+		r.add("ledger", "", StatusPass, "anchored on ledger, state %s since %s", anchor.State, anchor.UpdatedAt)
+	case len(hashes) == 0:
+		r.add("ledger", "", StatusFail, "no ledger record of contract %d", ic.Contract.ContractID)
+	default:
+		r.add("ledger", "", StatusFail, "the ledger records of contract %d anchor other hashes: %v", ic.Contract.ContractID, hashes)
+	}
+}
@@ -25,18 +25,44 @@ import (
 const (
 	Unknown = ""
 	None    = ""
+)
+
+// Role is the role of a participant in a contract.
+type Role string
 
-	Agreement       = "agreement"
-	Approver        = "approver"
-	Beneficiary     = "beneficiary"
-	Contractual     = "contractual"
-	Creator         = "creator"
-	Notary          = "notary"
-	Notifier        = "notifier"
-	Proxy           = "proxy"
-	ServiceProvider = "service-provider"
-	Verifier        = "verifier"
-	Signatory       = "signatory"
+const (
+	Approver        Role = "approver"
+	Beneficiary     Role = "beneficiary"
+	Contractual     Role = "contractual"
+	Creator         Role = "creator"
+	Notary          Role = "notary"
+	Notifier        Role = "notifier"
+	Proxy           Role = "proxy"
+	ServiceProvider Role = "service-provider"
+	Verifier        Role = "verifier"
+	Signatory       Role = "signatory"
+)
+
+// knownRoles is the registry of participant roles understood by the chaincode.
+// A new role is added here with its constant, any other role fails validation.
+var knownRoles = []Role{
+	Approver,
+	Beneficiary,
+	Contractual,
+	Creator,
+	Notary,
+	Notifier,
+	Proxy,
+	ServiceProvider,
+	Verifier,
+	Signatory,
+}
+
+// Roles of content items.
+const (
+	Agreement            = "agreement"             // a consensual agreement between the contractual participants
+	ConstructedAgreement = "constructed-agreement" // contract content appended with the contract details, signed by each signee
+	FinalizedAgreement   = "finalized-agreement"   // document with embedded signatures, saved once all signatures are collected
 )
 
 type SignPackageMethodId int64
@@ -120,9 +146,17 @@ type ContractBlock struct {
 	Definition        ContractDefinition `json:"definition"`         // the definition used to validate required fields and values
 	DefinitionVersion int64              `json:"definition_version"` // version of the definition schema used
 
+	Amends *ContractAmendment `json:"amends,omitempty"` // null unless this contract amends and supersedes an instantiated contract
+
 	SealedOnDate time.Time `json:"sealed_on_date"` // Date contract is sealed and ready for consent
 }
 
+// Links an amendment to the contract it supersedes.
+type ContractAmendment struct {
+	ContractId            int64  `json:"contract_id"`             // contract id of the superseded contract
+	ImmutableContractHash string `json:"immutable_contract_hash"` // hash of the immutable contract anchored for the superseded contract
+}
+
 type ContractOptions struct {
 	ExpiryDate    *time.Time `json:"expiry_date"`    // null if no expiry date
 	EffectiveDate *time.Time `json:"effective_date"` // null if effective immeadiately upon consent
@@ -160,6 +194,13 @@ type ContractUserRoleDefinition struct {
 	Max                int64  `json:"max"`
 	IncludeRoleInCount string `json:"include_role_in_count"`
 	MinKycLevel        int64  `json:"min_kyc_level"`
+
+	// identity claims each participant with this role must have, example: name, mobile-phone
+	RequiredClaims []string `json:"required_claims,omitempty"`
+
+	// verifiers accepted for the identity claims of participants with this role, example: Subskribo, ItsMe
+	// if empty, then claims from any verifier are accepted
+	AcceptedVerifiers []string `json:"accepted_verifiers,omitempty"`
 }
 
 type ContractOption struct {
@@ -347,6 +388,9 @@ type ContractSignatures struct {
 	SealedOnDate time.Time `json:"sealed_on_date"`
 
 	Signatures []SignedContractSignature `json:"signatures"`
+
+	// only for the constructed package method, the first item is the constructed content viewed and signed by each signee
+	ConstructedContentItems []ConstructedContentItem `json:"constructed_content_items,omitempty"`
 }
 
 // The signature by a user for the contract and optional signatures for each content item
@@ -363,13 +407,20 @@ type ContractSignaturePackage struct {
 	KeyInfo           KeyInfo            `json:"key_info"`           // the key info used for the signature
 	IsApprover        bool               `json:"is_approver"`        // if true, then this signee is an approver, they must sign before non-approvers can sign
 	ContentSignatures []ContentSignature `json:"content_signatures"` // signatures for each content item
+
+	// only for the constructed package method, the content id of the constructed content item viewed by the signee
+	ConstructedContentId int64 `json:"constructed_content_id,omitempty"`
 }
 
 // Used for signatures bound to content items (such as a document)
 // Signatures may be from third party providers
+// The fields added after content__id are omitted when empty, so signature packages anchored with only the content id keep their hash.
 type ContentSignature struct {
-	ContentId int64 `json:"content__id"`
-	// additional fields to be added
+	ContentId   int64      `json:"content__id"`            // the content item signed; the tag of anchored signature packages, part of their hash
+	ContentHash string     `json:"content_hash,omitempty"` // plain or encrypted hash of the content item signed
+	Signature   string     `json:"signature,omitempty"`    // signature of the content hash, base64 encoded
+	KeyInfo     *KeyInfo   `json:"key_info,omitempty"`     // the key info used for the signature
+	DateSigned  *time.Time `json:"date_signed,omitempty"`
 }
 
 // Charges and payment information sealed into a readonly record in database upon moving to consent phase.
@@ -427,8 +478,8 @@ type ContractOrganization struct {
 	LegalName      string `json:"legal_name"`
 	CommonName     string `json:"common_name"`
 	OrgType        string `json:"org_type"`
-	Signatories    string `json:"signatories"`
-	NonSignatories string `json:"non_signatories"`
+	Signatories    string `json:"signatories"`     // comma separated positions, each required to sign for the organization
+	NonSignatories string `json:"non_signatories"` // comma separated positions which cannot sign for the organization
 }
 
 // populate and test with enums used for each property
@@ -446,7 +497,23 @@ type ContractProxyInstructions struct {
 	InstructionsHash string `json:"instructions_hash"` // hash of the instructions
 }
 
-func (c *ContractParticipant) IsRole(role string) bool {
+// KnownRoles returns the participant roles understood by the chaincode.
+func KnownRoles() []Role {
+	return append([]Role{}, knownRoles...)
+}
+
+// IsKnown returns true if the role is in the registry of known roles.
+func (r Role) IsKnown() bool {
+	for _, k := range knownRoles {
+		if r == k {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *ContractParticipant) IsRole(role Role) bool {
 	if c == nil {
 		return false
 	}
@@ -456,8 +523,58 @@ func (c *ContractParticipant) IsRole(role string) bool {
 	}
 
 	for _, r := range c.Roles {
-		return r == role
+		if Role(r) == role {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HasAnyRole returns true if the participant has at least one of the roles.
+func (c *ContractParticipant) HasAnyRole(roles ...Role) bool {
+	for _, role := range roles {
+		if c.IsRole(role) {
+			return true
+		}
 	}
 
 	return false
 }
+
+// ParticipantsWithRole returns the participants who have the role.
+func (c *ContractBlock) ParticipantsWithRole(role Role) []*ContractParticipant {
+	if c == nil {
+		return nil
+	}
+
+	participants := []*ContractParticipant{}
+	for i := range c.Participants {
+		if c.Participants[i].IsRole(role) {
+			participants = append(participants, &c.Participants[i])
+		}
+	}
+
+	return participants
+}
+
+// RoleCounts returns the number of participants with each role.
+// A participant with several roles is counted once for each role.
+func (c *ContractBlock) RoleCounts() map[Role]int {
+	counts := map[Role]int{}
+	if c == nil {
+		return counts
+	}
+
+	for _, p := range c.Participants {
+		seen := map[Role]bool{}
+		for _, r := range p.Roles {
+			if !seen[Role(r)] {
+				seen[Role(r)] = true
+				counts[Role(r)]++
+			}
+		}
+	}
+
+	return counts
+}
@@ -0,0 +1,99 @@
+package contract
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParticipantRoles(t *testing.T) {
+	cb := &ContractBlock{
+		Participants: []ContractParticipant{
+			{UserId: "c102", Roles: []string{"creator", "signatory"}},
+			{UserId: "c103", Roles: []string{"beneficiary", "signatory", "approver"}},
+			{UserId: "n48", Roles: []string{"notary"}},
+		},
+	}
+
+	p := &cb.Participants[0]
+	if !p.IsRole(Signatory) || !p.IsRole(Creator) {
+		t.Error("expected creator to be a signatory")
+	}
+
+	if p.IsRole(Notary) || p.IsRole("") {
+		t.Error("unexpected role")
+	}
+
+	if !p.HasAnyRole(Notary, Signatory) || p.HasAnyRole(Notary, Verifier) {
+		t.Error("unexpected result of HasAnyRole")
+	}
+
+	if n := cb.GetSignatoryCountFromParticipants(); n != 2 {
+		t.Errorf("expected 2 signatories, got %d", n)
+	}
+
+	approvers := cb.ParticipantsWithRole(Approver)
+	if len(approvers) != 1 || approvers[0].UserId != "c103" {
+		t.Errorf("unexpected approvers %v", approvers)
+	}
+
+	want := map[Role]int{Creator: 1, Signatory: 2, Beneficiary: 1, Approver: 1, Notary: 1}
+	got := cb.RoleCounts()
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+
+	for r, n := range want {
+		if got[r] != n {
+			t.Errorf("role %v: expected %d, got %d", r, n, got[r])
+		}
+	}
+}
+
+func TestRoleIsKnown(t *testing.T) {
+	for _, r := range KnownRoles() {
+		if !r.IsKnown() {
+			t.Errorf("role %v is not known", r)
+		}
+	}
+
+	for _, r := range []Role{"", "witness", Agreement} {
+		if r.IsKnown() {
+			t.Errorf("role %q should not be known", r)
+		}
+	}
+}
+
+func TestContentSignatureJSON(t *testing.T) {
+	// the tag of content signatures anchored before must not change, or their package hashes would
+	data, err := json.Marshal(ContentSignature{ContentId: 11})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != `{"content__id":11}` {
+		t.Errorf("unexpected content signature JSON %s", data)
+	}
+}
+
+func TestContentSignatureHashOfAnchoredPackage(t *testing.T) {
+	// a signature package anchored when content signatures only held the content id, and its anchored hash
+	anchored := `{"signature_id":"s-1","contract_id":1001,"contract_hash":"h","user_id":"c102","user_full_name":"Ann Author",` +
+		`"date_signed":"2024-01-02T09:00:00Z","ip_address":"10.0.0.1","signature_provider":"Subskribo","signature_type":"advanced",` +
+		`"key_info":{"key_id":"key-c102","key_type":"rsa2048","key_source":"local","key_fingerprint":"","x509_certificate":""},` +
+		`"is_approver":false,"content_signatures":[{"content__id":11}]}`
+	anchoredHash := "24TpeQScBxj/jkTK+13peZFu331p5R66RmAYnHIMa4A="
+
+	pkg := ContractSignaturePackage{}
+	if err := json.Unmarshal([]byte(anchored), &pkg); err != nil {
+		t.Fatal(err)
+	}
+
+	hash, err := JsonHashS256(pkg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if hash != anchoredHash {
+		t.Errorf("hash = %s, want the anchored hash %s", hash, anchoredHash)
+	}
+}
@@ -0,0 +1,75 @@
+package contract
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Stable codes of validation errors, used by clients to tell failures apart.
+// Together with the path of the error they identify the failed rule.
+const (
+	CodeRequired    = "required"     // the value is missing
+	CodeInvalid     = "invalid"      // the value is not allowed
+	CodeMismatch    = "mismatch"     // the value does not match a related value
+	CodeOutOfRange  = "out_of_range" // the value is outside the allowed range
+	CodeUnsupported = "unsupported"  // the schema or method is not supported by the chaincode
+	CodeNotFound    = "not_found"    // the contract is not anchored on ledger
+	CodeState       = "state"        // the change is not allowed in the current state of the contract
+)
+
+// ValidationError is a single failed validation rule.
+type ValidationError struct {
+	Code    string `json:"code"`
+	Path    string `json:"path"` // JSON pointer to the value, example /contract/participants/3/kyc_level
+	Message string `json:"message"`
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+// ValidationErrors aggregates the errors of a validation, so all problems are reported in one pass.
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, ve := range e {
+		msgs[i] = ve.Message
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
+// Add adds an error for the value at path.
+func (e *ValidationErrors) Add(code string, path string, msg string) {
+	*e = append(*e, &ValidationError{code, path, msg})
+}
+
+// Append adds the errors of a nested validation, prefixing their paths with the path of the nested value.
+// An error which is not a validation error is added as invalid at the prefix.
+func (e *ValidationErrors) Append(prefix string, err error) {
+	switch v := err.(type) {
+	case nil:
+	case ValidationErrors:
+		for _, ve := range v {
+			e.Add(ve.Code, prefix+ve.Path, ve.Message)
+		}
+	case *ValidationError:
+		e.Add(v.Code, prefix+v.Path, v.Message)
+	default:
+		e.Add(CodeInvalid, prefix, err.Error())
+	}
+}
+
+// Err returns the errors as an error, or nil if there are none.
+func (e ValidationErrors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+
+	return e
+}
+
+func pathf(format string, a ...any) string {
+	return fmt.Sprintf(format, a...)
+}
@@ -0,0 +1,33 @@
+package contract
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+// JsonHashS256 returns the SHA256 hash, Base64 encoded, of the compacted JSON representation of data.
+// This is how the hashes chaining the blocks of a contract are derived.
+func JsonHashS256(data any) (string, error) {
+	if data == nil {
+		return "", errors.New("data is nil")
+	}
+
+	bytesData, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+
+	var bb bytes.Buffer
+	if err := json.Compact(&bb, bytesData); err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+
+	h.Write(bb.Bytes())
+
+	return base64.StdEncoding.EncodeToString(h.Sum(nil)), nil
+}
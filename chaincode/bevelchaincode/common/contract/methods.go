@@ -3,6 +3,7 @@ package contract
 import (
 	"errors"
 	"fmt"
+	"strings"
 )
 
 const SHA256_HASH_BASE64_LENGTH = 44
@@ -17,316 +18,925 @@ func (cd *ContractDefinition) UserRoleDefinition(role string) *ContractUserRoleD
 	return nil
 }
 
-func (cb *ContractBlock) Validate() error {
+// Validate validates the contract block against its definition and the rules, DefaultRules if r is nil.
+func (cb *ContractBlock) Validate(r *Rules) error {
+	errs := ValidationErrors{}
 	d := cb.Definition
+	r = r.orDefault()
+
+	if cb.ContractID < 1 {
+		errs.Add(CodeRequired, "/contract_id", "invalid contract id")
+	}
+
+	if cb.SealedOnDate.IsZero() {
+		errs.Add(CodeRequired, "/sealed_on_date", "contract block is not sealed")
+	}
 
 	if cb.ContractFamilyId != d.ContractFamilyId {
-		return errors.New("invalid contract family id")
+		errs.Add(CodeMismatch, "/contract_family_id", "invalid contract family id")
 	}
 
 	if cb.ContractTypeId != d.ContractType {
-		return errors.New("invalid contract type id")
+		errs.Add(CodeMismatch, "/contract_type_id", "invalid contract type id")
 	}
 
 	if cb.ContractTypeVersion == 0 {
-		return errors.New("invalid contract type version")
+		errs.Add(CodeRequired, "/contract_type_version", "invalid contract type version")
 	}
 
-	if cb.StorageYears < 1 || cb.StorageYears > 30 {
-		return errors.New("invalid storage years")
+	if cb.StorageYears < r.MinStorageYears || cb.StorageYears > r.MaxStorageYears {
+		errs.Add(CodeOutOfRange, "/storage_years", "invalid storage years")
 	}
 
 	opt := cb.ContractOptions
 
-	if opt.ExpiryDate != nil {
-		if opt.ExpiryDate.Before(cb.SealedOnDate) {
-			return errors.New("invalid expiry date")
-		}
+	if opt.ExpiryDate != nil && opt.ExpiryDate.Before(cb.SealedOnDate) {
+		errs.Add(CodeInvalid, "/contract_options/expiry_date", "invalid expiry date")
+	}
 
-		if opt.DaysToSign < 1 {
-			return errors.New("invalid days to sign")
-		}
+	if opt.DaysToSign < 1 {
+		errs.Add(CodeOutOfRange, "/contract_options/days_to_sign", "invalid days to sign")
+	}
+
+	if opt.AllowSignatureExtension && opt.MaxDaysToSign < opt.DaysToSign {
+		errs.Add(CodeOutOfRange, "/contract_options/max_days_to_sign", "invalid days to sign extension")
+	}
 
-		if opt.AllowSignatureExtension && opt.MaxDaysToSign < 1 {
-			return errors.New("invalid days to sign extension")
+	errs.Append("/signature_method", cb.SignatureMethod.Validate(r))
+
+	if cb.ReleaseInstructions != nil {
+		errs.Append("/release_instructions", cb.ReleaseInstructions.Validate(r, d.Options.EvidenceRequiredForConditionalRelease))
+	}
+
+	errs.Append("", cb.ValidateRoles())
+	errs.Append("", cb.ValidateOrganizations())
+	errs.Append("", cb.ValidateIdentityClaims())
+
+	if cb.ProxyInstructions != nil {
+		if cb.ReleaseInstructions == nil {
+			errs.Add(CodeInvalid, "/proxy_instructions", "proxy instructions are only for a conditional release contract")
 		}
+
+		errs.Append("/proxy_instructions", cb.ProxyInstructions.Validate())
 	}
 
-	return nil
+	return errs.Err()
 }
 
-func (sm *SignatureMethod) Validate() error {
-	if sm.SignatureType == "" {
-		return errors.New("invalid signature type")
-	}
+func (sm *SignatureMethod) Validate(r *Rules) error {
+	errs := ValidationErrors{}
+	r = r.orDefault()
 
-	if sm.SignatureType != "advanced" && sm.SignatureType != "qualified" {
-		return errors.New("invalid signature type")
+	if sm.SignatureType == "" {
+		errs.Add(CodeRequired, "/signature_type", "invalid signature type")
+	} else if !containsValue(r.SignatureTypes, sm.SignatureType) {
+		errs.Add(CodeInvalid, "/signature_type", "invalid signature type")
 	}
 
 	if sm.PackageMethodId != 1 && sm.PackageMethodId != 2 && sm.PackageMethodId != 3 {
-		return errors.New("invalid package method id")
+		errs.Add(CodeInvalid, "/package_method_id", "invalid package method id")
 	}
 
-	if sm.SignatureProvider != "Subskribo" && sm.SignatureProvider != "Connective" {
-		return errors.New("invalid signature provider")
+	if !containsValue(r.SignatureProviders, sm.SignatureProvider) {
+		errs.Add(CodeInvalid, "/signature_provider", "invalid signature provider")
 	}
 
-	return nil
+	return errs.Err()
 }
 
-const (
-	InstructionMinLength = 60
-)
+func (rid *ReleaseInstructionDetail) Validate(r *Rules, evidenceRequired bool) error {
+	errs := ValidationErrors{}
+	r = r.orDefault()
 
-func (rid *ReleaseInstructionDetail) Validate(evidenceRequired bool) error {
-	if len(rid.Instructions) < InstructionMinLength {
-		return errors.New("invalid instructions")
+	if len(rid.Instructions) < r.InstructionMinLength {
+		errs.Add(CodeOutOfRange, "/instructions", "invalid instructions")
 	}
 
 	if !rid.IsCustomRelease {
 		if rid.StandardReleaseTemplateId < 1 {
-			return errors.New("invalid standard release template id")
+			errs.Add(CodeRequired, "/standard_release_template_id", "invalid standard release template id")
 		}
 	} else {
 		if rid.NotaryPackage != nil {
 			if rid.NotarySignature == "" {
-				return errors.New("invalid notary signature")
+				errs.Add(CodeRequired, "/notary_signature", "invalid notary signature")
 			}
 
 			if rid.NotaryPackage.ApprovalState != "none" {
 				if rid.AcceptancePackage == nil {
-					return errors.New("invalid acceptance package")
+					errs.Add(CodeRequired, "/acceptance_package", "invalid acceptance package")
 				}
 
 				if rid.AcceptanceSignature == "" {
-					return errors.New("invalid acceptance signature")
+					errs.Add(CodeRequired, "/acceptance_signature", "invalid acceptance signature")
 				}
 			}
 		} else {
 			// verifiers
 			if rid.ConsensusMethod == "" {
-				return errors.New("invalid consensus method")
+				errs.Add(CodeRequired, "/consensus_method", "invalid consensus method")
 			}
 
 			if evidenceRequired {
 				if !rid.IsEvidenceRequiredForRelease {
-					return errors.New("invalid evidence required for release flag")
+					errs.Add(CodeMismatch, "/is_evidence_required_for_release", "invalid evidence required for release flag")
 				}
 			}
 		}
 	}
-	return nil
+
+	return errs.Err()
 }
 
 func (pi *ContractProxyInstructions) Validate() error {
+	errs := ValidationErrors{}
 
 	if pi.VisibleToAll {
 		if pi.Instructions == "" {
-			return errors.New("invalid instructions")
+			errs.Add(CodeRequired, "/instructions", "invalid instructions")
 		}
 	}
 
 	if pi.InstructionsHash == "" {
-		return errors.New("invalid instructions hash")
+		errs.Add(CodeRequired, "/instructions_hash", "invalid instructions hash")
 	}
 
-	return nil
+	return errs.Err()
 }
 
 func (cc *ConstructedContentItem) Validate() error {
+	errs := ValidationErrors{}
 
 	if cc.ContentId < 1 {
-		return errors.New("invalid content id")
+		errs.Add(CodeRequired, "/content_id", "invalid content id")
 	}
 
 	if cc.PlainHash == "" {
-		return errors.New("invalid plain hash")
+		errs.Add(CodeRequired, "/plain_hash", "invalid plain hash")
 	}
 
-	return nil
+	return errs.Err()
 }
 
 func (sp *ContractSignaturePackage) Validate(signatureMethodId int64) error {
+	errs := ValidationErrors{}
 
 	if sp.ContractId < 1 {
-		return errors.New("invalid contract id")
+		errs.Add(CodeRequired, "/contract_id", "invalid contract id")
 	}
 
 	if sp.ContractHash == "" {
-		return errors.New("invalid contract hash")
+		errs.Add(CodeRequired, "/contract_hash", "invalid contract hash")
 	}
 
 	if sp.UserId == "" {
-		return errors.New("invalid user id")
+		errs.Add(CodeRequired, "/user_id", "invalid user id")
 	}
 
 	if sp.UserFullName == "" {
-		return errors.New("invalid user full name")
+		errs.Add(CodeRequired, "/user_full_name", "invalid user full name")
 	}
 
 	if sp.DateSigned.IsZero() {
-		return errors.New("invalid date signed")
+		errs.Add(CodeRequired, "/date_signed", "invalid date signed")
 	}
 
 	if sp.SignatureType == "" {
-		return errors.New("invalid signature type")
+		errs.Add(CodeRequired, "/signature_type", "invalid signature type")
 	}
 
 	if signatureMethodId != 3 {
 		if sp.SignatureId == "" {
-			return errors.New("invalid signature id")
+			errs.Add(CodeRequired, "/signature_id", "invalid signature id")
 		}
 
 		if sp.IpAddress == "" {
-			return errors.New("invalid ip address")
+			errs.Add(CodeRequired, "/ip_address", "invalid ip address")
 		}
 
 		if sp.SignatureProvider == "" {
-			return errors.New("invalid signature provider")
+			errs.Add(CodeRequired, "/signature_provider", "invalid signature provider")
+		}
+
+		errs.Append("/key_info", sp.KeyInfo.Validate())
+	}
+
+	return errs.Err()
+}
+
+func (cs *ContentSignature) Validate() error {
+	errs := ValidationErrors{}
+
+	if cs.ContentId < 1 {
+		errs.Add(CodeRequired, "/content__id", "invalid content id")
+	}
+
+	if len(cs.ContentHash) != SHA256_HASH_BASE64_LENGTH {
+		errs.Add(CodeInvalid, "/content_hash", "content hash not set or incorrect length")
+	}
+
+	if cs.Signature == "" {
+		errs.Add(CodeRequired, "/signature", "missing signature")
+	}
+
+	if cs.DateSigned == nil || cs.DateSigned.IsZero() {
+		errs.Add(CodeRequired, "/date_signed", "invalid date signed")
+	}
+
+	if cs.KeyInfo == nil {
+		errs.Add(CodeRequired, "/key_info", "missing key info")
+	} else {
+		errs.Append("/key_info", cs.KeyInfo.Validate())
+	}
+
+	return errs.Err()
+}
+
+// ValidateContentSignatures validates the content signatures of a signature package against the content items of the contract block.
+// Each content item may be signed once, and the signed hash must be the plain or encrypted hash of the content item.
+func (cb *ContractBlock) ValidateContentSignatures(sigs []ContentSignature) error {
+	errs := ValidationErrors{}
+	signed := map[int64]bool{}
+
+	for i, cs := range sigs {
+		path := pathf("/%d", i)
+
+		errs.Append(path, cs.Validate())
+
+		if cs.DateSigned != nil && !cs.DateSigned.IsZero() && cs.DateSigned.Before(cb.SealedOnDate) {
+			errs.Add(CodeOutOfRange, path+"/date_signed", fmt.Sprintf("content id %d signed before the contract was sealed", cs.ContentId))
 		}
 
-		if err := sp.KeyInfo.Validate(); err != nil {
-			return err
+		if signed[cs.ContentId] {
+			errs.Add(CodeInvalid, path+"/content__id", fmt.Sprintf("content id %d is signed more than once", cs.ContentId))
 		}
+		signed[cs.ContentId] = true
 
-		if sp.ContractHash == "" {
-			return errors.New("invalid contract hash")
+		item := cb.ContentItem(cs.ContentId)
+		if item == nil {
+			errs.Add(CodeInvalid, path+"/content__id", fmt.Sprintf("content id %d is not a content item of the contract", cs.ContentId))
+			continue
 		}
 
+		if cs.ContentHash != item.PlainHash && (item.EncryptedHash == "" || cs.ContentHash != item.EncryptedHash) {
+			errs.Add(CodeMismatch, path+"/content_hash", fmt.Sprintf("content hash does not match the plain or encrypted hash of content id %d", cs.ContentId))
+		}
 	}
 
-	return nil
+	return errs.Err()
 }
 
 func (k *KeyInfo) Validate() error {
+	errs := ValidationErrors{}
 
 	if k.X509Certificate == "" {
 		if k.KeyId == "" {
-			return errors.New("invalid key id")
+			errs.Add(CodeRequired, "/key_id", "invalid key id")
 		}
 
 		if k.KeyType == "" {
-			return errors.New("invalid key type")
+			errs.Add(CodeRequired, "/key_type", "invalid key type")
 		}
 
 		if k.KeySource == "" {
-			return errors.New("invalid key source")
+			errs.Add(CodeRequired, "/key_source", "invalid key source")
 		}
 	}
 
-	return nil
+	return errs.Err()
+}
+
+// Validate validates the immutable contract as a whole: the contract block, the chaining of hashes between blocks,
+// each signature package, the finalized content, the order of sealed on dates, and that the signatures are complete.
+// The contract block is validated against the rules, DefaultRules if r is nil.
+func (c *ImmutableContract) Validate(r *Rules) error {
+	if c == nil {
+		return errors.New("contract container is nil for method: Validate")
+	}
+
+	errs := ValidationErrors{}
+
+	errs.Append("/contract", c.Contract.Validate(r))
+
+	contractHash, err := JsonHashS256(c.Contract)
+	if err != nil {
+		return err
+	}
+
+	if c.ContractHash != contractHash {
+		errs.Add(CodeMismatch, "/contract_hash", "contract hash does not match contract block")
+	}
+
+	if c.ContractSignatures.ContractHash != c.ContractHash {
+		errs.Add(CodeMismatch, "/contract_signatures/contract_hash", "contract hash in signatures block does not match contract hash")
+	}
+
+	signaturesHash, err := JsonHashS256(c.ContractSignatures)
+	if err != nil {
+		return err
+	}
+
+	if c.ContractSignaturesHash != signaturesHash {
+		errs.Add(CodeMismatch, "/contract_signatures_hash", "contract signatures hash does not match contract signatures block")
+	}
+
+	sealedOn := c.Contract.SealedOnDate
+	signaturesSealedOn := c.ContractSignatures.SealedOnDate
+	methodId := c.Contract.SignatureMethod.PackageMethodId
+
+	for i, sp := range c.ContractSignatures.Signatures {
+		path := pathf("/contract_signatures/signatures/%d", i)
+		pkg := &sp.ContractSignaturePackage
+
+		errs.Append(path+"/contract_signature_package", pkg.Validate(methodId))
+		errs.Append(path+"/contract_signature_package/content_signatures", c.Contract.ValidateContentSignatures(pkg.ContentSignatures))
+
+		for j, cs := range pkg.ContentSignatures {
+			if cs.DateSigned != nil && cs.DateSigned.After(signaturesSealedOn) {
+				errs.Add(CodeOutOfRange, pathf("%s/contract_signature_package/content_signatures/%d/date_signed", path, j),
+					formatSignedPackageMsg(pkg.UserId, pkg.UserFullName, fmt.Sprintf("content id %d signed after the signatures were sealed", cs.ContentId)))
+			}
+		}
+
+		if methodId != int64(SignPackageMethodId_Embedded) {
+			pkgHash, err := JsonHashS256(pkg)
+			if err != nil {
+				return err
+			}
+
+			if sp.ContractSignaturePackageHash != pkgHash {
+				errs.Add(CodeMismatch, path+"/contract_signature_package_hash", formatSignedPackageMsg(pkg.UserId, pkg.UserFullName, "package hash does not match signature package"))
+			}
+		}
+
+		if !pkg.DateSigned.IsZero() {
+			if pkg.DateSigned.Before(sealedOn) {
+				errs.Add(CodeOutOfRange, path+"/contract_signature_package/date_signed", formatSignedPackageMsg(pkg.UserId, pkg.UserFullName, "signed before the contract was sealed"))
+			} else if pkg.DateSigned.After(signaturesSealedOn) {
+				errs.Add(CodeOutOfRange, path+"/contract_signature_package/date_signed", formatSignedPackageMsg(pkg.UserId, pkg.UserFullName, "signed after the signatures were sealed"))
+			}
+		}
+	}
+
+	if d := c.ContractSignatures.ApproverSealedOnDate; d != nil && (d.Before(sealedOn) || d.After(signaturesSealedOn)) {
+		errs.Add(CodeOutOfRange, "/contract_signatures/approver_sealed_on_date", "approvers sealed on date is not between the contract and signatures sealed on dates")
+	}
+
+	if signaturesSealedOn.Before(sealedOn) {
+		errs.Add(CodeOutOfRange, "/contract_signatures/sealed_on_date", "signatures sealed before the contract was sealed")
+	}
+
+	if c.SealedOnDate.Before(signaturesSealedOn) {
+		errs.Add(CodeOutOfRange, "/sealed_on_date", "contract container sealed before the signatures were sealed")
+	}
+
+	// for the embedded package method finalized content is validated with the signatures
+	if c.FinalizedContent != nil && methodId != int64(SignPackageMethodId_Embedded) {
+		errs.Append("/finalized_content", c.FinalizedContent.Validate())
+	}
+
+	errs.Append("", c.ValidateSignaturesComplete())
+
+	return errs.Err()
 }
 
 func (c *ImmutableContract) ValidateSignaturesComplete() error {
 	if c == nil {
-		return fmt.Errorf("contract container is nil for method: ValidateSignaturesComplete")
+		return errors.New("contract container is nil for method: ValidateSignaturesComplete")
 	}
 
+	errs := ValidationErrors{}
+
 	if len(c.ContractSignatures.ContractHash) != SHA256_HASH_BASE64_LENGTH {
-		return fmt.Errorf("contract signatures block does not have a contract hash set, or is not of correct length")
+		errs.Add(CodeInvalid, "/contract_signatures/contract_hash", "contract signatures block does not have a contract hash set, or is not of correct length")
 	}
 
 	signedPackCount := len(c.ContractSignatures.Signatures)
 	if signedPackCount == 0 {
-		return fmt.Errorf("contract signatures block does not have any signature packages set")
+		errs.Add(CodeRequired, "/contract_signatures/signatures", "contract signatures block does not have any signature packages set")
+		return errs.Err()
 	}
 
 	signatoryCount := c.Contract.GetSignatoryCountFromParticipants()
 
 	if signedPackCount != signatoryCount {
-		return fmt.Errorf("contract signatures block does not have the same number of signature packages as there are signatories")
+		errs.Add(CodeMismatch, "/contract_signatures/signatures", "contract signatures block does not have the same number of signature packages as there are signatories")
 	}
 
 	isEmbedded := c.Contract.SignatureMethod.PackageMethodId == int64(SignPackageMethodId_Embedded)
 	requireConstructedContent := c.Contract.SignatureMethod.PackageMethodId == int64(SignPackageMethodId_Constructed)
 
 	if isEmbedded {
-		// if c.ContractSignatures.FinalizedContent == nil || c.ContractSignatures.FinalizedContent.ContentId == 0 {
-		// 	return fmt.Errorf("contract signatures block does not have a finalized content item set")
-		// }
+		errs.Append("/finalized_content", c.Contract.ValidateFinalizedContent(c.FinalizedContent))
 	}
 
-	// constructedContentItemId := int64(0)
+	constructedContentItemId := int64(0)
 
 	if requireConstructedContent {
-		// if len(c.ContractSignatures.ConstructedContentItems) == 0 {
-		// 	return fmt.Errorf("contract signatures block does not have any constructed content items set")
-		// }
-		// myItem := &c.ContractSignatures.ConstructedContentItems[0]
-		// constructedContentItemId = myItem.ContentId
-		// if constructedContentItemId == 0 || len(myItem.PlainHash) != SHA256_HASH_BASE64_LENGTH {
-		// 	return fmt.Errorf("contract signatures block does not have content id or else required parameters set for constructed content item")
-		// }
+		items := c.ContractSignatures.ConstructedContentItems
+		errs.Append("/contract_signatures/constructed_content_items", c.Contract.ValidateConstructedContent(items))
+
+		if len(items) > 0 {
+			constructedContentItemId = items[0].ContentId
+		}
 	}
 
-	found := false
+	errs.Append("", c.validateOrganizationSignatories())
+
 	for _, p := range c.Contract.Participants {
 		if p.IsRole(Signatory) {
-			for _, sp := range c.ContractSignatures.Signatures {
+			found := false
+			for i, sp := range c.ContractSignatures.Signatures {
 				if sp.ContractSignaturePackage.UserId == p.UserId {
 					found = true
-					if sp.ContractSignaturePackage.UserFullName == "" {
-						return formatSignedPackageErr(sp.ContractSignaturePackage.UserId, sp.ContractSignaturePackage.UserFullName, "full name not set")
+					path := pathf("/contract_signatures/signatures/%d", i)
+					pkg := &sp.ContractSignaturePackage
+
+					if pkg.UserFullName == "" {
+						errs.Add(CodeRequired, path+"/contract_signature_package/user_full_name", formatSignedPackageMsg(pkg.UserId, pkg.UserFullName, "full name not set"))
 					}
-					if sp.ContractSignaturePackage.DateSigned.IsZero() {
-						return formatSignedPackageErr(sp.ContractSignaturePackage.UserId, sp.ContractSignaturePackage.UserFullName, "signed on data not set")
+					if pkg.DateSigned.IsZero() {
+						errs.Add(CodeRequired, path+"/contract_signature_package/date_signed", formatSignedPackageMsg(pkg.UserId, pkg.UserFullName, "signed on data not set"))
 					}
-					if len(sp.ContractSignaturePackage.ContractHash) != SHA256_HASH_BASE64_LENGTH {
-						return formatSignedPackageErr(sp.ContractSignaturePackage.UserId, sp.ContractSignaturePackage.UserFullName, "package hash no set or incorrect length")
-					} else if c.ContractSignatures.ContractHash != sp.ContractSignaturePackage.ContractHash {
-						return formatSignedPackageErr(sp.ContractSignaturePackage.UserId, sp.ContractSignaturePackage.UserFullName, "contract hash does not match contract hash in signature package")
+					if len(pkg.ContractHash) != SHA256_HASH_BASE64_LENGTH {
+						errs.Add(CodeInvalid, path+"/contract_signature_package/contract_hash", formatSignedPackageMsg(pkg.UserId, pkg.UserFullName, "package hash no set or incorrect length"))
+					} else if c.ContractSignatures.ContractHash != pkg.ContractHash {
+						errs.Add(CodeMismatch, path+"/contract_signature_package/contract_hash", formatSignedPackageMsg(pkg.UserId, pkg.UserFullName, "contract hash does not match contract hash in signature package"))
 					}
-					if sp.ContractSignaturePackage.ContractId != c.Contract.ContractID {
-						return formatSignedPackageErr(sp.ContractSignaturePackage.UserId, sp.ContractSignaturePackage.UserFullName, "contract id does not match contract id in signature package")
+					if pkg.ContractId != c.Contract.ContractID {
+						errs.Add(CodeMismatch, path+"/contract_signature_package/contract_id", formatSignedPackageMsg(pkg.UserId, pkg.UserFullName, "contract id does not match contract id in signature package"))
 					}
 
-					if requireConstructedContent {
-						// if sp.ContractSignaturePackage.ConstructedContentId != constructedContentItemId {
-						// 	return formatSignedPackageErr(sp.ContractSignaturePackage.UserId, sp.ContractSignaturePackage.UserFullName, "constructed content id is not set correctly")
-						// }
+					if requireConstructedContent && pkg.ConstructedContentId != constructedContentItemId {
+						errs.Add(CodeMismatch, path+"/contract_signature_package/constructed_content_id", formatSignedPackageMsg(pkg.UserId, pkg.UserFullName, "constructed content id is not set correctly"))
 					}
 
 					if !isEmbedded {
 						// to do: validate key information set
 
 						if len(sp.ContractSignaturePackageHash) != SHA256_HASH_BASE64_LENGTH {
-							return formatSignedPackageErr(sp.ContractSignaturePackage.UserId, sp.ContractSignaturePackage.UserFullName, "package hash not of correct length")
+							errs.Add(CodeInvalid, path+"/contract_signature_package_hash", formatSignedPackageMsg(pkg.UserId, pkg.UserFullName, "package hash not of correct length"))
 						}
 
 						if sp.Signature == "" {
-							return formatSignedPackageErr(sp.ContractSignaturePackage.UserId, sp.ContractSignaturePackage.UserFullName, "missing signature")
+							errs.Add(CodeRequired, path+"/signature", formatSignedPackageMsg(pkg.UserId, pkg.UserFullName, "missing signature"))
 						} else if len(sp.Signature) != SIGNATURE_RSA2048_BASE64_LENGTH {
-							return formatSignedPackageErr(sp.ContractSignaturePackage.UserId, sp.ContractSignaturePackage.UserFullName, "signature is not of correct length ")
+							errs.Add(CodeInvalid, path+"/signature", formatSignedPackageMsg(pkg.UserId, pkg.UserFullName, "signature is not of correct length "))
 						}
 					}
 				}
+			}
+
+			if !found {
+				errs.Add(CodeRequired, "/contract_signatures/signatures", fmt.Sprintf("contract signatures block does not have a signature package for signatory user id '%v'", p.UserId))
+			}
+		}
+	}
+
+	return errs.Err()
+}
+
+// ValidateConstructedContent validates the constructed content items for the constructed package method.
+// The first item is the constructed agreement signed by each signee, built from content items of the contract block.
+func (cb *ContractBlock) ValidateConstructedContent(items []ConstructedContentItem) error {
+	errs := ValidationErrors{}
+
+	if len(items) == 0 {
+		errs.Add(CodeRequired, "", "contract signatures block does not have any constructed content items set")
+		return errs.Err()
+	}
+
+	item := &items[0]
+
+	errs.Append("/0", item.Validate())
+
+	if item.ItemRole != ConstructedAgreement {
+		errs.Add(CodeInvalid, "/0/item_role", fmt.Sprintf("constructed content item must have the role '%v'", ConstructedAgreement))
+	}
+
+	if item.PlainHash != "" && len(item.PlainHash) != SHA256_HASH_BASE64_LENGTH {
+		errs.Add(CodeInvalid, "/0/plain_hash", "constructed content item plain hash is not of correct length")
+	}
+
+	if len(item.OrigContentIds) == 0 {
+		errs.Add(CodeRequired, "/0/orig_content_ids", "constructed content item does not reference any content items")
+	}
+
+	for i, id := range item.OrigContentIds {
+		if !cb.HasContentItem(id) {
+			errs.Add(CodeInvalid, pathf("/0/orig_content_ids/%d", i), fmt.Sprintf("content id %d is not a content item of the contract", id))
+		}
+	}
+
+	return errs.Err()
+}
+
+// ValidateFinalizedContent validates the finalized content for the embedded package method,
+// the document holding the contract content with the embedded signatures.
+func (cb *ContractBlock) ValidateFinalizedContent(fc *ConstructedContentItem) error {
+	errs := ValidationErrors{}
+
+	if fc == nil || fc.ContentId == 0 {
+		errs.Add(CodeRequired, "", "contract does not have a finalized content item set")
+		return errs.Err()
+	}
+
+	if fc.ItemRole != FinalizedAgreement {
+		errs.Add(CodeInvalid, "/item_role", fmt.Sprintf("finalized content item must have the role '%v'", FinalizedAgreement))
+	}
+
+	if len(fc.PlainHash) != SHA256_HASH_BASE64_LENGTH {
+		errs.Add(CodeInvalid, "/plain_hash", "finalized content item plain hash is not set or of correct length")
+	}
+
+	for i, id := range fc.OrigContentIds {
+		if !cb.HasContentItem(id) {
+			errs.Add(CodeInvalid, pathf("/orig_content_ids/%d", i), fmt.Sprintf("content id %d is not a content item of the contract", id))
+		}
+	}
+
+	return errs.Err()
+}
+
+// ValidateRoles checks that the roles of participants and of the definition are known roles.
+func (cb *ContractBlock) ValidateRoles() error {
+	errs := ValidationErrors{}
+
+	for i, p := range cb.Participants {
+		if len(p.Roles) == 0 {
+			errs.Add(CodeRequired, pathf("/participants/%d/roles", i), fmt.Sprintf("user id '%v' has no roles", p.UserId))
+		}
+
+		for j, r := range p.Roles {
+			if !Role(r).IsKnown() {
+				errs.Add(CodeInvalid, pathf("/participants/%d/roles/%d", i, j), fmt.Sprintf("unknown role '%v' for user id '%v'", r, p.UserId))
+			}
+		}
+	}
+
+	for i, ur := range cb.Definition.UserRoles {
+		if !Role(ur.Role).IsKnown() {
+			errs.Add(CodeInvalid, pathf("/definition/user_roles/%d/role", i), fmt.Sprintf("unknown role '%v' in definition", ur.Role))
+		}
+	}
+
+	return errs.Err()
+}
+
+// ValidateIdentityClaims validates the identity claims of each participant against the definition of each of their roles.
+// Required claims must be present and every claim must come from a verifier accepted for the role.
+// The kyc level of a participant must meet the minimum for their roles and be supported by their claims.
+func (cb *ContractBlock) ValidateIdentityClaims() error {
+	errs := ValidationErrors{}
+	d := &cb.Definition
+	opt := cb.ContractOptions
 
-				if !found {
-					return fmt.Errorf("contract signatures block does not have a signature package for signatory user id '%v'", p.UserId)
+	if opt.IsMinKycLevelForAllRoles && !d.Options.AllowMinKycLevelForAllRoles {
+		errs.Add(CodeInvalid, "/contract_options/is_min_kyc_level_for_all_roles", "min kyc level for all roles is not allowed by the definition")
+	}
+
+	for i, p := range cb.Participants {
+		path := pathf("/participants/%d", i)
+		rejected := map[int]bool{}
+
+		for _, role := range p.Roles {
+			rd := d.UserRoleDefinition(role)
+			if rd == nil {
+				continue
+			}
+
+			minKycLevel := rd.MinKycLevel
+			if opt.IsMinKycLevelForAllRoles && d.Options.AllowMinKycLevelForAllRoles {
+				minKycLevel = opt.MinKycLevelForAllRoles
+			}
+
+			if p.KycLevel < minKycLevel {
+				errs.Add(CodeOutOfRange, path+"/kyc_level", fmt.Sprintf("user id '%v' has kyc level %d, role '%v' requires at least %d", p.UserId, p.KycLevel, role, minKycLevel))
+			}
+
+			for _, claim := range rd.RequiredClaims {
+				if p.IdentityClaim(claim) == nil {
+					errs.Add(CodeRequired, path+"/identity_claims", fmt.Sprintf("user id '%v' with role '%v' is missing identity claim '%v'", p.UserId, role, claim))
+				}
+			}
+
+			for j, ic := range p.IdentityClaims {
+				if !rejected[j] && !rd.AcceptsVerifier(ic.Verifier) {
+					rejected[j] = true
+					errs.Add(CodeInvalid, pathf("%s/identity_claims/%d/verifier", path, j),
+						fmt.Sprintf("identity claim '%v' of user id '%v' is verified by '%v', which is not accepted for role '%v'", ic.Claim, p.UserId, ic.Verifier, role))
+				}
+			}
+		}
+
+		supportedKycLevel := int64(0)
+		for j, ic := range p.IdentityClaims {
+			if ic.Claim == "" {
+				errs.Add(CodeRequired, pathf("%s/identity_claims/%d/claim", path, j), fmt.Sprintf("identity claim of user id '%v' has no claim set", p.UserId))
+			}
+
+			if !rejected[j] && ic.KycLevel > supportedKycLevel {
+				supportedKycLevel = ic.KycLevel
+			}
+		}
+
+		if p.KycLevel > supportedKycLevel {
+			errs.Add(CodeOutOfRange, path+"/kyc_level", fmt.Sprintf("kyc level %d of user id '%v' is not supported by their identity claims", p.KycLevel, p.UserId))
+		}
+	}
+
+	return errs.Err()
+}
+
+// IdentityClaim returns the identity claim of the participant for the claim name, or nil if the participant does not have it.
+func (c *ContractParticipant) IdentityClaim(claim string) *ContractIdentityClaim {
+	if c == nil {
+		return nil
+	}
+
+	for i := range c.IdentityClaims {
+		if c.IdentityClaims[i].Claim == claim {
+			return &c.IdentityClaims[i]
+		}
+	}
+
+	return nil
+}
+
+// AcceptsVerifier returns true if identity claims from the verifier are accepted for the role.
+func (rd *ContractUserRoleDefinition) AcceptsVerifier(verifier string) bool {
+	if len(rd.AcceptedVerifiers) == 0 {
+		return true
+	}
+
+	for _, v := range rd.AcceptedVerifiers {
+		if v == verifier {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ValidateOrganizations validates the organizations of the contract and the positions of participants in them.
+// A virtual organization, added by the author for this contract, has a negative org id.
+// Every position must reference an organization of the contract, so virtual positions must be trimmed before sealing.
+func (cb *ContractBlock) ValidateOrganizations() error {
+	errs := ValidationErrors{}
+	seen := map[int64]bool{}
+
+	for i, o := range cb.Organizations {
+		path := pathf("/organizations/%d", i)
+
+		if o.OrgId == 0 {
+			errs.Add(CodeRequired, path+"/org_id", "invalid org id")
+		} else if seen[o.OrgId] {
+			errs.Add(CodeInvalid, path+"/org_id", fmt.Sprintf("organization %d is listed more than once", o.OrgId))
+		}
+		seen[o.OrgId] = true
+
+		if o.IsVirtual != (o.OrgId < 0) {
+			errs.Add(CodeInvalid, path+"/is_virtual", fmt.Sprintf("organization %d: a virtual organization must have a negative org id", o.OrgId))
+		}
+
+		for _, sp := range o.SignatoryPositions() {
+			if containsPosition(o.NonSignatoryPositions(), sp) {
+				errs.Add(CodeInvalid, path+"/non_signatories", fmt.Sprintf("organization %d: position '%v' is both a signatory and a non-signatory", o.OrgId, sp))
+			}
+		}
+	}
+
+	for i, p := range cb.Participants {
+		for j, pos := range p.Positions {
+			path := pathf("/participants/%d/positions/%d", i, j)
+
+			o := cb.Organization(pos.OrgId)
+			if o == nil {
+				errs.Add(CodeInvalid, path+"/org_id", fmt.Sprintf("position of user id '%v' references organization %d which is not in the contract", p.UserId, pos.OrgId))
+				continue
+			}
+
+			if pos.IsVirtual != o.IsVirtual {
+				errs.Add(CodeMismatch, path+"/is_virtual", fmt.Sprintf("position of user id '%v' does not match whether organization %d is virtual", p.UserId, pos.OrgId))
+			}
+		}
+	}
+
+	for i, vp := range cb.VirtualPositions {
+		path := pathf("/virtual_positions/%d", i)
+
+		if cb.Organization(vp.OrgId) == nil {
+			errs.Add(CodeInvalid, path+"/org_id", fmt.Sprintf("virtual position of user id '%v' references organization %d which is not in the contract and was not trimmed", vp.UserId, vp.OrgId))
+		}
+
+		if cb.Participant(vp.UserId) == nil {
+			errs.Add(CodeInvalid, path+"/user_id", fmt.Sprintf("virtual position references user id '%v' who is not a participant", vp.UserId))
+		}
+	}
+
+	return errs.Err()
+}
+
+// TrimVirtualPositions removes the virtual positions which do not have a corresponding organization in the contract.
+// Called just before sealing the contract block.
+func (cb *ContractBlock) TrimVirtualPositions() {
+	if cb == nil {
+		return
+	}
+
+	trimmed := []ContractParticipantVirtualPosition{}
+	for _, vp := range cb.VirtualPositions {
+		if cb.Organization(vp.OrgId) != nil {
+			trimmed = append(trimmed, vp)
+		}
+	}
+
+	cb.VirtualPositions = trimmed
+}
+
+// validateOrganizationSignatories checks that each signatory position of an organization
+// is covered by a signature package of a participant holding the position in the organization.
+func (c *ImmutableContract) validateOrganizationSignatories() error {
+	errs := ValidationErrors{}
+
+	for i, o := range c.Contract.Organizations {
+		for _, position := range o.SignatoryPositions() {
+			covered := false
+			for _, sp := range c.ContractSignatures.Signatures {
+				if c.Contract.HoldsPosition(sp.ContractSignaturePackage.UserId, o.OrgId, position) {
+					covered = true
+					break
 				}
 			}
+
+			if !covered {
+				errs.Add(CodeRequired, pathf("/contract/organizations/%d/signatories", i),
+					fmt.Sprintf("organization '%v' requires a signature from position '%v'", o.LegalName, position))
+			}
+		}
+	}
+
+	return errs.Err()
+}
+
+// SignatoryPositions returns the positions required to sign for the organization.
+func (o *ContractOrganization) SignatoryPositions() []string {
+	return splitPositions(o.Signatories)
+}
+
+// NonSignatoryPositions returns the positions which cannot sign for the organization.
+func (o *ContractOrganization) NonSignatoryPositions() []string {
+	return splitPositions(o.NonSignatories)
+}
+
+// Organization returns the organization with the org id, or nil if the contract block does not have it.
+func (cb *ContractBlock) Organization(orgId int64) *ContractOrganization {
+	if cb == nil {
+		return nil
+	}
+
+	for i := range cb.Organizations {
+		if cb.Organizations[i].OrgId == orgId {
+			return &cb.Organizations[i]
 		}
 	}
 
 	return nil
 }
 
-func formatSignedPackageErr(userId string, userName string, msgPostfix string) error {
-	return fmt.Errorf("contract signature package for user id '%v' and name '%v', has error: %v", userId, userName, msgPostfix)
+// Participant returns the participant with the user id, or nil if the contract block does not have it.
+func (cb *ContractBlock) Participant(userId string) *ContractParticipant {
+	if cb == nil {
+		return nil
+	}
+
+	for i := range cb.Participants {
+		if cb.Participants[i].UserId == userId {
+			return &cb.Participants[i]
+		}
+	}
+
+	return nil
+}
+
+// HoldsPosition returns true if the participant holds the position in the organization,
+// either as a current position or a virtual position added by the author.
+// A position listed as a non-signatory position of the organization is never held for signing.
+func (cb *ContractBlock) HoldsPosition(userId string, orgId int64, position string) bool {
+	p := cb.Participant(userId)
+	if p == nil {
+		return false
+	}
+
+	if o := cb.Organization(orgId); o == nil || containsPosition(o.NonSignatoryPositions(), position) {
+		return false
+	}
+
+	for _, pos := range p.Positions {
+		if pos.OrgId == orgId && strings.EqualFold(strings.TrimSpace(pos.Position), position) {
+			return true
+		}
+	}
+
+	for _, vp := range cb.VirtualPositions {
+		if vp.UserId == userId && vp.OrgId == orgId && strings.EqualFold(strings.TrimSpace(vp.Position), position) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func splitPositions(list string) []string {
+	positions := []string{}
+	for _, p := range strings.Split(list, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			positions = append(positions, p)
+		}
+	}
+
+	return positions
+}
+
+func containsPosition(positions []string, position string) bool {
+	for _, p := range positions {
+		if strings.EqualFold(p, position) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HasContentItem returns true if the contract block has a content item with the content id.
+func (cb *ContractBlock) HasContentItem(contentId int64) bool {
+	return cb.ContentItem(contentId) != nil
+}
+
+// ContentItem returns the content item with the content id, or nil if the contract block does not have it.
+func (cb *ContractBlock) ContentItem(contentId int64) *ContentItem {
+	if cb == nil {
+		return nil
+	}
+
+	for i := range cb.ContentItems {
+		if cb.ContentItems[i].ContentId == contentId {
+			return &cb.ContentItems[i]
+		}
+	}
+
+	return nil
+}
+
+func formatSignedPackageMsg(userId string, userName string, msgPostfix string) string {
+	return fmt.Sprintf("contract signature package for user id '%v' and name '%v', has error: %v", userId, userName, msgPostfix)
 }
 
 func (c *ContractBlock) GetSignatoryCountFromParticipants() int {
+	return len(c.ParticipantsWithRole(Signatory))
+}
+
+// IsConsensualAgreement returns true if the contract has an agreement content item.
+func (c *ContractBlock) IsConsensualAgreement() bool {
+	if c == nil {
+		return false
+	}
+
+	for _, ci := range c.ContentItems {
+		if ci.ItemRole == Agreement {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsVoidableByParticipants returns true if the contract and its definition allow voiding by consensus of the participants,
+// in which case the contract is only voided through a void proposal.
+func (c *ContractBlock) IsVoidableByParticipants() bool {
 	if c == nil {
-		return 0
+		return false
 	}
 
-	count := 0
+	return c.ContractOptions.VoidableByParticipants && c.Definition.Options.AllowVoidableByParticipants
+}
+
+// VoidConsensusParticipants returns the user ids of participants whose consensus is required to void the contract.
+// These are the participants flagged as able to void the contract,
+// and for a consensual agreement also every participant with a contractual role.
+func (c *ContractBlock) VoidConsensusParticipants() []string {
+	if c == nil {
+		return nil
+	}
+
+	consensual := c.IsConsensualAgreement()
+
+	userIds := []string{}
 	for _, p := range c.Participants {
-		if p.IsRole(Signatory) {
-			count++
+		if p.CanVoidContract || (consensual && p.IsRole(Contractual)) {
+			userIds = append(userIds, p.UserId)
 		}
 	}
 
-	return count
+	return userIds
 }
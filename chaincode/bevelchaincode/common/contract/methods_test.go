@@ -0,0 +1,639 @@
+package contract
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+var testSealedOn = time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+
+func testContractBlock() ContractBlock {
+	return ContractBlock{
+		ContractID:          1001,
+		SchemaVersion:       1,
+		Language:            "en",
+		ContractFamilyId:    2,
+		ContractTypeId:      7,
+		ContractTypeVersion: 1,
+		ContractName:        "Conditional release",
+		Participants: []ContractParticipant{
+			{UserId: "c102", Roles: []string{"signatory", "creator"}, FullName: "Ann Author", KycLevel: 2, IdentityClaims: []ContractIdentityClaim{
+				{IdentityClaimId: 1, Claim: "name", Value: "Ann Author", Verifier: "Subskribo", KycLevel: 2},
+			}},
+			{UserId: "c103", Roles: []string{"signatory", "beneficiary"}, FullName: "Bob Beneficiary", KycLevel: 2, IdentityClaims: []ContractIdentityClaim{
+				{IdentityClaimId: 2, Claim: "name", Value: "Bob Beneficiary", Verifier: "ItsMe", KycLevel: 2},
+				{IdentityClaimId: 3, Claim: "mobile-phone", Value: "...", Verifier: "Subskribo", KycLevel: 1},
+			}},
+		},
+		ContractOptions: ContractOptions{
+			DaysToSign:              7,
+			MaxDaysToSign:           14,
+			AllowSignatureExtension: true,
+		},
+		ContentItems: []ContentItem{
+			{ContentId: 11, ItemRole: Agreement, PlainHash: strings.Repeat("p", SHA256_HASH_BASE64_LENGTH)},
+		},
+		SignatureMethod: SignatureMethod{
+			PackageMethodId:   int64(SignPackageMethodId_OriginalContent),
+			SignatureType:     "advanced",
+			SignatureProvider: "Subskribo",
+		},
+		StorageYears: 10,
+		Definition: ContractDefinition{
+			ContractFamilyId:    2,
+			ContractType:        7,
+			ContractTypeVersion: 1,
+			SchemaVersion:       1,
+		},
+		DefinitionVersion: 2,
+		SealedOnDate:      testSealedOn,
+	}
+}
+
+// testImmutableContract returns a signed immutable contract which passes validation,
+// after applying edit to it. Hashes are computed after the edit unless the edit sets them.
+func testImmutableContract(t *testing.T, edit func(c *ImmutableContract)) *ImmutableContract {
+	t.Helper()
+
+	c := &ImmutableContract{Contract: testContractBlock()}
+	c.ContractSignatures.SealedOnDate = testSealedOn.Add(48 * time.Hour)
+	c.SealedOnDate = testSealedOn.Add(49 * time.Hour)
+
+	for i, p := range c.Contract.Participants {
+		c.ContractSignatures.Signatures = append(c.ContractSignatures.Signatures, SignedContractSignature{
+			ContractSignaturePackage: ContractSignaturePackage{
+				SignatureId:       p.UserId + "-sig",
+				ContractId:        c.Contract.ContractID,
+				UserId:            p.UserId,
+				UserFullName:      p.FullName,
+				DateSigned:        testSealedOn.Add(time.Duration(i+1) * time.Hour),
+				IpAddress:         "10.0.0.1",
+				SignatureProvider: "Subskribo",
+				SignatureType:     "advanced",
+				KeyInfo:           KeyInfo{KeyId: "key-" + p.UserId, KeyType: "rsa2048", KeySource: "local"},
+			},
+			Signature: strings.Repeat("s", SIGNATURE_RSA2048_BASE64_LENGTH),
+		})
+	}
+
+	if edit != nil {
+		edit(c)
+	}
+
+	sealHashes(t, c)
+
+	return c
+}
+
+// useConstructed switches c to the constructed package method, binding the constructed content into each signature.
+func useConstructed(c *ImmutableContract) {
+	c.Contract.SignatureMethod.PackageMethodId = int64(SignPackageMethodId_Constructed)
+	c.ContractSignatures.ConstructedContentItems = []ConstructedContentItem{{
+		ContentId:      21,
+		OrigContentIds: []int64{11},
+		ItemRole:       ConstructedAgreement,
+		PlainHash:      strings.Repeat("c", SHA256_HASH_BASE64_LENGTH),
+		ConstructTypes: "signature-placeholders",
+	}}
+
+	for i := range c.ContractSignatures.Signatures {
+		c.ContractSignatures.Signatures[i].ContractSignaturePackage.ConstructedContentId = 21
+	}
+}
+
+// useEmbedded switches c to the embedded package method with a finalized document.
+func useEmbedded(c *ImmutableContract) {
+	c.Contract.SignatureMethod.PackageMethodId = int64(SignPackageMethodId_Embedded)
+	c.FinalizedContent = &ConstructedContentItem{
+		ContentId:      31,
+		OrigContentIds: []int64{11},
+		ItemRole:       FinalizedAgreement,
+		PlainHash:      strings.Repeat("f", SHA256_HASH_BASE64_LENGTH),
+	}
+}
+
+// addClaimsPolicy requires a verified name and mobile phone for beneficiaries.
+func addClaimsPolicy(cb *ContractBlock) {
+	cb.Definition.UserRoles = []ContractUserRoleDefinition{
+		{Role: string(Creator), Min: 1, Max: 1, MinKycLevel: 1, RequiredClaims: []string{"name"}},
+		{Role: string(Beneficiary), Min: 1, Max: 10, MinKycLevel: 2, RequiredClaims: []string{"name", "mobile-phone"}, AcceptedVerifiers: []string{"Subskribo", "ItsMe"}},
+	}
+}
+
+// addOrganizations adds a registered organization with a director holding a position in it,
+// and a virtual organization with its owner.
+func addOrganizations(cb *ContractBlock) {
+	cb.Organizations = []ContractOrganization{
+		{OrgId: 40, LegalName: "Author Holding BV", Signatories: "Director", NonSignatories: "Intern"},
+		{OrgId: -1, IsVirtual: true, LegalName: "Bob's Bakery", Signatories: "Owner"},
+	}
+	cb.Participants[0].Positions = []ContractParticipantPosition{{OrgId: 40, OrgLegalName: "Author Holding BV", Position: "Director"}}
+	cb.Participants[1].Positions = []ContractParticipantPosition{{OrgId: -1, OrgLegalName: "Bob's Bakery", Position: "Owner", IsVirtual: true}}
+}
+
+// signContent adds a signature of the first content item to the signature package at index i.
+func signContent(c *ImmutableContract, i int) *ContentSignature {
+	pkg := &c.ContractSignatures.Signatures[i].ContractSignaturePackage
+	keyInfo, dateSigned := pkg.KeyInfo, pkg.DateSigned
+	pkg.ContentSignatures = append(pkg.ContentSignatures, ContentSignature{
+		ContentId:   c.Contract.ContentItems[0].ContentId,
+		ContentHash: c.Contract.ContentItems[0].PlainHash,
+		Signature:   strings.Repeat("s", SIGNATURE_RSA2048_BASE64_LENGTH),
+		KeyInfo:     &keyInfo,
+		DateSigned:  &dateSigned,
+	})
+
+	return &pkg.ContentSignatures[len(pkg.ContentSignatures)-1]
+}
+
+// sealHashes computes the hashes chaining the blocks of c, keeping any hash already set.
+func sealHashes(t *testing.T, c *ImmutableContract) {
+	t.Helper()
+
+	var err error
+
+	if c.ContractHash == "" {
+		if c.ContractHash, err = JsonHashS256(c.Contract); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if c.ContractSignatures.ContractHash == "" {
+		c.ContractSignatures.ContractHash = c.ContractHash
+	}
+
+	for i := range c.ContractSignatures.Signatures {
+		sp := &c.ContractSignatures.Signatures[i]
+		if sp.ContractSignaturePackage.ContractHash == "" {
+			sp.ContractSignaturePackage.ContractHash = c.ContractHash
+		}
+
+		if sp.ContractSignaturePackageHash == "" {
+			if sp.ContractSignaturePackageHash, err = JsonHashS256(sp.ContractSignaturePackage); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	if c.ContractSignaturesHash == "" {
+		if c.ContractSignaturesHash, err = JsonHashS256(c.ContractSignatures); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// assertErrors checks err holds a validation error with each of the codes at the paths, given as "code path".
+func assertErrors(t *testing.T, err error, want []string) {
+	t.Helper()
+
+	if len(want) == 0 {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return
+	}
+
+	errs, ok := err.(ValidationErrors)
+	if !ok {
+		t.Fatalf("expected validation errors, got %T: %v", err, err)
+	}
+
+	got := map[string]bool{}
+	for _, ve := range errs {
+		got[ve.Code+" "+ve.Path] = true
+	}
+
+	for _, w := range want {
+		if !got[w] {
+			t.Errorf("missing error %q in %v", w, got)
+		}
+	}
+}
+
+func TestContractBlockValidate(t *testing.T) {
+	expiry := testSealedOn.AddDate(0, 0, -1)
+
+	tests := []struct {
+		name string
+		edit func(cb *ContractBlock)
+		want []string
+	}{
+		{"valid", nil, nil},
+		{"missing contract id", func(cb *ContractBlock) { cb.ContractID = 0 }, []string{"required /contract_id"}},
+		{"not sealed", func(cb *ContractBlock) { cb.SealedOnDate = time.Time{} }, []string{"required /sealed_on_date"}},
+		{"family mismatch", func(cb *ContractBlock) { cb.ContractFamilyId = 3 }, []string{"mismatch /contract_family_id"}},
+		{"storage years", func(cb *ContractBlock) { cb.StorageYears = 31 }, []string{"out_of_range /storage_years"}},
+		{"expiry before sealed", func(cb *ContractBlock) { cb.ContractOptions.ExpiryDate = &expiry }, []string{"invalid /contract_options/expiry_date"}},
+		{"days to sign without expiry", func(cb *ContractBlock) { cb.ContractOptions.DaysToSign = 0 }, []string{"out_of_range /contract_options/days_to_sign"}},
+		{"max days below days to sign", func(cb *ContractBlock) { cb.ContractOptions.MaxDaysToSign = 3 }, []string{"out_of_range /contract_options/max_days_to_sign"}},
+		{"max days ignored without extension", func(cb *ContractBlock) {
+			cb.ContractOptions.AllowSignatureExtension = false
+			cb.ContractOptions.MaxDaysToSign = 0
+		}, nil},
+		{"signature method", func(cb *ContractBlock) {
+			cb.SignatureMethod = SignatureMethod{PackageMethodId: 4, SignatureType: "simple", SignatureProvider: "Other"}
+		}, []string{
+			"invalid /signature_method/package_method_id",
+			"invalid /signature_method/signature_type",
+			"invalid /signature_method/signature_provider",
+		}},
+		{"release instructions", func(cb *ContractBlock) {
+			cb.ReleaseInstructions = &ReleaseInstructionDetail{Instructions: "too short"}
+		}, []string{
+			"out_of_range /release_instructions/instructions",
+			"required /release_instructions/standard_release_template_id",
+		}},
+		{"valid release instructions", func(cb *ContractBlock) {
+			cb.ReleaseInstructions = &ReleaseInstructionDetail{Instructions: strings.Repeat("i", InstructionMinLength), StandardReleaseTemplateId: 1}
+		}, nil},
+		{"evidence required by definition", func(cb *ContractBlock) {
+			cb.Definition.Options.EvidenceRequiredForConditionalRelease = true
+			cb.ReleaseInstructions = &ReleaseInstructionDetail{
+				Instructions:    strings.Repeat("i", InstructionMinLength),
+				IsCustomRelease: true,
+				ConsensusMethod: "majority",
+			}
+		}, []string{"mismatch /release_instructions/is_evidence_required_for_release"}},
+		{"organizations", func(cb *ContractBlock) { addOrganizations(cb) }, nil},
+		{"virtual organization with positive id", func(cb *ContractBlock) {
+			addOrganizations(cb)
+			cb.Organizations[1].OrgId = 9
+			cb.Participants[1].Positions[0].OrgId = 9
+		}, []string{"invalid /organizations/1/is_virtual"}},
+		{"position of unknown organization", func(cb *ContractBlock) {
+			addOrganizations(cb)
+			cb.Participants[0].Positions[0].OrgId = 6
+		}, []string{"invalid /participants/0/positions/0/org_id"}},
+		{"position virtual flag", func(cb *ContractBlock) {
+			addOrganizations(cb)
+			cb.Participants[1].Positions[0].IsVirtual = false
+		}, []string{"mismatch /participants/1/positions/0/is_virtual"}},
+		{"virtual position not trimmed", func(cb *ContractBlock) {
+			addOrganizations(cb)
+			cb.VirtualPositions = []ContractParticipantVirtualPosition{{OrgId: -2, UserId: "c102", Position: "Partner"}}
+		}, []string{"invalid /virtual_positions/0/org_id"}},
+		{"virtual positions trimmed", func(cb *ContractBlock) {
+			addOrganizations(cb)
+			cb.VirtualPositions = []ContractParticipantVirtualPosition{
+				{OrgId: -2, UserId: "c102", Position: "Partner"},
+				{OrgId: -1, UserId: "c102", Position: "Partner"},
+			}
+			cb.TrimVirtualPositions()
+		}, nil},
+		{"identity claims policy", func(cb *ContractBlock) { addClaimsPolicy(cb) }, nil},
+		{"missing required claim", func(cb *ContractBlock) {
+			addClaimsPolicy(cb)
+			cb.Participants[1].IdentityClaims = cb.Participants[1].IdentityClaims[:1]
+		}, []string{"required /participants/1/identity_claims"}},
+		{"claim from unaccepted verifier", func(cb *ContractBlock) {
+			addClaimsPolicy(cb)
+			cb.Participants[1].IdentityClaims[1].Verifier = "Unknown"
+		}, []string{"invalid /participants/1/identity_claims/1/verifier"}},
+		{"kyc level below role minimum", func(cb *ContractBlock) {
+			addClaimsPolicy(cb)
+			cb.Definition.UserRoles[1].MinKycLevel = 3
+		}, []string{"out_of_range /participants/1/kyc_level"}},
+		{"kyc level not supported by claims", func(cb *ContractBlock) {
+			cb.Participants[0].KycLevel = 3
+		}, []string{"out_of_range /participants/0/kyc_level"}},
+		{"kyc level supported only by rejected claim", func(cb *ContractBlock) {
+			addClaimsPolicy(cb)
+			cb.Participants[1].IdentityClaims[0].Verifier = "Unknown"
+			cb.Participants[1].IdentityClaims[1].KycLevel = 1
+		}, []string{"invalid /participants/1/identity_claims/0/verifier", "out_of_range /participants/1/kyc_level"}},
+		{"min kyc level for all roles not allowed", func(cb *ContractBlock) {
+			cb.ContractOptions.IsMinKycLevelForAllRoles = true
+		}, []string{"invalid /contract_options/is_min_kyc_level_for_all_roles"}},
+		{"min kyc level for all roles", func(cb *ContractBlock) {
+			addClaimsPolicy(cb)
+			cb.Definition.Options.AllowMinKycLevelForAllRoles = true
+			cb.ContractOptions.IsMinKycLevelForAllRoles = true
+			cb.ContractOptions.MinKycLevelForAllRoles = 1
+			cb.Definition.UserRoles[1].MinKycLevel = 3
+		}, nil},
+		{"unknown participant role", func(cb *ContractBlock) {
+			cb.Participants[1].Roles = append(cb.Participants[1].Roles, "witness")
+		}, []string{"invalid /participants/1/roles/2"}},
+		{"participant without roles", func(cb *ContractBlock) {
+			cb.Participants[0].Roles = nil
+		}, []string{"required /participants/0/roles"}},
+		{"unknown definition role", func(cb *ContractBlock) {
+			cb.Definition.UserRoles = []ContractUserRoleDefinition{{Role: "witness"}}
+		}, []string{"invalid /definition/user_roles/0/role"}},
+		{"proxy without release", func(cb *ContractBlock) {
+			cb.ProxyInstructions = &ContractProxyInstructions{VisibleToAll: true}
+		}, []string{
+			"invalid /proxy_instructions",
+			"required /proxy_instructions/instructions",
+			"required /proxy_instructions/instructions_hash",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cb := testContractBlock()
+			if tt.edit != nil {
+				tt.edit(&cb)
+			}
+
+			assertErrors(t, cb.Validate(nil), tt.want)
+		})
+	}
+}
+
+func TestImmutableContractValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		edit func(c *ImmutableContract)
+		want []string
+	}{
+		{"valid", nil, nil},
+		{"contract block errors are nested", func(c *ImmutableContract) { c.Contract.StorageYears = 0 }, []string{"out_of_range /contract/storage_years"}},
+		{"contract hash not chained", func(c *ImmutableContract) {
+			c.ContractHash = strings.Repeat("x", SHA256_HASH_BASE64_LENGTH)
+		}, []string{"mismatch /contract_hash"}},
+		{"signatures not chained to contract", func(c *ImmutableContract) {
+			c.ContractSignatures.ContractHash = strings.Repeat("x", SHA256_HASH_BASE64_LENGTH)
+		}, []string{"mismatch /contract_signatures/contract_hash"}},
+		{"signatures hash", func(c *ImmutableContract) {
+			c.ContractSignaturesHash = strings.Repeat("x", SHA256_HASH_BASE64_LENGTH)
+		}, []string{"mismatch /contract_signatures_hash"}},
+		{"package hash", func(c *ImmutableContract) {
+			c.ContractSignatures.Signatures[1].ContractSignaturePackageHash = strings.Repeat("x", SHA256_HASH_BASE64_LENGTH)
+		}, []string{"mismatch /contract_signatures/signatures/1/contract_signature_package_hash"}},
+		{"package key info", func(c *ImmutableContract) {
+			c.ContractSignatures.Signatures[0].ContractSignaturePackage.KeyInfo = KeyInfo{}
+		}, []string{
+			"required /contract_signatures/signatures/0/contract_signature_package/key_info/key_id",
+			"required /contract_signatures/signatures/0/contract_signature_package/key_info/key_type",
+			"required /contract_signatures/signatures/0/contract_signature_package/key_info/key_source",
+		}},
+		{"signed before contract sealed", func(c *ImmutableContract) {
+			c.ContractSignatures.Signatures[0].ContractSignaturePackage.DateSigned = testSealedOn.Add(-time.Minute)
+		}, []string{"out_of_range /contract_signatures/signatures/0/contract_signature_package/date_signed"}},
+		{"signed after signatures sealed", func(c *ImmutableContract) {
+			c.ContractSignatures.Signatures[1].ContractSignaturePackage.DateSigned = c.ContractSignatures.SealedOnDate.Add(time.Minute)
+		}, []string{"out_of_range /contract_signatures/signatures/1/contract_signature_package/date_signed"}},
+		{"approvers sealed out of order", func(c *ImmutableContract) {
+			d := testSealedOn.Add(-time.Hour)
+			c.ContractSignatures.ApproverSealedOnDate = &d
+		}, []string{"out_of_range /contract_signatures/approver_sealed_on_date"}},
+		{"signatures sealed before contract", func(c *ImmutableContract) {
+			c.ContractSignatures.SealedOnDate = testSealedOn.Add(-time.Hour)
+		}, []string{"out_of_range /contract_signatures/sealed_on_date"}},
+		{"container sealed before signatures", func(c *ImmutableContract) {
+			c.SealedOnDate = c.ContractSignatures.SealedOnDate.Add(-time.Minute)
+		}, []string{"out_of_range /sealed_on_date"}},
+		{"finalized content", func(c *ImmutableContract) {
+			c.FinalizedContent = &ConstructedContentItem{}
+		}, []string{"required /finalized_content/content_id", "required /finalized_content/plain_hash"}},
+		{"constructed", func(c *ImmutableContract) {
+			useConstructed(c)
+		}, nil},
+		{"constructed without items", func(c *ImmutableContract) {
+			useConstructed(c)
+			c.ContractSignatures.ConstructedContentItems = nil
+		}, []string{"required /contract_signatures/constructed_content_items"}},
+		{"constructed with unknown content", func(c *ImmutableContract) {
+			useConstructed(c)
+			item := &c.ContractSignatures.ConstructedContentItems[0]
+			item.ItemRole = FinalizedAgreement
+			item.OrigContentIds = []int64{11, 12}
+		}, []string{
+			"invalid /contract_signatures/constructed_content_items/0/item_role",
+			"invalid /contract_signatures/constructed_content_items/0/orig_content_ids/1",
+		}},
+		{"constructed content id not bound", func(c *ImmutableContract) {
+			useConstructed(c)
+			c.ContractSignatures.Signatures[1].ContractSignaturePackage.ConstructedContentId = 0
+		}, []string{"mismatch /contract_signatures/signatures/1/contract_signature_package/constructed_content_id"}},
+		{"embedded", func(c *ImmutableContract) {
+			useEmbedded(c)
+		}, nil},
+		{"embedded without finalized content", func(c *ImmutableContract) {
+			useEmbedded(c)
+			c.FinalizedContent = nil
+		}, []string{"required /finalized_content"}},
+		{"embedded finalized content", func(c *ImmutableContract) {
+			useEmbedded(c)
+			c.FinalizedContent.ItemRole = ConstructedAgreement
+			c.FinalizedContent.PlainHash = "short"
+		}, []string{"invalid /finalized_content/item_role", "invalid /finalized_content/plain_hash"}},
+		{"content signature", func(c *ImmutableContract) {
+			signContent(c, 0)
+		}, nil},
+		{"content signature of encrypted hash", func(c *ImmutableContract) {
+			c.Contract.ContentItems[0].EncryptedHash = strings.Repeat("e", SHA256_HASH_BASE64_LENGTH)
+			cs := signContent(c, 0)
+			cs.ContentHash = c.Contract.ContentItems[0].EncryptedHash
+		}, nil},
+		{"content signature hash mismatch", func(c *ImmutableContract) {
+			cs := signContent(c, 1)
+			cs.ContentHash = strings.Repeat("x", SHA256_HASH_BASE64_LENGTH)
+		}, []string{"mismatch /contract_signatures/signatures/1/contract_signature_package/content_signatures/0/content_hash"}},
+		{"content signature of unknown item", func(c *ImmutableContract) {
+			cs := signContent(c, 0)
+			cs.ContentId = 99
+		}, []string{"invalid /contract_signatures/signatures/0/contract_signature_package/content_signatures/0/content__id"}},
+		{"content signature incomplete", func(c *ImmutableContract) {
+			cs := signContent(c, 0)
+			cs.Signature = ""
+			cs.KeyInfo = &KeyInfo{X509Certificate: "cert"}
+			signedAt := c.ContractSignatures.SealedOnDate.Add(time.Minute)
+			cs.DateSigned = &signedAt
+		}, []string{
+			"required /contract_signatures/signatures/0/contract_signature_package/content_signatures/0/signature",
+			"out_of_range /contract_signatures/signatures/0/contract_signature_package/content_signatures/0/date_signed",
+		}},
+		{"content signature of content id only", func(c *ImmutableContract) {
+			pkg := &c.ContractSignatures.Signatures[0].ContractSignaturePackage
+			pkg.ContentSignatures = append(pkg.ContentSignatures, ContentSignature{ContentId: c.Contract.ContentItems[0].ContentId})
+		}, []string{
+			"invalid /contract_signatures/signatures/0/contract_signature_package/content_signatures/0/content_hash",
+			"required /contract_signatures/signatures/0/contract_signature_package/content_signatures/0/key_info",
+			"required /contract_signatures/signatures/0/contract_signature_package/content_signatures/0/date_signed",
+		}},
+		{"organization signatories covered", func(c *ImmutableContract) {
+			addOrganizations(&c.Contract)
+		}, nil},
+		{"organization signatory by virtual position", func(c *ImmutableContract) {
+			addOrganizations(&c.Contract)
+			c.Contract.Organizations[1].Signatories = "Owner, Partner"
+			c.Contract.VirtualPositions = []ContractParticipantVirtualPosition{{OrgId: -1, UserId: "c102", Position: "partner"}}
+		}, nil},
+		{"organization signatory not covered", func(c *ImmutableContract) {
+			addOrganizations(&c.Contract)
+			c.Contract.Organizations[0].Signatories = "Director, CFO"
+		}, []string{"required /contract/organizations/0/signatories"}},
+		{"organization signatory position is a non-signatory", func(c *ImmutableContract) {
+			addOrganizations(&c.Contract)
+			c.Contract.Organizations[0].NonSignatories = "director"
+		}, []string{"invalid /contract/organizations/0/non_signatories", "required /contract/organizations/0/signatories"}},
+		{"missing signatory", func(c *ImmutableContract) {
+			c.ContractSignatures.Signatures = c.ContractSignatures.Signatures[:1]
+		}, []string{"mismatch /contract_signatures/signatures", "required /contract_signatures/signatures"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := testImmutableContract(t, tt.edit)
+			assertErrors(t, c.Validate(nil), tt.want)
+		})
+	}
+}
+
+func TestValidateFor(t *testing.T) {
+	c := testImmutableContract(t, nil)
+
+	for _, action := range []Action{ActionConsent, ActionCreate, ActionVoid, ActionExpire, ActionRelease} {
+		if err := c.ValidateFor(action, nil); err != nil {
+			t.Errorf("%s: unexpected error: %v", action, err)
+		}
+	}
+
+	c = testImmutableContract(t, func(c *ImmutableContract) { c.Contract.DefinitionVersion = 99 })
+	assertErrors(t, c.ValidateFor(ActionCreate, nil), []string{"unsupported /contract/schema_version"})
+}
+
+func TestValidateForDefinitionVersion1(t *testing.T) {
+	// rules added with definition version 2 do not apply to contracts anchored under version 1
+	c := testImmutableContract(t, func(c *ImmutableContract) {
+		c.Contract.DefinitionVersion = 1
+		c.Contract.ContractID = 0
+		c.Contract.ContractOptions.DaysToSign = 0
+		c.Contract.Participants[1].Roles = append(c.Contract.Participants[1].Roles, "unknown")
+		c.Contract.Participants[1].IdentityClaims = nil
+		for i := range c.ContractSignatures.Signatures {
+			c.ContractSignatures.Signatures[i].ContractSignaturePackage.ContractId = 0
+		}
+	})
+
+	for _, action := range []Action{ActionConsent, ActionCreate, ActionVoid, ActionExpire, ActionRelease} {
+		if err := c.ValidateFor(action, nil); err != nil {
+			t.Errorf("%s: unexpected error: %v", action, err)
+		}
+	}
+
+	c.Contract.DefinitionVersion = 2
+	if err := c.ValidateFor(ActionVoid, nil); err == nil {
+		t.Error("contract passes the rules of definition version 2")
+	}
+
+	c = testImmutableContract(t, func(c *ImmutableContract) {
+		c.Contract.DefinitionVersion = 1
+		c.Contract.StorageYears = 31
+	})
+	assertErrors(t, c.ValidateFor(ActionCreate, nil), []string{"out_of_range /contract/storage_years"})
+
+	// the storage years governed on ledger apply to version 1
+	r := DefaultRules()
+	r.MaxStorageYears = 5
+	c = testImmutableContract(t, func(c *ImmutableContract) { c.Contract.DefinitionVersion = 1 })
+	for _, action := range []Action{ActionConsent, ActionCreate, ActionVoid} {
+		assertErrors(t, c.ValidateFor(action, r), []string{"out_of_range /contract/storage_years"})
+	}
+
+	// version 1 only validated the contract block on create, not the signatures
+	c = testImmutableContract(t, func(c *ImmutableContract) {
+		c.Contract.DefinitionVersion = 1
+		c.ContractSignatures.Signatures = c.ContractSignatures.Signatures[:1]
+	})
+	if err := c.ValidateFor(ActionCreate, nil); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestValidateAmendment(t *testing.T) {
+	original := testImmutableContract(t, nil)
+	originalHash, err := JsonHashS256(original)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// amend seals a new contract amending the original, 3 days after the original was sealed
+	amend := func(edit func(c *ImmutableContract)) *ImmutableContract {
+		return testImmutableContract(t, func(c *ImmutableContract) {
+			shift := 72 * time.Hour
+			c.Contract.ContractID = 1002
+			c.Contract.SealedOnDate = c.Contract.SealedOnDate.Add(shift)
+			c.Contract.Amends = &ContractAmendment{ContractId: original.Contract.ContractID, ImmutableContractHash: originalHash}
+			c.ContractSignatures.SealedOnDate = c.ContractSignatures.SealedOnDate.Add(shift)
+			c.SealedOnDate = c.SealedOnDate.Add(shift)
+
+			for i := range c.ContractSignatures.Signatures {
+				pkg := &c.ContractSignatures.Signatures[i].ContractSignaturePackage
+				pkg.ContractId = c.Contract.ContractID
+				pkg.DateSigned = pkg.DateSigned.Add(shift)
+			}
+
+			if edit != nil {
+				edit(c)
+			}
+		})
+	}
+
+	tests := []struct {
+		name      string
+		amendment *ImmutableContract
+		want      []string
+	}{
+		{"valid", amend(nil), nil},
+		{"not linked", amend(func(c *ImmutableContract) { c.Contract.Amends = nil }), []string{"required /contract/amends"}},
+		{"linked to another contract", amend(func(c *ImmutableContract) {
+			c.Contract.Amends.ContractId = 999
+			c.Contract.Amends.ImmutableContractHash = "other"
+		}), []string{"mismatch /contract/amends/contract_id", "mismatch /contract/amends/immutable_contract_hash"}},
+		{"same contract id", amend(func(c *ImmutableContract) {
+			c.Contract.ContractID = original.Contract.ContractID
+			for i := range c.ContractSignatures.Signatures {
+				c.ContractSignatures.Signatures[i].ContractSignaturePackage.ContractId = c.Contract.ContractID
+			}
+		}), []string{"invalid /contract/contract_id"}},
+		{"other contract type", amend(func(c *ImmutableContract) {
+			c.Contract.ContractTypeId = 8
+			c.Contract.Definition.ContractType = 8
+		}), []string{"mismatch /contract/definition/contract_type"}},
+		{"sealed before original", amend(func(c *ImmutableContract) {
+			c.Contract.SealedOnDate = original.SealedOnDate
+		}), []string{"out_of_range /contract/sealed_on_date"}},
+		{"party to original did not sign", amend(func(c *ImmutableContract) {
+			c.Contract.Participants[1].UserId = "c104"
+			c.ContractSignatures.Signatures[1].ContractSignaturePackage.UserId = "c104"
+		}), []string{"required /contract_signatures/signatures"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertErrors(t, original.ValidateAmendment(tt.amendment, nil), tt.want)
+		})
+	}
+}
+
+func TestRules(t *testing.T) {
+	if err := DefaultRules().Validate(); err != nil {
+		t.Fatalf("default rules are invalid: %v", err)
+	}
+
+	r := DefaultRules()
+	r.SignatureTypes = []string{"qualified"}
+	r.InstructionMinLength = 100
+	r.MaxStorageYears = 5
+
+	cb := testContractBlock()
+	cb.ReleaseInstructions = &ReleaseInstructionDetail{Instructions: strings.Repeat("i", InstructionMinLength), StandardReleaseTemplateId: 1}
+
+	if err := cb.Validate(nil); err != nil {
+		t.Fatalf("unexpected error with default rules: %v", err)
+	}
+
+	assertErrors(t, cb.Validate(r), []string{
+		"invalid /signature_method/signature_type",
+		"out_of_range /release_instructions/instructions",
+		"out_of_range /storage_years",
+	})
+
+	r.MinStorageYears = 0
+	r.SignatureProviders = []string{""}
+	assertErrors(t, r.Validate(), []string{"out_of_range /min_storage_years", "invalid /signature_providers/0"})
+}
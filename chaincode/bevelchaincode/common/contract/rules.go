@@ -0,0 +1,88 @@
+package contract
+
+import "fmt"
+
+const (
+	InstructionMinLength = 60 // default minimum length of release instructions
+
+	MinStorageYears = 1  // lower bound of the storage years rule
+	MaxStorageYears = 30 // default and upper bound of the storage years rule
+)
+
+// Rules are the validation rules which are governed on ledger rather than fixed by the contract schema.
+// A nil *Rules applies DefaultRules.
+type Rules struct {
+	SignatureProviders   []string `json:"signature_providers"` // providers allowed in the signature method
+	SignatureTypes       []string `json:"signature_types"`     // signature types allowed in the signature method
+	InstructionMinLength int      `json:"instruction_min_length"`
+	MinStorageYears      int64    `json:"min_storage_years"`
+	MaxStorageYears      int64    `json:"max_storage_years"`
+}
+
+// DefaultRules returns the rules applied before any rules are governed on ledger.
+func DefaultRules() *Rules {
+	return &Rules{
+		SignatureProviders:   []string{"Subskribo", "Connective"},
+		SignatureTypes:       []string{"advanced", "qualified"},
+		InstructionMinLength: InstructionMinLength,
+		MinStorageYears:      MinStorageYears,
+		MaxStorageYears:      MaxStorageYears,
+	}
+}
+
+func (r *Rules) orDefault() *Rules {
+	if r == nil {
+		return DefaultRules()
+	}
+
+	return r
+}
+
+// Validate checks the rules can be applied, so governance cannot lock out every contract by mistake.
+func (r *Rules) Validate() error {
+	errs := ValidationErrors{}
+
+	if len(r.SignatureProviders) == 0 {
+		errs.Add(CodeRequired, "/signature_providers", "at least one signature provider is required")
+	}
+
+	for i, p := range r.SignatureProviders {
+		if p == "" {
+			errs.Add(CodeInvalid, fmt.Sprintf("/signature_providers/%d", i), "signature provider is empty")
+		}
+	}
+
+	if len(r.SignatureTypes) == 0 {
+		errs.Add(CodeRequired, "/signature_types", "at least one signature type is required")
+	}
+
+	for i, st := range r.SignatureTypes {
+		if st == "" {
+			errs.Add(CodeInvalid, fmt.Sprintf("/signature_types/%d", i), "signature type is empty")
+		}
+	}
+
+	if r.InstructionMinLength < 1 {
+		errs.Add(CodeOutOfRange, "/instruction_min_length", "instruction min length must be at least 1")
+	}
+
+	if r.MinStorageYears < MinStorageYears {
+		errs.Add(CodeOutOfRange, "/min_storage_years", fmt.Sprintf("min storage years must be at least %d", MinStorageYears))
+	}
+
+	if r.MaxStorageYears < r.MinStorageYears || r.MaxStorageYears > MaxStorageYears {
+		errs.Add(CodeOutOfRange, "/max_storage_years", fmt.Sprintf("max storage years must be between min storage years and %d", MaxStorageYears))
+	}
+
+	return errs.Err()
+}
+
+func containsValue(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,126 @@
+package contract
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Certificates parses the certificate chain of the key info, the signing certificate first.
+// The certificate is supplied as PEM blocks, the signing certificate followed by its intermediates,
+// or as a single base64 encoded DER certificate. No certificates are returned if none is supplied.
+func (k *KeyInfo) Certificates() ([]*x509.Certificate, error) {
+	data := strings.TrimSpace(k.X509Certificate)
+	if data == "" {
+		return nil, nil
+	}
+
+	if !strings.HasPrefix(data, "-----BEGIN") {
+		der, err := decodeBase64(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid x509 certificate: %v", err)
+		}
+
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, fmt.Errorf("invalid x509 certificate: %v", err)
+		}
+
+		return []*x509.Certificate{cert}, nil
+	}
+
+	certs := []*x509.Certificate{}
+	rest := []byte(data)
+	for {
+		var block *pem.Block
+		if block, rest = pem.Decode(rest); block == nil {
+			break
+		}
+
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid x509 certificate %d: %v", len(certs), err)
+		}
+
+		certs = append(certs, cert)
+	}
+
+	if len(certs) == 0 {
+		return nil, errors.New("invalid x509 certificate: no PEM certificate found")
+	}
+
+	return certs, nil
+}
+
+// VerifyHashSignature verifies a base64 encoded signature of a hash, as computed by JsonHashS256 or a content hash,
+// with the public key of the certificate. The signed digest is the SHA256 digest the hash encodes.
+// ECDSA, RSA PKCS #1 v1.5 and PSS, and Ed25519 keys are supported.
+func VerifyHashSignature(cert *x509.Certificate, hash string, signature string) error {
+	digest, err := base64.StdEncoding.DecodeString(hash)
+	if err != nil || len(digest) != 32 {
+		return fmt.Errorf("invalid SHA256 hash %q", hash)
+	}
+
+	sig, err := decodeBase64(signature)
+	if err != nil {
+		return fmt.Errorf("invalid signature encoding: %v", err)
+	}
+
+	switch pub := cert.PublicKey.(type) {
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(pub, digest, sig) {
+			return errors.New("ECDSA signature does not match the hash")
+		}
+	case *rsa.PublicKey:
+		if rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest, sig) != nil && rsa.VerifyPSS(pub, crypto.SHA256, digest, sig, nil) != nil {
+			return errors.New("RSA signature does not match the hash")
+		}
+	case ed25519.PublicKey:
+		if !ed25519.Verify(pub, digest, sig) {
+			return errors.New("Ed25519 signature does not match the hash")
+		}
+	default:
+		return fmt.Errorf("unsupported public key type %T", cert.PublicKey)
+	}
+
+	return nil
+}
+
+// VerifyCertificateChain verifies the signing certificate, the first of the chain, chains to one of the roots
+// through the other certificates of the chain, and was valid at the signing date.
+func VerifyCertificateChain(chain []*x509.Certificate, roots *x509.CertPool, signedAt time.Time) error {
+	if len(chain) == 0 {
+		return errors.New("no certificate")
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range chain[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	_, err := chain[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   signedAt,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+
+	return err
+}
+
+// decodeBase64 decodes standard base64, with or without padding.
+func decodeBase64(data string) ([]byte, error) {
+	return base64.RawStdEncoding.DecodeString(strings.TrimRight(data, "="))
+}
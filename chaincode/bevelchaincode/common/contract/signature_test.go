@@ -0,0 +1,78 @@
+package contract_test
+
+import (
+	"encoding/base64"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/Subskribo-BV/dnn-fabric-chaincode/common/contract"
+	"github.com/Subskribo-BV/dnn-fabric-chaincode/common/ledgertest"
+)
+
+func TestVerifySignature(t *testing.T) {
+	s := ledgertest.NewSigner("Subskribo signer")
+	k := s.KeyInfo()
+
+	certs, err := k.Certificates()
+	if err != nil || len(certs) != 1 || !certs[0].Equal(s.Cert) {
+		t.Fatalf("certificates: %v %v", certs, err)
+	}
+
+	// a base64 DER certificate, followed by no intermediates
+	der := contract.KeyInfo{X509Certificate: pemToBase64(t, k.X509Certificate)}
+	if certs, err := der.Certificates(); err != nil || len(certs) != 1 {
+		t.Errorf("DER certificate: %v %v", certs, err)
+	}
+
+	if certs, err := (&contract.KeyInfo{KeyId: "key"}).Certificates(); err != nil || certs != nil {
+		t.Errorf("key info without a certificate: %v %v", certs, err)
+	}
+
+	if _, err := (&contract.KeyInfo{X509Certificate: "not a certificate"}).Certificates(); err == nil {
+		t.Error("invalid certificate parsed")
+	}
+
+	hash := ledgertest.Hash("agreement")
+	sig := s.Sign(hash)
+
+	if len(sig) != contract.SIGNATURE_RSA2048_BASE64_LENGTH {
+		t.Errorf("signature length %d", len(sig))
+	}
+
+	if err := contract.VerifyHashSignature(certs[0], hash, sig); err != nil {
+		t.Errorf("valid signature: %v", err)
+	}
+
+	if err := contract.VerifyHashSignature(certs[0], ledgertest.Hash("other"), sig); err == nil {
+		t.Error("signature of another hash verified")
+	}
+
+	if err := contract.VerifyHashSignature(ledgertest.NewSigner("other").Cert, hash, sig); err == nil {
+		t.Error("signature verified with another key")
+	}
+
+	signedAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := contract.VerifyCertificateChain(certs, s.Roots(), signedAt); err != nil {
+		t.Errorf("chain to the signer CA: %v", err)
+	}
+
+	if err := contract.VerifyCertificateChain(certs, ledgertest.NewSigner("other").Roots(), signedAt); err == nil {
+		t.Error("chain verified to another CA")
+	}
+
+	if err := contract.VerifyCertificateChain(certs, s.Roots(), time.Date(2101, 1, 1, 0, 0, 0, 0, time.UTC)); err == nil {
+		t.Error("chain verified after the certificate expired")
+	}
+}
+
+func pemToBase64(t *testing.T, data string) string {
+	t.Helper()
+
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		t.Fatal("no PEM block")
+	}
+
+	return base64.StdEncoding.EncodeToString(block.Bytes)
+}
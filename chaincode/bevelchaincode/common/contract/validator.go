@@ -0,0 +1,244 @@
+package contract
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Action is a change of state of a contract on blockchain.
+type Action string
+
+const (
+	ActionConsent Action = "consent"
+	ActionCreate  Action = "create"
+	ActionVoid    Action = "void"
+	ActionExpire  Action = "expire"
+	ActionRelease Action = "release"
+	ActionAmend   Action = "amend"
+)
+
+// Validator validates a contract for each change of state.
+//
+// A validator is registered for the schema versions of the contract block and definition, and the definition version it applies to.
+// New validation rules are added by registering a new validator for the new versions,
+// so contracts anchored under older schemas continue to be validated by the rules they were anchored with.
+// Rules governed on ledger are passed in r; nil applies DefaultRules.
+type Validator interface {
+	ValidateConsent(cb *ContractBlock, r *Rules) error
+	ValidateCreate(c *ImmutableContract, r *Rules) error
+	ValidateVoid(c *ImmutableContract, r *Rules) error
+	ValidateExpire(c *ImmutableContract, r *Rules) error
+	ValidateRelease(c *ImmutableContract, r *Rules) error
+
+	// ValidateAmend validates an amendment of the contract c against the rules c was anchored with.
+	ValidateAmend(c *ImmutableContract, amendment *ImmutableContract) error
+
+	// ValidateSignature validates the signature package of a signatory submitted for the contract block during consent.
+	ValidateSignature(cb *ContractBlock, pkg *ContractSignaturePackage) error
+}
+
+type ValidatorKey struct {
+	SchemaVersion           int64 // ContractBlock.SchemaVersion
+	DefinitionSchemaVersion int64 // ContractDefinition.SchemaVersion
+	DefinitionVersion       int64 // ContractBlock.DefinitionVersion
+}
+
+var validators = map[ValidatorKey]Validator{}
+
+// RegisterValidator registers the validator for contracts with the given versions.
+// Intended to be called from init, it panics if a validator is already registered for the versions.
+func RegisterValidator(key ValidatorKey, v Validator) {
+	if v == nil {
+		panic("contract: validator is nil")
+	}
+
+	if _, ok := validators[key]; ok {
+		panic(fmt.Sprintf("contract: validator already registered for %+v", key))
+	}
+
+	validators[key] = v
+}
+
+// ValidatorFor returns the validator registered for the versions of the contract block.
+func ValidatorFor(cb *ContractBlock) (Validator, error) {
+	if cb == nil {
+		return nil, errors.New("contract block is nil")
+	}
+
+	key := ValidatorKey{
+		SchemaVersion:           cb.SchemaVersion,
+		DefinitionSchemaVersion: cb.Definition.SchemaVersion,
+		DefinitionVersion:       cb.DefinitionVersion,
+	}
+
+	v, ok := validators[key]
+	if !ok {
+		return nil, &ValidationError{
+			Code: CodeUnsupported,
+			Path: "/schema_version",
+			Message: fmt.Sprintf("unsupported schema version: contract schema version %d, definition schema version %d, definition version %d",
+				key.SchemaVersion, key.DefinitionSchemaVersion, key.DefinitionVersion),
+		}
+	}
+
+	return v, nil
+}
+
+// ValidateFor validates the immutable contract for the change of state, using the validator registered for its versions
+// with the rules, DefaultRules if r is nil.
+func (c *ImmutableContract) ValidateFor(action Action, r *Rules) error {
+	if c == nil {
+		return errors.New("immutable contract is nil")
+	}
+
+	v, err := ValidatorFor(&c.Contract)
+	if err != nil {
+		errs := ValidationErrors{}
+		errs.Append("/contract", err)
+		return errs
+	}
+
+	switch action {
+	case ActionConsent:
+		errs := ValidationErrors{}
+		errs.Append("/contract", v.ValidateConsent(&c.Contract, r))
+		return errs.Err()
+	case ActionCreate:
+		return v.ValidateCreate(c, r)
+	case ActionVoid:
+		return v.ValidateVoid(c, r)
+	case ActionExpire:
+		return v.ValidateExpire(c, r)
+	case ActionRelease:
+		return v.ValidateRelease(c, r)
+	}
+
+	return fmt.Errorf("unknown action %q", action)
+}
+
+// ValidateAmendment validates an amendment superseding the immutable contract.
+// The amendment is validated for create by the validator for its own versions,
+// and its consent requirements by the validator for the versions of the original contract.
+func (c *ImmutableContract) ValidateAmendment(amendment *ImmutableContract, r *Rules) error {
+	if c == nil || amendment == nil {
+		return errors.New("immutable contract is nil")
+	}
+
+	errs := ValidationErrors{}
+	errs.Append("", amendment.ValidateFor(ActionCreate, r))
+
+	v, err := ValidatorFor(&c.Contract)
+	if err != nil {
+		return err
+	}
+
+	errs.Append("", v.ValidateAmend(c, amendment))
+
+	return errs.Err()
+}
+
+func init() {
+	RegisterValidator(ValidatorKey{1, 1, 1}, validatorV1{})
+	RegisterValidator(ValidatorKey{1, 1, 2}, validatorV2{})
+}
+
+// validatorV2 applies the complete validation of definition version 2: the contract block with its nested
+// signature method, release and proxy instructions, roles, organizations and identity claims against the rules,
+// the chaining of hashes between blocks, the sealed on dates and the signatures of every signatory.
+type validatorV2 struct{}
+
+func (validatorV2) ValidateConsent(cb *ContractBlock, r *Rules) error {
+	return cb.Validate(r)
+}
+
+func (validatorV2) ValidateCreate(c *ImmutableContract, r *Rules) error {
+	return c.Validate(r)
+}
+
+func (v validatorV2) ValidateVoid(c *ImmutableContract, r *Rules) error {
+	return v.validateChange(c, r)
+}
+
+func (v validatorV2) ValidateExpire(c *ImmutableContract, r *Rules) error {
+	return v.validateChange(c, r)
+}
+
+func (v validatorV2) ValidateRelease(c *ImmutableContract, r *Rules) error {
+	return v.validateChange(c, r)
+}
+
+func (validatorV2) ValidateAmend(c *ImmutableContract, amendment *ImmutableContract) error {
+	return validateAmend(c, amendment)
+}
+
+func (validatorV2) ValidateSignature(cb *ContractBlock, pkg *ContractSignaturePackage) error {
+	errs := ValidationErrors{}
+	errs.Append("/content_signatures", cb.ValidateContentSignatures(pkg.ContentSignatures))
+	return errs.Err()
+}
+
+func (validatorV2) validateChange(c *ImmutableContract, r *Rules) error {
+	errs := ValidationErrors{}
+
+	if c.Contract.SchemaVersion != c.Contract.Definition.SchemaVersion {
+		errs.Add(CodeMismatch, "/contract/schema_version", "contract schema version does not match with definition")
+	}
+
+	errs.Append("", c.Validate(r))
+	return errs.Err()
+}
+
+// validateAmend requires the amendment to link the contract by id and hash, to keep its contract family and type,
+// and to be signed by every signatory and contractual participant of the contract.
+func validateAmend(c *ImmutableContract, amendment *ImmutableContract) error {
+	errs := ValidationErrors{}
+	block := &amendment.Contract
+
+	if block.Amends == nil {
+		errs.Add(CodeRequired, "/contract/amends", "amendment does not reference the contract it amends")
+		return errs.Err()
+	}
+
+	if block.Amends.ContractId != c.Contract.ContractID {
+		errs.Add(CodeMismatch, "/contract/amends/contract_id", "amendment does not reference the contract it amends")
+	}
+
+	hash, err := JsonHashS256(c)
+	if err != nil {
+		return err
+	}
+
+	if block.Amends.ImmutableContractHash != hash {
+		errs.Add(CodeMismatch, "/contract/amends/immutable_contract_hash", "amendment does not reference the hash of the contract it amends")
+	}
+
+	if block.ContractID == c.Contract.ContractID {
+		errs.Add(CodeInvalid, "/contract/contract_id", "amendment must have a new contract id")
+	}
+
+	if block.Definition.ContractFamilyId != c.Contract.Definition.ContractFamilyId {
+		errs.Add(CodeMismatch, "/contract/definition/contract_family_id", "amendment must keep the contract family of the contract it amends")
+	}
+
+	if block.Definition.ContractType != c.Contract.Definition.ContractType {
+		errs.Add(CodeMismatch, "/contract/definition/contract_type", "amendment must keep the contract type of the contract it amends")
+	}
+
+	if !block.SealedOnDate.After(c.SealedOnDate) {
+		errs.Add(CodeOutOfRange, "/contract/sealed_on_date", "amendment must be sealed after the contract it amends")
+	}
+
+	signed := map[string]bool{}
+	for _, sp := range amendment.ContractSignatures.Signatures {
+		signed[sp.ContractSignaturePackage.UserId] = true
+	}
+
+	for _, p := range c.Contract.Participants {
+		if p.HasAnyRole(Signatory, Contractual) && !signed[p.UserId] {
+			errs.Add(CodeRequired, "/contract_signatures/signatures",
+				fmt.Sprintf("amendment requires the signature of user id '%v' who is a party to the contract it amends", p.UserId))
+		}
+	}
+
+	return errs.Err()
+}
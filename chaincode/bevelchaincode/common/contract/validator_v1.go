@@ -0,0 +1,91 @@
+package contract
+
+// validatorV1 applies the rules of the initial contract and definition schemas.
+// Its checks are frozen: contracts anchored under definition version 1 keep being validated by them.
+// Of the rules governed on ledger it reads the storage years, the only rule version 1 checked;
+// release instructions and signature methods were not validated by version 1.
+type validatorV1 struct{}
+
+func (validatorV1) ValidateConsent(cb *ContractBlock, r *Rules) error {
+	return validateBlockV1(cb, r)
+}
+
+func (validatorV1) ValidateCreate(c *ImmutableContract, r *Rules) error {
+	errs := ValidationErrors{}
+	errs.Append("/contract", validateBlockV1(&c.Contract, r))
+	return errs.Err()
+}
+
+func (v validatorV1) ValidateVoid(c *ImmutableContract, r *Rules) error {
+	return v.validateChange(c, r)
+}
+
+func (v validatorV1) ValidateExpire(c *ImmutableContract, r *Rules) error {
+	return v.validateChange(c, r)
+}
+
+func (v validatorV1) ValidateRelease(c *ImmutableContract, r *Rules) error {
+	return v.validateChange(c, r)
+}
+
+func (validatorV1) ValidateAmend(c *ImmutableContract, amendment *ImmutableContract) error {
+	return validateAmend(c, amendment)
+}
+
+// ValidateSignature accepts the content signatures of version 1 as anchored, which only held the content id.
+func (validatorV1) ValidateSignature(_ *ContractBlock, _ *ContractSignaturePackage) error {
+	return nil
+}
+
+func (validatorV1) validateChange(c *ImmutableContract, r *Rules) error {
+	errs := ValidationErrors{}
+
+	if c.Contract.SchemaVersion != c.Contract.Definition.SchemaVersion {
+		errs.Add(CodeMismatch, "/contract/schema_version", "contract schema version does not match with definition")
+	}
+
+	errs.Append("/contract", validateBlockV1(&c.Contract, r))
+	return errs.Err()
+}
+
+// validateBlockV1 validates the contract block against its definition as of definition version 1,
+// with the storage years of the rules, DefaultRules if r is nil.
+func validateBlockV1(cb *ContractBlock, r *Rules) error {
+	errs := ValidationErrors{}
+	d := cb.Definition
+	r = r.orDefault()
+
+	if cb.ContractFamilyId != d.ContractFamilyId {
+		errs.Add(CodeMismatch, "/contract_family_id", "invalid contract family id")
+	}
+
+	if cb.ContractTypeId != d.ContractType {
+		errs.Add(CodeMismatch, "/contract_type_id", "invalid contract type id")
+	}
+
+	if cb.ContractTypeVersion == 0 {
+		errs.Add(CodeRequired, "/contract_type_version", "invalid contract type version")
+	}
+
+	if cb.StorageYears < r.MinStorageYears || cb.StorageYears > r.MaxStorageYears {
+		errs.Add(CodeOutOfRange, "/storage_years", "invalid storage years")
+	}
+
+	opt := cb.ContractOptions
+
+	if opt.ExpiryDate != nil {
+		if opt.ExpiryDate.Before(cb.SealedOnDate) {
+			errs.Add(CodeInvalid, "/contract_options/expiry_date", "invalid expiry date")
+		}
+
+		if opt.DaysToSign < 1 {
+			errs.Add(CodeOutOfRange, "/contract_options/days_to_sign", "invalid days to sign")
+		}
+
+		if opt.AllowSignatureExtension && opt.MaxDaysToSign < 1 {
+			errs.Add(CodeOutOfRange, "/contract_options/max_days_to_sign", "invalid days to sign extension")
+		}
+	}
+
+	return errs.Err()
+}
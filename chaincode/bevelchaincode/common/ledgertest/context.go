@@ -0,0 +1,17 @@
+package ledgertest
+
+import (
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// TransactionContext is the context of a transaction on an in-memory ledger.
+type TransactionContext struct {
+	contractapi.TransactionContext
+
+	Stub *Stub
+}
+
+// Commit applies the writes and event of the transaction to the ledger.
+func (ctx *TransactionContext) Commit() error {
+	return ctx.Stub.commit()
+}
@@ -0,0 +1,234 @@
+package ledgertest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/Subskribo-BV/dnn-fabric-chaincode/common/contract"
+)
+
+// ContractBuilder builds a signed immutable contract which passes validation for create, void, expire and release.
+//
+// The contract uses definition family 2, type 7, schema and definition versions 1 and the original content
+// package method. Hashes are computed by Build after every option is applied, unless an edit sets them.
+type ContractBuilder struct {
+	ic     contract.ImmutableContract
+	edits  []func(c *contract.ImmutableContract)
+	signer *Signer
+}
+
+// NewContract returns a builder for the contract with the id, sealed on the given date.
+// Its participants are a creator and a beneficiary who are both signatories, each signing within the following hours.
+func NewContract(id int64, sealedOn time.Time) *ContractBuilder {
+	sealedOn = sealedOn.UTC()
+
+	b := &ContractBuilder{}
+	b.ic.Contract = contract.ContractBlock{
+		ContractID:          id,
+		SchemaVersion:       1,
+		Language:            "en",
+		ContractFamilyId:    2,
+		ContractTypeId:      7,
+		ContractTypeVersion: 1,
+		ContractName:        "Conditional release",
+		ContractOptions: contract.ContractOptions{
+			DaysToSign: 7,
+		},
+		ContentItems: []contract.ContentItem{
+			{ContentId: 11, ItemRole: contract.Agreement, PlainHash: Hash("agreement")},
+		},
+		SignatureMethod: contract.SignatureMethod{
+			PackageMethodId:   int64(contract.SignPackageMethodId_OriginalContent),
+			SignatureType:     "advanced",
+			SignatureProvider: "Subskribo",
+		},
+		StorageYears: 10,
+		Definition: contract.ContractDefinition{
+			ContractFamilyId:    2,
+			ContractType:        7,
+			ContractTypeVersion: 1,
+			SchemaVersion:       1,
+		},
+		DefinitionVersion: 2,
+		SealedOnDate:      sealedOn,
+	}
+	b.ic.ContractSignatures.SealedOnDate = sealedOn.Add(48 * time.Hour)
+	b.ic.SealedOnDate = sealedOn.Add(49 * time.Hour)
+
+	b.WithParticipant("c102", "Ann Author", contract.Signatory, contract.Creator)
+	b.WithParticipant("c103", "Bob Beneficiary", contract.Signatory, contract.Beneficiary)
+
+	return b
+}
+
+// WithParticipant adds a participant with a verified name claim, and their signature if they are a signatory.
+func (b *ContractBuilder) WithParticipant(userId, fullName string, roles ...contract.Role) *ContractBuilder {
+	cb := &b.ic.Contract
+
+	p := contract.ContractParticipant{
+		UserId:   userId,
+		FullName: fullName,
+		KycLevel: 2,
+		IdentityClaims: []contract.ContractIdentityClaim{
+			{IdentityClaimId: int64(len(cb.Participants) + 1), Claim: "name", Value: fullName, Verifier: "Subskribo", KycLevel: 2},
+		},
+	}
+	for _, r := range roles {
+		p.Roles = append(p.Roles, string(r))
+	}
+	cb.Participants = append(cb.Participants, p)
+
+	if !p.IsRole(contract.Signatory) {
+		return b
+	}
+
+	n := len(b.ic.ContractSignatures.Signatures)
+	b.ic.ContractSignatures.Signatures = append(b.ic.ContractSignatures.Signatures, contract.SignedContractSignature{
+		ContractSignaturePackage: contract.ContractSignaturePackage{
+			SignatureId:       userId + "-sig",
+			ContractId:        cb.ContractID,
+			UserId:            userId,
+			UserFullName:      fullName,
+			DateSigned:        cb.SealedOnDate.Add(time.Duration(n+1) * time.Hour),
+			IpAddress:         "10.0.0.1",
+			SignatureProvider: "Subskribo",
+			SignatureType:     "advanced",
+			KeyInfo:           contract.KeyInfo{KeyId: "key-" + userId, KeyType: "rsa2048", KeySource: "local"},
+		},
+		Signature: strings.Repeat("s", contract.SIGNATURE_RSA2048_BASE64_LENGTH),
+	})
+
+	return b
+}
+
+// WithExpiry sets the expiry date of the contract.
+func (b *ContractBuilder) WithExpiry(t time.Time) *ContractBuilder {
+	t = t.UTC()
+	b.ic.Contract.ContractOptions.ExpiryDate = &t
+	return b
+}
+
+// WithEffectiveDate sets the date the contract becomes effective, scheduling it until then.
+func (b *ContractBuilder) WithEffectiveDate(t time.Time) *ContractBuilder {
+	t = t.UTC()
+	b.ic.Contract.ContractOptions.EffectiveDate = &t
+	return b
+}
+
+// WithStandardRelease adds release instructions using a standard release template.
+func (b *ContractBuilder) WithStandardRelease(instructions string, templateId int64) *ContractBuilder {
+	b.ic.Contract.ReleaseInstructions = &contract.ReleaseInstructionDetail{
+		Instructions:              instructions,
+		StandardReleaseTemplateId: templateId,
+	}
+	return b
+}
+
+// Edit applies edit to the contract when it is built, before its hashes are computed.
+func (b *ContractBuilder) Edit(edit func(c *contract.ImmutableContract)) *ContractBuilder {
+	b.edits = append(b.edits, edit)
+	return b
+}
+
+// SignedBy signs the signature packages with the key of the signer when the contract is built, after the edits.
+func (b *ContractBuilder) SignedBy(s *Signer) *ContractBuilder {
+	b.signer = s
+	return b
+}
+
+// Build returns a copy of the contract with its hashes sealed, and the hash of the immutable contract.
+func (b *ContractBuilder) Build() (*contract.ImmutableContract, string, error) {
+	ic, err := clone(&b.ic)
+	if err != nil {
+		return nil, "", err
+	}
+
+	for _, edit := range b.edits {
+		edit(ic)
+	}
+
+	if b.signer != nil {
+		if err := b.signer.SignPackages(ic); err != nil {
+			return nil, "", err
+		}
+	}
+
+	if err := Seal(ic); err != nil {
+		return nil, "", err
+	}
+
+	hash, err := contract.JsonHashS256(ic)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return ic, hash, nil
+}
+
+// Seal computes the contract, signature package and signatures hashes of an immutable contract which are not set.
+func Seal(ic *contract.ImmutableContract) error {
+	var err error
+
+	if ic.ContractHash == "" {
+		if ic.ContractHash, err = contract.JsonHashS256(ic.Contract); err != nil {
+			return err
+		}
+	}
+
+	if ic.ContractSignatures.ContractHash == "" {
+		ic.ContractSignatures.ContractHash = ic.ContractHash
+	}
+
+	for i := range ic.ContractSignatures.Signatures {
+		sp := &ic.ContractSignatures.Signatures[i]
+		if sp.ContractSignaturePackage.ContractHash == "" {
+			sp.ContractSignaturePackage.ContractHash = ic.ContractHash
+		}
+
+		if sp.ContractSignaturePackageHash == "" {
+			if sp.ContractSignaturePackageHash, err = contract.JsonHashS256(sp.ContractSignaturePackage); err != nil {
+				return err
+			}
+		}
+	}
+
+	if ic.ContractSignaturesHash == "" {
+		if ic.ContractSignaturesHash, err = contract.JsonHashS256(ic.ContractSignatures); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Hash returns a base64 SHA256 hash of the text, for content item hashes.
+func Hash(text string) string {
+	hash, _ := contract.JsonHashS256(text)
+	return hash
+}
+
+// Encode encodes a request as a transaction argument, JSON in base64 without padding.
+func Encode(req any) (string, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawStdEncoding.EncodeToString(data), nil
+}
+
+func clone(ic *contract.ImmutableContract) (*contract.ImmutableContract, error) {
+	data, err := json.Marshal(ic)
+	if err != nil {
+		return nil, err
+	}
+
+	c := new(contract.ImmutableContract)
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
@@ -0,0 +1,185 @@
+package ledgertest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-chaincode-go/pkg/attrmgr"
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+	"github.com/hyperledger/fabric-protos-go/msp"
+)
+
+// ClientIdentity is the identity of the client submitting a transaction: its MSP and an X.509 certificate
+// holding its organizational units and attributes, as issued by a Fabric CA.
+//
+// The identity is read from its serialized form with the cid package, as the chaincode does on a peer,
+// so GetID returns the subject and issuer of the certificate.
+type ClientIdentity struct {
+	MSPID string
+	Cert  *x509.Certificate
+
+	name       string
+	ous        []string
+	attributes map[string]string
+	creator    []byte
+	id         *cid.ClientID
+}
+
+// NewClientIdentity returns a client identity of the MSP with a self-signed certificate
+// for the common name, holding the attributes.
+func NewClientIdentity(mspId string, name string, attributes map[string]string) *ClientIdentity {
+	c := &ClientIdentity{
+		MSPID:      mspId,
+		name:       name,
+		attributes: map[string]string{},
+	}
+	for k, v := range attributes {
+		c.attributes[k] = v
+	}
+
+	return c.issue()
+}
+
+// NewClientIdentityFromCert returns a client identity of the MSP with the certificate.
+func NewClientIdentityFromCert(mspId string, cert *x509.Certificate) (*ClientIdentity, error) {
+	c := &ClientIdentity{MSPID: mspId, Cert: cert}
+	if err := c.serialize(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// WithAttribute returns a copy of the identity with a certificate holding the attribute.
+func (c *ClientIdentity) WithAttribute(name string, value string) *ClientIdentity {
+	cp := c.copy()
+	cp.attributes[name] = value
+
+	return cp.issue()
+}
+
+// WithOU returns a copy of the identity with a certificate holding the organizational unit.
+func (c *ClientIdentity) WithOU(ou string) *ClientIdentity {
+	cp := c.copy()
+	cp.ous = append(cp.ous, ou)
+
+	return cp.issue()
+}
+
+// Creator returns the serialized identity, as returned by the stub's GetCreator.
+func (c *ClientIdentity) Creator() []byte {
+	return copyBytes(c.creator)
+}
+
+func (c *ClientIdentity) GetID() (string, error) {
+	return c.id.GetID()
+}
+
+func (c *ClientIdentity) GetMSPID() (string, error) {
+	return c.id.GetMSPID()
+}
+
+func (c *ClientIdentity) GetAttributeValue(attrName string) (string, bool, error) {
+	return c.id.GetAttributeValue(attrName)
+}
+
+func (c *ClientIdentity) AssertAttributeValue(attrName, attrValue string) error {
+	return c.id.AssertAttributeValue(attrName, attrValue)
+}
+
+func (c *ClientIdentity) GetX509Certificate() (*x509.Certificate, error) {
+	return c.id.GetX509Certificate()
+}
+
+func (c *ClientIdentity) copy() *ClientIdentity {
+	cp := &ClientIdentity{
+		MSPID:      c.MSPID,
+		name:       c.name,
+		ous:        append([]string{}, c.ous...),
+		attributes: map[string]string{},
+	}
+	for k, v := range c.attributes {
+		cp.attributes[k] = v
+	}
+
+	return cp
+}
+
+// issue issues the certificate of the identity. Key generation and signing only fail if the system
+// random source fails, which leaves no test able to run, so a failure panics.
+func (c *ClientIdentity) issue() *ClientIdentity {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+
+	attrs, err := json.Marshal(&attrmgr.Attributes{Attrs: c.attributes})
+	if err != nil {
+		panic(err)
+	}
+
+	subject := pkix.Name{CommonName: c.name, OrganizationalUnit: c.ous}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      subject,
+		Issuer:       subject,
+		NotBefore:    time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
+		NotAfter:     time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtraExtensions: []pkix.Extension{
+			{Id: attrmgr.AttrOID, Value: attrs},
+		},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		panic(err)
+	}
+
+	if c.Cert, err = x509.ParseCertificate(der); err != nil {
+		panic(err)
+	}
+
+	if err := c.serialize(); err != nil {
+		panic(err)
+	}
+
+	return c
+}
+
+func (c *ClientIdentity) serialize() error {
+	sid := &msp.SerializedIdentity{
+		Mspid:   c.MSPID,
+		IdBytes: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.Cert.Raw}),
+	}
+
+	creator, err := proto.Marshal(sid)
+	if err != nil {
+		return err
+	}
+
+	id, err := cid.New(creatorBytes(creator))
+	if err != nil {
+		return err
+	}
+
+	c.creator = creator
+	c.id = id
+
+	return nil
+}
+
+// creatorBytes is a serialized identity read by the cid package.
+type creatorBytes []byte
+
+func (b creatorBytes) GetCreator() ([]byte, error) {
+	return b, nil
+}
@@ -0,0 +1,63 @@
+package ledgertest
+
+import (
+	"errors"
+
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+)
+
+type kv = queryresult.KV
+
+type stateIterator struct {
+	kvs    []*kv
+	closed bool
+}
+
+func newStateIterator(kvs []*kv) *stateIterator {
+	return &stateIterator{kvs: kvs}
+}
+
+func (it *stateIterator) HasNext() bool {
+	return !it.closed && len(it.kvs) > 0
+}
+
+func (it *stateIterator) Next() (*queryresult.KV, error) {
+	if !it.HasNext() {
+		return nil, errors.New("no more results")
+	}
+
+	next := it.kvs[0]
+	it.kvs = it.kvs[1:]
+
+	return next, nil
+}
+
+func (it *stateIterator) Close() error {
+	it.closed = true
+	return nil
+}
+
+type historyIterator struct {
+	mods   []*queryresult.KeyModification
+	closed bool
+}
+
+func (it *historyIterator) HasNext() bool {
+	return !it.closed && len(it.mods) > 0
+}
+
+func (it *historyIterator) Next() (*queryresult.KeyModification, error) {
+	if !it.HasNext() {
+		return nil, errors.New("no more results")
+	}
+
+	next := it.mods[0]
+	it.mods = it.mods[1:]
+
+	return next, nil
+}
+
+func (it *historyIterator) Close() error {
+	it.closed = true
+	return nil
+}
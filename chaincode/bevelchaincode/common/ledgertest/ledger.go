@@ -0,0 +1,258 @@
+// Package ledgertest provides an in-memory ledger for testing chaincode transactions without a Fabric network.
+//
+// Each transaction runs on its own stub. As on a peer, a transaction reads the committed state only,
+// its writes are buffered and applied to the ledger when the transaction is committed.
+// A transaction which returns an error is simply not committed.
+//
+//	l := ledgertest.NewLedger()
+//	ctx := l.NewTx(ledgertest.WithIdentity(ledgertest.NewClientIdentity("Org1MSP", "server", nil)))
+//	if _, err := s.CreateAsset(ctx, data); err == nil {
+//		err = ctx.Commit()
+//	}
+package ledgertest
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Event is a chaincode event of a committed transaction.
+type Event struct {
+	TxId    string
+	Name    string
+	Payload []byte
+}
+
+// Ledger is the committed world state, private data, key history and events of a channel.
+type Ledger struct {
+	mu sync.Mutex
+
+	channelId  string
+	now        time.Time
+	txSeq      int
+	state      map[string][]byte
+	private    map[string]map[string][]byte
+	validation map[string][]byte
+	history    map[string][]*queryresult.KeyModification
+	events     []Event
+}
+
+// NewLedger returns an empty ledger whose clock starts at 2024-01-01 UTC.
+func NewLedger() *Ledger {
+	return &Ledger{
+		channelId:  "mychannel",
+		now:        time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		state:      map[string][]byte{},
+		private:    map[string]map[string][]byte{},
+		validation: map[string][]byte{},
+		history:    map[string][]*queryresult.KeyModification{},
+	}
+}
+
+// Now returns the time of the ledger clock, used as the timestamp of new transactions.
+func (l *Ledger) Now() time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.now
+}
+
+// SetTime sets the ledger clock.
+func (l *Ledger) SetTime(t time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.now = t.UTC()
+}
+
+// Advance moves the ledger clock forward.
+func (l *Ledger) Advance(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.now = l.now.Add(d)
+}
+
+// TxOption configures a new transaction.
+type TxOption func(s *Stub)
+
+// WithIdentity sets the client identity submitting the transaction.
+func WithIdentity(id *ClientIdentity) TxOption {
+	return func(s *Stub) {
+		s.identity = id
+	}
+}
+
+// WithTransient sets the transient data of the transaction proposal.
+func WithTransient(transient map[string][]byte) TxOption {
+	return func(s *Stub) {
+		s.transient = transient
+	}
+}
+
+// WithArgs sets the function name and parameters of the transaction proposal.
+func WithArgs(function string, params ...string) TxOption {
+	return func(s *Stub) {
+		s.args = [][]byte{[]byte(function)}
+		for _, p := range params {
+			s.args = append(s.args, []byte(p))
+		}
+	}
+}
+
+// WithTxTime sets the timestamp of the transaction instead of the ledger clock.
+func WithTxTime(t time.Time) TxOption {
+	return func(s *Stub) {
+		s.timestamp = t.UTC()
+	}
+}
+
+// NewTx returns the context of a new transaction on the ledger.
+// Without an identity option, the transaction is submitted by a client of Org1MSP without attributes.
+func (l *Ledger) NewTx(opts ...TxOption) *TransactionContext {
+	l.mu.Lock()
+	l.txSeq++
+	stub := &Stub{
+		ledger:      l,
+		txId:        fmt.Sprintf("tx%d", l.txSeq),
+		timestamp:   l.now,
+		transient:   map[string][]byte{},
+		decorations: map[string][]byte{},
+		writes:      map[string]map[string]*write{},
+	}
+	l.mu.Unlock()
+
+	for _, opt := range opts {
+		opt(stub)
+	}
+
+	if stub.identity == nil {
+		stub.identity = NewClientIdentity("Org1MSP", "client", nil)
+	}
+
+	ctx := &TransactionContext{Stub: stub}
+	ctx.SetStub(stub)
+	ctx.SetClientIdentity(stub.identity)
+
+	return ctx
+}
+
+// Invoke invokes the chaincode in a new transaction as an endorsing peer would, dispatching on the function
+// and parameters set with WithArgs. The transaction is committed if the chaincode returns a success response;
+// a failed commit is returned as an error response. The stub of the transaction is returned with the response.
+func (l *Ledger) Invoke(cc shim.Chaincode, opts ...TxOption) (pb.Response, *Stub) {
+	ctx := l.NewTx(opts...)
+
+	res := cc.Invoke(ctx.Stub)
+	if res.Status >= shim.ERRORTHRESHOLD {
+		return res, ctx.Stub
+	}
+
+	if err := ctx.Commit(); err != nil {
+		return shim.Error(err.Error()), ctx.Stub
+	}
+
+	return res, ctx.Stub
+}
+
+// State returns the committed value of a key, or nil if the key does not exist.
+func (l *Ledger) State(key string) []byte {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return copyBytes(l.state[key])
+}
+
+// PutState sets the committed value of a key outside of a transaction, for seeding a test.
+func (l *Ledger) PutState(key string, value []byte) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.state[key] = copyBytes(value)
+}
+
+// Keys returns the committed keys in lexical order, including composite keys.
+func (l *Ledger) Keys() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return sortedKeys(l.state)
+}
+
+// Events returns the events of the committed transactions, in commit order.
+func (l *Ledger) Events() []Event {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return append([]Event{}, l.events...)
+}
+
+// commit applies the writes and event of a transaction.
+func (l *Ledger) commit(s *Stub) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	ts := timestamppb.New(s.timestamp)
+
+	for _, collection := range sortedKeys(s.writes) {
+		target := l.state
+		if collection != "" {
+			if l.private[collection] == nil {
+				l.private[collection] = map[string][]byte{}
+			}
+			target = l.private[collection]
+		}
+
+		writes := s.writes[collection]
+		for _, key := range sortedKeys(writes) {
+			w := writes[key]
+			if w.isDelete {
+				delete(target, key)
+			} else {
+				target[key] = w.value
+			}
+
+			if collection == "" {
+				l.history[key] = append(l.history[key], &queryresult.KeyModification{
+					TxId:      s.txId,
+					Value:     w.value,
+					Timestamp: ts,
+					IsDelete:  w.isDelete,
+				})
+			}
+		}
+	}
+
+	for key, ep := range s.validationWrites {
+		l.validation[key] = ep
+	}
+
+	if s.event != nil {
+		l.events = append(l.events, Event{s.txId, s.event.EventName, s.event.Payload})
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func copyBytes(b []byte) []byte {
+	if b == nil {
+		return nil
+	}
+
+	return append([]byte{}, b...)
+}
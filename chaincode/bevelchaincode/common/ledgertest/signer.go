@@ -0,0 +1,142 @@
+package ledgertest
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"time"
+
+	"github.com/Subskribo-BV/dnn-fabric-chaincode/common/contract"
+)
+
+// Signer is a signature provider signing contract signature packages with an RSA 2048 key,
+// certified by a certificate authority of its own.
+type Signer struct {
+	CA   *x509.Certificate
+	Cert *x509.Certificate
+
+	key *rsa.PrivateKey
+}
+
+// NewSigner returns a signer whose certificate, with the common name, is issued by a new certificate authority.
+// Both are valid from 2000 to 2100. As for client identities, a failure of key generation panics.
+func NewSigner(name string) *Signer {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name + " CA"},
+		NotBefore:             time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
+		NotAfter:              time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	s := &Signer{}
+	s.CA = issueCert(caTemplate, caTemplate, &caKey.PublicKey, caKey)
+
+	if s.key, err = rsa.GenerateKey(rand.Reader, 2048); err != nil {
+		panic(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    caTemplate.NotBefore,
+		NotAfter:     caTemplate.NotAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	s.Cert = issueCert(template, s.CA, &s.key.PublicKey, caKey)
+
+	return s
+}
+
+// Roots returns a pool holding the certificate authority of the signer.
+func (s *Signer) Roots() *x509.CertPool {
+	roots := x509.NewCertPool()
+	roots.AddCert(s.CA)
+	return roots
+}
+
+// KeyInfo returns the key info of the signer, with its certificate in PEM format.
+func (s *Signer) KeyInfo() contract.KeyInfo {
+	return contract.KeyInfo{
+		KeyId:           s.Cert.Subject.CommonName,
+		KeyType:         "rsa2048",
+		KeySource:       "local",
+		X509Certificate: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: s.Cert.Raw})),
+	}
+}
+
+// Sign returns the base64 signature of a hash computed by JsonHashS256, as contract.VerifyHashSignature verifies it.
+func (s *Signer) Sign(hash string) string {
+	digest, err := base64.StdEncoding.DecodeString(hash)
+	if err != nil {
+		panic(err)
+	}
+
+	if len(digest) != sha256.Size {
+		panic("not a SHA256 hash: " + hash)
+	}
+
+	sig, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, digest)
+	if err != nil {
+		panic(err)
+	}
+
+	return base64.StdEncoding.EncodeToString(sig)
+}
+
+// SignPackages signs every signature package of the immutable contract with the key of the signer.
+// The key info of each package is set and its hash computed before it is signed;
+// the signatures hash is left to Seal.
+func (s *Signer) SignPackages(ic *contract.ImmutableContract) error {
+	var err error
+
+	if ic.ContractHash == "" {
+		if ic.ContractHash, err = contract.JsonHashS256(ic.Contract); err != nil {
+			return err
+		}
+	}
+
+	for i := range ic.ContractSignatures.Signatures {
+		sp := &ic.ContractSignatures.Signatures[i]
+		if sp.ContractSignaturePackage.ContractHash == "" {
+			sp.ContractSignaturePackage.ContractHash = ic.ContractHash
+		}
+		sp.ContractSignaturePackage.KeyInfo = s.KeyInfo()
+
+		if sp.ContractSignaturePackageHash, err = contract.JsonHashS256(sp.ContractSignaturePackage); err != nil {
+			return err
+		}
+		sp.Signature = s.Sign(sp.ContractSignaturePackageHash)
+	}
+
+	return nil
+}
+
+func issueCert(template, parent *x509.Certificate, pub any, key crypto.Signer) *x509.Certificate {
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, pub, key)
+	if err != nil {
+		panic(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		panic(err)
+	}
+
+	return cert
+}
@@ -0,0 +1,471 @@
+package ledgertest
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const compositeKeyNamespace = "\x00"
+
+var (
+	_ shim.ChaincodeStubInterface             = (*Stub)(nil)
+	_ cid.ClientIdentity                      = (*ClientIdentity)(nil)
+	_ contractapi.TransactionContextInterface = (*TransactionContext)(nil)
+)
+
+type write struct {
+	value    []byte
+	isDelete bool
+}
+
+// Stub is the chaincode stub of a transaction on an in-memory ledger.
+// Reads see the committed state only, writes are applied when the transaction is committed.
+type Stub struct {
+	ledger *Ledger
+
+	txId        string
+	timestamp   time.Time
+	args        [][]byte
+	transient   map[string][]byte
+	decorations map[string][]byte
+	identity    *ClientIdentity
+
+	writes           map[string]map[string]*write // by collection, "" for the world state
+	validationWrites map[string][]byte
+	event            *pb.ChaincodeEvent
+	paginated        bool
+	committed        bool
+}
+
+// Event returns the event set by the transaction, or nil.
+func (s *Stub) Event() *pb.ChaincodeEvent {
+	return s.event
+}
+
+// Written returns the value written to a key of the world state by the transaction,
+// and whether the key was written or deleted.
+func (s *Stub) Written(key string) ([]byte, bool) {
+	w, ok := s.writes[""][key]
+	if !ok {
+		return nil, false
+	}
+
+	return w.value, true
+}
+
+func (s *Stub) commit() error {
+	if s.committed {
+		return errors.New("transaction already committed")
+	}
+
+	// as on a peer, paginated queries are only supported in read only transactions
+	if s.paginated && s.hasWrites() {
+		return errors.New("paginated queries are not supported in update transactions")
+	}
+
+	s.committed = true
+	s.ledger.commit(s)
+
+	return nil
+}
+
+func (s *Stub) hasWrites() bool {
+	for _, w := range s.writes {
+		if len(w) > 0 {
+			return true
+		}
+	}
+
+	return len(s.validationWrites) > 0
+}
+
+func (s *Stub) GetArgs() [][]byte {
+	return s.args
+}
+
+func (s *Stub) GetStringArgs() []string {
+	args := make([]string, len(s.args))
+	for i, a := range s.args {
+		args[i] = string(a)
+	}
+
+	return args
+}
+
+func (s *Stub) GetFunctionAndParameters() (string, []string) {
+	args := s.GetStringArgs()
+	if len(args) == 0 {
+		return "", []string{}
+	}
+
+	return args[0], args[1:]
+}
+
+func (s *Stub) GetArgsSlice() ([]byte, error) {
+	res := []byte{}
+	for _, a := range s.args {
+		res = append(res, a...)
+	}
+
+	return res, nil
+}
+
+func (s *Stub) GetTxID() string {
+	return s.txId
+}
+
+func (s *Stub) GetChannelID() string {
+	return s.ledger.channelId
+}
+
+func (s *Stub) InvokeChaincode(chaincodeName string, args [][]byte, channel string) pb.Response {
+	return shim.Error("invoking other chaincodes is not supported by the in-memory ledger")
+}
+
+func (s *Stub) GetState(key string) ([]byte, error) {
+	if key == "" {
+		return nil, errors.New("key must not be an empty string")
+	}
+
+	return s.ledger.State(key), nil
+}
+
+func (s *Stub) PutState(key string, value []byte) error {
+	if key == "" {
+		return errors.New("key must not be an empty string")
+	}
+
+	return s.put("", key, &write{value: copyBytes(value)})
+}
+
+func (s *Stub) DelState(key string) error {
+	if key == "" {
+		return errors.New("key must not be an empty string")
+	}
+
+	return s.put("", key, &write{isDelete: true})
+}
+
+func (s *Stub) put(collection string, key string, w *write) error {
+	if s.committed {
+		return errors.New("transaction already committed")
+	}
+
+	if s.writes[collection] == nil {
+		s.writes[collection] = map[string]*write{}
+	}
+	s.writes[collection][key] = w
+
+	return nil
+}
+
+func (s *Stub) SetStateValidationParameter(key string, ep []byte) error {
+	if s.validationWrites == nil {
+		s.validationWrites = map[string][]byte{}
+	}
+	s.validationWrites[key] = copyBytes(ep)
+
+	return nil
+}
+
+func (s *Stub) GetStateValidationParameter(key string) ([]byte, error) {
+	s.ledger.mu.Lock()
+	defer s.ledger.mu.Unlock()
+
+	return copyBytes(s.ledger.validation[key]), nil
+}
+
+func (s *Stub) GetStateByRange(startKey, endKey string) (shim.StateQueryIteratorInterface, error) {
+	if err := validateSimpleKeys(startKey, endKey); err != nil {
+		return nil, err
+	}
+
+	return newStateIterator(s.rangeKVs("", startKey, endKey)), nil
+}
+
+func (s *Stub) GetStateByRangeWithPagination(startKey, endKey string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	if err := validateSimpleKeys(startKey, endKey); err != nil {
+		return nil, nil, err
+	}
+
+	return s.page(s.rangeKVs("", startKey, endKey), pageSize, bookmark)
+}
+
+func (s *Stub) GetStateByPartialCompositeKey(objectType string, keys []string) (shim.StateQueryIteratorInterface, error) {
+	prefix, err := s.CreateCompositeKey(objectType, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	return newStateIterator(s.prefixKVs("", prefix)), nil
+}
+
+func (s *Stub) GetStateByPartialCompositeKeyWithPagination(objectType string, keys []string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	prefix, err := s.CreateCompositeKey(objectType, keys)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return s.page(s.prefixKVs("", prefix), pageSize, bookmark)
+}
+
+// page returns a page of pageSize records starting at the bookmark key,
+// with the key of the first record of the next page as bookmark.
+func (s *Stub) page(kvs []*kv, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	if pageSize < 1 {
+		return nil, nil, errors.New("page size must be greater than zero")
+	}
+
+	s.paginated = true
+
+	start := 0
+	if bookmark != "" {
+		for start < len(kvs) && kvs[start].Key < bookmark {
+			start++
+		}
+	}
+
+	end := start + int(pageSize)
+	next := ""
+	if end < len(kvs) {
+		next = kvs[end].Key
+	} else {
+		end = len(kvs)
+	}
+
+	meta := &pb.QueryResponseMetadata{
+		FetchedRecordsCount: int32(end - start),
+		Bookmark:            next,
+	}
+
+	return newStateIterator(kvs[start:end]), meta, nil
+}
+
+func (s *Stub) CreateCompositeKey(objectType string, attributes []string) (string, error) {
+	return shim.CreateCompositeKey(objectType, attributes)
+}
+
+func (s *Stub) SplitCompositeKey(compositeKey string) (string, []string, error) {
+	if !strings.HasPrefix(compositeKey, compositeKeyNamespace) {
+		return "", nil, fmt.Errorf("not a composite key: %q", compositeKey)
+	}
+
+	components := []string{}
+	start := 1
+	for i := 1; i < len(compositeKey); i++ {
+		if compositeKey[i] == 0 {
+			components = append(components, compositeKey[start:i])
+			start = i + 1
+		}
+	}
+
+	if len(components) == 0 {
+		return "", nil, fmt.Errorf("not a composite key: %q", compositeKey)
+	}
+
+	return components[0], components[1:], nil
+}
+
+func (s *Stub) GetQueryResult(query string) (shim.StateQueryIteratorInterface, error) {
+	return nil, errors.New("rich queries are not supported by the in-memory ledger")
+}
+
+func (s *Stub) GetQueryResultWithPagination(query string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	return nil, nil, errors.New("rich queries are not supported by the in-memory ledger")
+}
+
+// GetHistoryForKey returns the committed modifications of a key, the most recent first.
+func (s *Stub) GetHistoryForKey(key string) (shim.HistoryQueryIteratorInterface, error) {
+	s.ledger.mu.Lock()
+	defer s.ledger.mu.Unlock()
+
+	history := s.ledger.history[key]
+	mods := make([]*queryresult.KeyModification, len(history))
+	for i, m := range history {
+		mods[len(history)-1-i] = m
+	}
+
+	return &historyIterator{mods: mods}, nil
+}
+
+func (s *Stub) GetPrivateData(collection, key string) ([]byte, error) {
+	if collection == "" {
+		return nil, errors.New("collection must not be an empty string")
+	}
+
+	s.ledger.mu.Lock()
+	defer s.ledger.mu.Unlock()
+
+	return copyBytes(s.ledger.private[collection][key]), nil
+}
+
+func (s *Stub) GetPrivateDataHash(collection, key string) ([]byte, error) {
+	value, err := s.GetPrivateData(collection, key)
+	if err != nil || value == nil {
+		return nil, err
+	}
+
+	hash := sha256.Sum256(value)
+	return hash[:], nil
+}
+
+func (s *Stub) PutPrivateData(collection string, key string, value []byte) error {
+	if collection == "" {
+		return errors.New("collection must not be an empty string")
+	}
+
+	if key == "" {
+		return errors.New("key must not be an empty string")
+	}
+
+	return s.put(collection, key, &write{value: copyBytes(value)})
+}
+
+func (s *Stub) DelPrivateData(collection, key string) error {
+	if collection == "" {
+		return errors.New("collection must not be an empty string")
+	}
+
+	return s.put(collection, key, &write{isDelete: true})
+}
+
+func (s *Stub) PurgePrivateData(collection, key string) error {
+	return s.DelPrivateData(collection, key)
+}
+
+func (s *Stub) SetPrivateDataValidationParameter(collection, key string, ep []byte) error {
+	return s.SetStateValidationParameter(collection+compositeKeyNamespace+key, ep)
+}
+
+func (s *Stub) GetPrivateDataValidationParameter(collection, key string) ([]byte, error) {
+	return s.GetStateValidationParameter(collection + compositeKeyNamespace + key)
+}
+
+func (s *Stub) GetPrivateDataByRange(collection, startKey, endKey string) (shim.StateQueryIteratorInterface, error) {
+	if collection == "" {
+		return nil, errors.New("collection must not be an empty string")
+	}
+
+	if err := validateSimpleKeys(startKey, endKey); err != nil {
+		return nil, err
+	}
+
+	return newStateIterator(s.rangeKVs(collection, startKey, endKey)), nil
+}
+
+func (s *Stub) GetPrivateDataByPartialCompositeKey(collection, objectType string, keys []string) (shim.StateQueryIteratorInterface, error) {
+	if collection == "" {
+		return nil, errors.New("collection must not be an empty string")
+	}
+
+	prefix, err := s.CreateCompositeKey(objectType, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	return newStateIterator(s.prefixKVs(collection, prefix)), nil
+}
+
+func (s *Stub) GetPrivateDataQueryResult(collection, query string) (shim.StateQueryIteratorInterface, error) {
+	return nil, errors.New("rich queries are not supported by the in-memory ledger")
+}
+
+// GetCreator returns the serialized identity of the client.
+func (s *Stub) GetCreator() ([]byte, error) {
+	return s.identity.Creator(), nil
+}
+
+func (s *Stub) GetTransient() (map[string][]byte, error) {
+	return s.transient, nil
+}
+
+func (s *Stub) GetBinding() ([]byte, error) {
+	return nil, nil
+}
+
+func (s *Stub) GetDecorations() map[string][]byte {
+	return s.decorations
+}
+
+func (s *Stub) GetSignedProposal() (*pb.SignedProposal, error) {
+	return &pb.SignedProposal{}, nil
+}
+
+func (s *Stub) GetTxTimestamp() (*timestamp.Timestamp, error) {
+	return timestamppb.New(s.timestamp), nil
+}
+
+// SetEvent sets the event of the transaction. As on a peer, a transaction has a single event,
+// so a later call replaces the event.
+func (s *Stub) SetEvent(name string, payload []byte) error {
+	if name == "" {
+		return errors.New("event name can not be empty string")
+	}
+
+	s.event = &pb.ChaincodeEvent{
+		TxId:      s.txId,
+		EventName: name,
+		Payload:   copyBytes(payload),
+	}
+
+	return nil
+}
+
+// rangeKVs returns the committed simple keys in [startKey, endKey), an empty key leaves the range open.
+func (s *Stub) rangeKVs(collection string, startKey, endKey string) []*kv {
+	return s.kvs(collection, func(key string) bool {
+		return !strings.HasPrefix(key, compositeKeyNamespace) &&
+			key >= startKey && (endKey == "" || key < endKey)
+	})
+}
+
+func (s *Stub) prefixKVs(collection string, prefix string) []*kv {
+	return s.kvs(collection, func(key string) bool {
+		return strings.HasPrefix(key, prefix)
+	})
+}
+
+func (s *Stub) kvs(collection string, match func(key string) bool) []*kv {
+	s.ledger.mu.Lock()
+	defer s.ledger.mu.Unlock()
+
+	state := s.ledger.state
+	if collection != "" {
+		state = s.ledger.private[collection]
+	}
+
+	kvs := []*kv{}
+	for _, key := range sortedKeys(state) {
+		if match(key) {
+			kvs = append(kvs, &kv{Key: key, Value: copyBytes(state[key])})
+		}
+	}
+
+	return kvs
+}
+
+func validateSimpleKeys(keys ...string) error {
+	for _, key := range keys {
+		if !utf8.ValidString(key) {
+			return fmt.Errorf("not a valid utf8 string: [%x]", key)
+		}
+
+		if strings.HasPrefix(key, compositeKeyNamespace) {
+			return fmt.Errorf("first character of the key [%s] contains a null character which is not allowed", key)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,189 @@
+package ledgertest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+func keys(t *testing.T, it shim.StateQueryIteratorInterface) []string {
+	t.Helper()
+	defer it.Close()
+
+	res := []string{}
+	for it.HasNext() {
+		kv, err := it.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		res = append(res, kv.Key)
+	}
+
+	return res
+}
+
+func TestStubReadsCommittedState(t *testing.T) {
+	l := NewLedger()
+
+	ctx := l.NewTx()
+	if err := ctx.GetStub().PutState("a", []byte("1")); err != nil {
+		t.Fatal(err)
+	}
+
+	if v, _ := ctx.GetStub().GetState("a"); v != nil {
+		t.Errorf("uncommitted write read back: %q", v)
+	}
+
+	if err := ctx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ctx.Commit(); err == nil {
+		t.Error("transaction committed twice")
+	}
+
+	if v, _ := l.NewTx().GetStub().GetState("a"); string(v) != "1" {
+		t.Errorf("committed value = %q, want %q", v, "1")
+	}
+}
+
+func TestStubQueries(t *testing.T) {
+	l := NewLedger()
+
+	ctx := l.NewTx()
+	stub := ctx.GetStub()
+	for _, k := range []string{"k1", "k2", "k3", "k4", "k5"} {
+		stub.PutState(k, []byte(k))
+	}
+	for _, attrs := range [][]string{{"b", "2"}, {"a", "1"}, {"a", "2"}} {
+		key, _ := stub.CreateCompositeKey("idx", attrs)
+		stub.PutState(key, []byte{0})
+	}
+	if err := ctx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	stub = l.NewTx().GetStub()
+
+	it, err := stub.GetStateByRange("k2", "k4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprint(keys(t, it)); got != "[k2 k3]" {
+		t.Errorf("range = %v", got)
+	}
+
+	it, err = stub.GetStateByRange("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(keys(t, it)); got != 5 {
+		t.Errorf("open range returned %d keys, want the 5 simple keys", got)
+	}
+
+	pages := []string{}
+	bookmark := ""
+	for {
+		it, meta, err := stub.GetStateByRangeWithPagination("", "", 2, bookmark)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages = append(pages, fmt.Sprint(keys(t, it)))
+		if bookmark = meta.Bookmark; bookmark == "" {
+			break
+		}
+	}
+	if got := fmt.Sprint(pages); got != "[[k1 k2] [k3 k4] [k5]]" {
+		t.Errorf("pages = %v", got)
+	}
+
+	it, err = stub.GetStateByPartialCompositeKey("idx", []string{"a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := keys(t, it)
+	if len(found) != 2 {
+		t.Fatalf("partial composite key returned %d keys, want 2", len(found))
+	}
+
+	objectType, attrs, err := stub.SplitCompositeKey(found[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if objectType != "idx" || fmt.Sprint(attrs) != "[a 2]" {
+		t.Errorf("split = %q %v", objectType, attrs)
+	}
+}
+
+func TestStubRejectsPaginationInUpdate(t *testing.T) {
+	l := NewLedger()
+
+	ctx := l.NewTx()
+	if _, _, err := ctx.GetStub().GetStateByRangeWithPagination("", "", 10, ""); err != nil {
+		t.Fatal(err)
+	}
+	ctx.GetStub().PutState("a", []byte("1"))
+
+	if err := ctx.Commit(); err == nil {
+		t.Error("paginated update transaction committed")
+	}
+}
+
+func TestStubHistoryAndEvents(t *testing.T) {
+	l := NewLedger()
+
+	for _, v := range []string{"1", "2"} {
+		ctx := l.NewTx()
+		ctx.GetStub().PutState("a", []byte(v))
+		ctx.GetStub().SetEvent("first", nil)
+		ctx.GetStub().SetEvent("Changed", []byte(v))
+		if err := ctx.Commit(); err != nil {
+			t.Fatal(err)
+		}
+		l.Advance(1)
+	}
+
+	ctx := l.NewTx()
+	ctx.GetStub().DelState("a")
+	if err := ctx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	it, err := l.NewTx().GetStub().GetHistoryForKey("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer it.Close()
+
+	got := []string{}
+	for it.HasNext() {
+		mod, _ := it.Next()
+		got = append(got, fmt.Sprintf("%s:%s:%t", mod.TxId, mod.Value, mod.IsDelete))
+	}
+	if fmt.Sprint(got) != "[tx3::true tx2:2:false tx1:1:false]" {
+		t.Errorf("history = %v", got)
+	}
+
+	events := l.Events()
+	if len(events) != 2 || events[0].Name != "Changed" || string(events[1].Payload) != "2" {
+		t.Errorf("events = %+v", events)
+	}
+}
+
+func TestClientIdentity(t *testing.T) {
+	id := NewClientIdentity("Org2MSP", "alice", nil).WithAttribute("user_id", "c102")
+	ctx := NewLedger().NewTx(WithIdentity(id))
+
+	if msp, _ := ctx.GetClientIdentity().GetMSPID(); msp != "Org2MSP" {
+		t.Errorf("msp = %q", msp)
+	}
+
+	if v, found, _ := ctx.GetClientIdentity().GetAttributeValue("user_id"); !found || v != "c102" {
+		t.Errorf("user_id = %q %t", v, found)
+	}
+
+	if err := ctx.GetClientIdentity().AssertAttributeValue("user_id", "c103"); err == nil {
+		t.Error("attribute assertion passed for another value")
+	}
+}
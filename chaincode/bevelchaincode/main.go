@@ -5,20 +5,97 @@ SPDX-License-Identifier: Apache-2.0
 package main
 
 import (
+	"fmt"
 	"log"
+	"os"
+	"strconv"
 
 	"github.com/Subskribo-BV/dnn-fabric-chaincode/service"
 
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 )
 
+const (
+	envRateLimit = "CHAINCODE_RATE_LIMIT" // transactions per second allowed for each caller; the rate limit is off if not set
+	envRateBurst = "CHAINCODE_RATE_BURST" // transactions a caller may submit at once, defaults to the rate limit
+
+	envLegacyContract = "CHAINCODE_LEGACY_CONTRACT" // "false" removes the legacy contract, making lifecycle the default contract
+
+	envMaxRequestSize = "CHAINCODE_MAX_REQUEST_SIZE" // maximum size in bytes of a decoded request, service.DefaultMaxRequestSize if not set
+)
+
 func main() {
-	cc, err := contractapi.NewChaincode(&service.SmartContract{})
+	s := service.NewSmartContract()
+
+	// the rate limit is off unless enabled in the environment of the peer: it is kept in the memory of
+	// each peer and is not a consensus rule, so clients limit themselves before endorsement
+	limiter, err := rateLimiter()
+	if err != nil {
+		log.Panicf("Error configuring rate limit: %v", err)
+	}
+	if limiter != nil {
+		log.Println("rate limit of each caller enabled")
+		s.Limiter = limiter
+	}
+
+	if s.MaxRequestSize, err = maxRequestSize(); err != nil {
+		log.Panicf("Error configuring request size: %v", err)
+	}
+
+	cc, err := contractapi.NewChaincode(service.Contracts(s, os.Getenv(envLegacyContract) != "false")...)
 	if err != nil {
 		log.Panicf("Error creating chaincode: %v", err)
 	}
 
+	if serverConfigured() {
+		if err := runServer(cc); err != nil {
+			log.Panicf("Error starting chaincode server: %v", err)
+		}
+		return
+	}
+
 	if err := cc.Start(); err != nil {
 		log.Panicf("Error starting chaincode: %v", err)
 	}
 }
+
+// rateLimiter returns the rate limiter enabled by the environment, or nil if CHAINCODE_RATE_LIMIT is not set.
+func rateLimiter() (*service.RateLimiter, error) {
+	v := os.Getenv(envRateLimit)
+	if v == "" {
+		return nil, nil
+	}
+
+	rate, err := strconv.ParseFloat(v, 64)
+	if err != nil || rate <= 0 {
+		return nil, fmt.Errorf("invalid %s %q", envRateLimit, v)
+	}
+
+	burst := int(rate)
+	if burst < 1 {
+		burst = 1
+	}
+
+	if v := os.Getenv(envRateBurst); v != "" {
+		if burst, err = strconv.Atoi(v); err != nil || burst < 1 {
+			return nil, fmt.Errorf("invalid %s %q", envRateBurst, v)
+		}
+	}
+
+	return service.NewRateLimiter(rate, burst), nil
+}
+
+// maxRequestSize returns the maximum request size configured by the environment, or 0 for the default.
+func maxRequestSize() (int, error) {
+	v := os.Getenv(envMaxRequestSize)
+	if v == "" {
+		return 0, nil
+	}
+
+	size, err := strconv.Atoi(v)
+	if err != nil || size < 1 {
+		return 0, fmt.Errorf("invalid %s %q", envMaxRequestSize, v)
+	}
+
+	return size, nil
+}
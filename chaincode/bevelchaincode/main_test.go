@@ -0,0 +1,32 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"testing"
+)
+
+func TestRateLimiter(t *testing.T) {
+	t.Setenv(envRateLimit, "")
+	t.Setenv(envRateBurst, "")
+
+	if limiter, err := rateLimiter(); err != nil || limiter != nil {
+		t.Errorf("rate limit on by default: %v, %v", limiter, err)
+	}
+
+	t.Setenv(envRateLimit, "5")
+
+	if limiter, err := rateLimiter(); err != nil || limiter == nil {
+		t.Errorf("rate limit not enabled: %v", err)
+	}
+
+	for _, v := range []string{"0", "-1", "fast"} {
+		t.Setenv(envRateLimit, v)
+
+		if _, err := rateLimiter(); err == nil {
+			t.Errorf("rate limit %q accepted", v)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
+)
+
+// Environment of the chaincode when run as an external service.
+// If CHAINCODE_SERVER_ADDRESS and CHAINCODE_ID are not set, the chaincode is launched by the peer.
+const (
+	envServerAddress    = "CHAINCODE_SERVER_ADDRESS" // listen address, example 0.0.0.0:7052
+	envChaincodeId      = "CHAINCODE_ID"             // package id of the chaincode installed on the peer
+	envTLSKeyFile       = "CHAINCODE_TLS_KEY_FILE"   // TLS is enabled if both key and cert files are set
+	envTLSCertFile      = "CHAINCODE_TLS_CERT_FILE"
+	envTLSClientCAFile  = "CHAINCODE_TLS_CLIENT_CA_FILE" // if set, the peer must present a client certificate issued by this CA
+	envKeepaliveTime    = "CHAINCODE_KEEPALIVE_TIME"     // duration, example 1m
+	envKeepaliveTimeout = "CHAINCODE_KEEPALIVE_TIMEOUT"  // duration, example 20s
+	envShutdownTimeout  = "CHAINCODE_SHUTDOWN_TIMEOUT"   // duration to wait for open streams on SIGTERM
+	envPeerMSPID        = "CORE_PEER_LOCALMSPID"         // MSP of the peer, set by the peer only for chaincode it launches
+)
+
+// defaults follow the chaincode server of the shim and the peer
+const (
+	defaultKeepaliveTime    = 1 * time.Minute
+	defaultKeepaliveTimeout = 20 * time.Second
+	defaultShutdownTimeout  = 30 * time.Second
+	keepaliveMinTime        = 1 * time.Minute
+	connectionTimeout       = 5 * time.Second
+	maxMessageSize          = 100 * 1024 * 1024
+)
+
+// serverConfigured returns true if the chaincode is to run as an external service.
+func serverConfigured() bool {
+	return os.Getenv(envServerAddress) != "" && os.Getenv(envChaincodeId) != ""
+}
+
+// runServer serves the chaincode as an external service until SIGTERM or SIGINT,
+// then stops gracefully, waiting for open streams up to the shutdown timeout.
+func runServer(cc shim.Chaincode) error {
+	if err := checkServerEnv(); err != nil {
+		return err
+	}
+
+	cs := &shim.ChaincodeServer{
+		CCID:    os.Getenv(envChaincodeId),
+		Address: os.Getenv(envServerAddress),
+		CC:      cc,
+	}
+
+	tlsConfig, err := serverTLSConfig()
+	if err != nil {
+		return err
+	}
+
+	kaOpts, err := serverKeepalive()
+	if err != nil {
+		return err
+	}
+
+	shutdownTimeout, err := envDuration(envShutdownTimeout, defaultShutdownTimeout)
+	if err != nil {
+		return err
+	}
+
+	// the grpc server is created here rather than by cs.Start, so it can be stopped gracefully
+	serverOpts := []grpc.ServerOption{
+		grpc.KeepaliveParams(*kaOpts),
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             keepaliveMinTime,
+			PermitWithoutStream: true,
+		}),
+		grpc.ConnectionTimeout(connectionTimeout),
+		grpc.MaxSendMsgSize(maxMessageSize),
+		grpc.MaxRecvMsgSize(maxMessageSize),
+	}
+
+	if tlsConfig != nil {
+		serverOpts = append(serverOpts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+
+	server := grpc.NewServer(serverOpts...)
+	pb.RegisterChaincodeServer(server, cs)
+
+	listener, err := net.Listen("tcp", cs.Address)
+	if err != nil {
+		return err
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
+	defer signal.Stop(signals)
+
+	served := make(chan error, 1)
+	go func() {
+		served <- server.Serve(listener)
+	}()
+
+	log.Printf("chaincode %s listening on %s, tls: %t", cs.CCID, cs.Address, tlsConfig != nil)
+
+	select {
+	case err := <-served:
+		return err
+	case sig := <-signals:
+		log.Printf("received %v, shutting down", sig)
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(shutdownTimeout):
+		log.Println("shutdown timeout elapsed, closing open streams")
+		server.Stop()
+	}
+
+	return nil
+}
+
+// checkServerEnv checks the environment the peer gives chaincode it launches is set for the chaincode server.
+// InitLedger and VoidAsset read the MSP of the peer from CORE_PEER_LOCALMSPID.
+func checkServerEnv() error {
+	if os.Getenv(envPeerMSPID) == "" {
+		return fmt.Errorf("%s must be set to the MSP of the peer when running as a chaincode server", envPeerMSPID)
+	}
+
+	return nil
+}
+
+// serverTLSConfig returns the TLS configuration of the server, or nil if TLS is not configured.
+// Mutual TLS is required if a client CA file is set.
+func serverTLSConfig() (*tls.Config, error) {
+	keyFile := os.Getenv(envTLSKeyFile)
+	certFile := os.Getenv(envTLSCertFile)
+	clientCAFile := os.Getenv(envTLSClientCAFile)
+
+	if keyFile == "" && certFile == "" {
+		if clientCAFile != "" {
+			return nil, fmt.Errorf("%s is set without %s and %s", envTLSClientCAFile, envTLSKeyFile, envTLSCertFile)
+		}
+		return nil, nil
+	}
+
+	if keyFile == "" || certFile == "" {
+		return nil, fmt.Errorf("both %s and %s must be set to enable TLS", envTLSKeyFile, envTLSCertFile)
+	}
+
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load TLS key pair: %v", err)
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:             tls.VersionTLS12,
+		Certificates:           []tls.Certificate{cert},
+		SessionTicketsDisabled: true,
+	}
+
+	if clientCAFile != "" {
+		caPEM, err := os.ReadFile(clientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client CA file: %v", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, errors.New("failed to load client CA file")
+		}
+
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return tlsConfig, nil
+}
+
+func serverKeepalive() (*keepalive.ServerParameters, error) {
+	kaTime, err := envDuration(envKeepaliveTime, defaultKeepaliveTime)
+	if err != nil {
+		return nil, err
+	}
+
+	kaTimeout, err := envDuration(envKeepaliveTimeout, defaultKeepaliveTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	return &keepalive.ServerParameters{
+		Time:    kaTime,
+		Timeout: kaTimeout,
+	}, nil
+}
+
+func envDuration(name string, def time.Duration) (time.Duration, error) {
+	v := os.Getenv(name)
+	if v == "" {
+		return def, nil
+	}
+
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid %s %q", name, v)
+	}
+
+	return d, nil
+}
@@ -0,0 +1,30 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckServerEnv(t *testing.T) {
+	t.Setenv(envServerAddress, "0.0.0.0:7052")
+	t.Setenv(envChaincodeId, "dnn:0123")
+	t.Setenv(envPeerMSPID, "")
+
+	if !serverConfigured() {
+		t.Fatal("server not configured")
+	}
+
+	if err := checkServerEnv(); err == nil || !strings.Contains(err.Error(), envPeerMSPID) {
+		t.Errorf("server without the MSP of the peer: %v", err)
+	}
+
+	t.Setenv(envPeerMSPID, "Org1MSP")
+
+	if err := checkServerEnv(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Subskribo-BV/dnn-fabric-chaincode/common/contract"
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// AccessRole is a role of the clients of the chaincode. Each transaction declares the roles which may call it.
+type AccessRole string
+
+const (
+	// RolePlatformAdmin governs the ledger configuration. Clients of an admin MSP hold it implicitly.
+	RolePlatformAdmin AccessRole = "platform-admin"
+	// RoleNotaryOperator releases contracts held in escrow.
+	RoleNotaryOperator AccessRole = "notary-operator"
+	// RoleAuditor reads the audit trail and the configuration history.
+	RoleAuditor AccessRole = "auditor"
+	// RoleServerSubmitter is the platform server, submitting the lifecycle transactions of contracts.
+	RoleServerSubmitter AccessRole = "server-submitter"
+)
+
+// AccessRoles are the roles known to the chaincode, in the order they are resolved.
+var AccessRoles = []AccessRole{RolePlatformAdmin, RoleNotaryOperator, RoleAuditor, RoleServerSubmitter}
+
+// RoleBinding grants a role to the clients of an MSP. If Attribute is set, only clients whose enrollment
+// certificate has the attribute hold the role, with the given value unless Value is empty.
+type RoleBinding struct {
+	Role      AccessRole `json:"role"`
+	MSPID     string     `json:"msp_id"`
+	Attribute string     `json:"attribute,omitempty" metadata:"attribute,optional"`
+	Value     string     `json:"value,omitempty" metadata:"value,optional"`
+}
+
+// matches reports whether the client of mspId holds the role of the binding.
+func (b RoleBinding) matches(ci cid.ClientIdentity, mspId string) (bool, error) {
+	if b.MSPID != mspId {
+		return false, nil
+	}
+
+	if b.Attribute == "" {
+		return true, nil
+	}
+
+	value, found, err := ci.GetAttributeValue(b.Attribute)
+	if err != nil {
+		return false, fmt.Errorf("failed getting caller attributes: %v", err)
+	}
+
+	return found && (b.Value == "" || b.Value == value), nil
+}
+
+// validateRoleBindings checks the role bindings of a configuration update.
+func validateRoleBindings(bindings []RoleBinding) error {
+	errs := contract.ValidationErrors{}
+
+	for i, b := range bindings {
+		path := fmt.Sprintf("/%d", i)
+
+		if b.Role == "" {
+			errs.Add(contract.CodeRequired, path+"/role", "role is required")
+		} else if !containsRole(AccessRoles, b.Role) {
+			errs.Add(contract.CodeUnsupported, path+"/role", fmt.Sprintf("role %q is not supported", b.Role))
+		}
+
+		if b.MSPID == "" {
+			errs.Add(contract.CodeRequired, path+"/msp_id", "MSP ID is required")
+		}
+
+		if b.Attribute == "" && b.Value != "" {
+			errs.Add(contract.CodeInvalid, path+"/value", "value requires an attribute")
+		}
+	}
+
+	return errs.Err()
+}
+
+// callerRoles returns the roles held by the client of mspId under the configuration.
+// No roles are held before InitLedger.
+func callerRoles(ci cid.ClientIdentity, mspId string, config *GovernanceConfig) ([]AccessRole, error) {
+	roles := []AccessRole{}
+	if config == nil {
+		return roles, nil
+	}
+
+	for _, role := range AccessRoles {
+		held := role == RolePlatformAdmin && containsString(config.AdminMSPs, mspId)
+
+		for _, b := range config.RoleBindings {
+			if held {
+				break
+			}
+
+			if b.Role != role {
+				continue
+			}
+
+			ok, err := b.matches(ci, mspId)
+			if err != nil {
+				return nil, err
+			}
+			held = ok
+		}
+
+		if held {
+			roles = append(roles, role)
+		}
+	}
+
+	return roles, nil
+}
+
+// authorize checks the caller holds one of roles. Any authenticated caller is authorized if roles is empty.
+func authorize(caller *Caller, function string, roles []AccessRole) error {
+	if len(roles) == 0 {
+		return nil
+	}
+
+	for _, role := range roles {
+		if containsRole(caller.Roles, role) {
+			return nil
+		}
+	}
+
+	names := make([]string, len(roles))
+	for i, role := range roles {
+		names[i] = string(role)
+	}
+
+	return fmt.Errorf("caller %s of %s may not call %s, it requires the role %s", caller.ID, caller.MSPID, function, strings.Join(names, " or "))
+}
+
+// WhoAmI returns the resolved identity of the caller and the roles it holds.
+func (s *SmartContract) WhoAmI(ctx contractapi.TransactionContextInterface) (*Caller, error) {
+	if tc, ok := ctx.(TransactionContextInterface); ok && tc.GetCaller() != nil {
+		return tc.GetCaller(), nil
+	}
+
+	config, err := s.getConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return resolveCaller(ctx, config)
+}
+
+func containsRole(roles []AccessRole, role AccessRole) bool {
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Subskribo-BV/dnn-fabric-chaincode/common/ledgertest"
+)
+
+func TestAccessRoles(t *testing.T) {
+	l := testLedger(t)
+	cc := testChaincode(t, NewSmartContract())
+
+	notary := ledgertest.NewClientIdentity("Org2MSP", "notary", map[string]string{"role": "notary"})
+	auditor := ledgertest.NewClientIdentity("Org2MSP", "auditor", map[string]string{"auditor": "true"})
+	client := ledgertest.NewClientIdentity("Org2MSP", "client", map[string]string{"role": "client"})
+
+	caller := new(Caller)
+	if err := invoke(t, l, cc, caller, "query:WhoAmI"); err != nil {
+		t.Fatal(err)
+	}
+	if caller.MSPID != "Org1MSP" || caller.UserId != "c102" || caller.ID == "" || len(caller.Roles) != 0 {
+		t.Errorf("caller before InitLedger: %+v", caller)
+	}
+
+	if err := invoke(t, l, cc, nil, "admin:InitLedger", encode(t, InitLedgerReq{AdminMSPs: []string{"Org1MSP"}})); err != nil {
+		t.Fatal(err)
+	}
+
+	config := new(GovernanceConfig)
+	if err := invoke(t, l, cc, config, "query:ReadConfig"); err != nil {
+		t.Fatal(err)
+	}
+
+	config.RoleBindings = []RoleBinding{
+		{Role: RoleServerSubmitter, MSPID: "Org1MSP"},
+		{Role: RoleNotaryOperator, MSPID: "Org2MSP", Attribute: "role", Value: "notary"},
+		{Role: RoleAuditor, MSPID: "Org2MSP", Attribute: "auditor"},
+	}
+	req := UpdateConfigReq{Version: config.Version, AdminMSPs: config.AdminMSPs, RoleBindings: config.RoleBindings, Rules: config.Rules, AuditRetentionDays: config.AuditRetentionDays}
+	if err := invoke(t, l, cc, nil, "admin:UpdateConfig", encode(t, req)); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		id    *ledgertest.ClientIdentity
+		roles string
+	}{
+		{testServer, "[platform-admin server-submitter]"},
+		{notary, "[notary-operator]"},
+		{auditor, "[auditor]"},
+		{client, "[]"},
+	} {
+		caller := new(Caller)
+		if err := invokeAs(t, l, cc, tc.id, caller, "query:WhoAmI"); err != nil {
+			t.Fatal(err)
+		}
+
+		if fmt.Sprint(caller.Roles) != tc.roles {
+			t.Errorf("roles of %s = %v, want %s", tc.id.Cert.Subject.CommonName, caller.Roles, tc.roles)
+		}
+	}
+
+	ic, hash := buildContract(t, ledgertest.NewContract(1001, testSealedOn))
+	data := encode(t, NewAssetReq{ImmutableContract: *ic, ImmutableContractHash: hash})
+
+	err := invokeAs(t, l, cc, notary, nil, "lifecycle:CreateAsset", data)
+	if err == nil || !strings.Contains(err.Error(), "requires the role server-submitter") {
+		t.Errorf("create by a notary operator: %v", err)
+	}
+
+	if err := invoke(t, l, cc, nil, "lifecycle:CreateAsset", data); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := invokeAs(t, l, cc, auditor, nil, "query:GetAuditRecords", "", ""); err != nil {
+		t.Errorf("audit records read by an auditor: %v", err)
+	}
+
+	err = invokeAs(t, l, cc, client, nil, "query:GetAuditRecords", "", "")
+	if err == nil || !strings.Contains(err.Error(), "requires the role auditor or platform-admin") {
+		t.Errorf("audit records read by a client without roles: %v", err)
+	}
+
+	// queries without a role are open to any authenticated client
+	if err := invokeAs(t, l, cc, client, nil, "query:ReadAsset", "1001"); err != nil {
+		t.Errorf("read by a client without roles: %v", err)
+	}
+}
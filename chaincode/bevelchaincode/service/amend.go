@@ -0,0 +1,226 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Subskribo-BV/dnn-fabric-chaincode/common/contract"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+type AmendContractResponse struct {
+	ContractId    int64  `json:"contractId"`
+	PredecessorId int64  `json:"predecessorId"`
+	State         string `json:"state"`
+	TxId          string `json:"txId"`
+}
+
+// AmendContract anchors a fully signed amendment of an active contract.
+// The amendment links the contract by id and hash, and must be signed by every party to the contract
+// as required by the rules the contract was anchored with, before its signature deadline.
+// A request setting StoredOriginal references the immutable contract stored on ledger for the contract.
+// The contract moves to the superseded state, leaving the due contract indexes, and its open void proposal is closed.
+func (s *SmartContract) AmendContract(ctx contractapi.TransactionContextInterface, data string) (*AmendContractResponse, error) {
+
+	cc, err := decodeRequest[AmendContractReq](ctx, data)
+	if err != nil {
+		return nil, err
+	}
+
+	icHash, err := JsonHashS256(cc.ImmutableContract)
+	if err != nil {
+		return nil, err
+	}
+
+	if icHash != cc.ImmutableContractHash {
+		return nil, errors.New("invalid immutable contract hash")
+	}
+
+	block := &cc.ImmutableContract.Contract
+	errs := contract.ValidationErrors{}
+
+	predecessorId := cc.Original.Contract.ContractID
+	if cc.StoredOriginal {
+		if block.Amends == nil {
+			errs.Add(contract.CodeRequired, "/immutable_contract/contract/amends", "an amendment referencing the stored original must link it")
+			return nil, responseError(errs)
+		}
+		predecessorId = block.Amends.ContractId
+	}
+
+	predecessor, err := s.ReadAsset(ctx, fmt.Sprint(predecessorId))
+	if err != nil {
+		return nil, err
+	}
+
+	if cc.StoredOriginal {
+		if ok, err := s.resolveStoredContract(ctx, &cc.Original, "/original", predecessor, &errs); err != nil {
+			return nil, err
+		} else if !ok {
+			return nil, responseError(errs)
+		}
+	}
+
+	originalHash, err := JsonHashS256(cc.Original)
+	if err != nil {
+		return nil, err
+	}
+
+	if originalHash != cc.OriginalHash {
+		return nil, errors.New("invalid original immutable contract hash")
+	}
+
+	if predecessor.ContractHash != originalHash {
+		return nil, errors.New("original immutable contract hash does not match the anchored contract")
+	}
+
+	rules, rulesVersion, err := s.rules(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	errs.Append("", cc.Original.ValidateAmendment(&cc.ImmutableContract, rules))
+
+	if err := s.checkSignatureDeadline(ctx, &cc.ImmutableContract, &errs); err != nil {
+		return nil, err
+	}
+
+	if len(errs) > 0 {
+		return nil, responseError(errs)
+	}
+
+	if predecessor.State != ContractStateActive {
+		return nil, fmt.Errorf("contract %d is %s, only an active contract can be amended", predecessor.ContractId, predecessor.State)
+	}
+
+	exists, err := s.AssetExists(ctx, fmt.Sprint(block.ContractID))
+	if err != nil {
+		return nil, err
+	}
+
+	if exists {
+		return nil, fmt.Errorf("the contract %d already exists", block.ContractID)
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	t := now.Format(time.RFC3339)
+
+	successor := Contract{
+		ContractId:    block.ContractID,
+		ContractHash:  icHash,
+		CreatedAt:     t,
+		UpdatedAt:     t,
+		Version:       block.SchemaVersion,
+		RulesVersion:  rulesVersion,
+		PredecessorId: predecessor.ContractId,
+		Changes:       []Change{},
+	}
+
+	if err := s.scheduleOrActivate(ctx, &successor, block.ContractOptions.EffectiveDate, now); err != nil {
+		return nil, err
+	}
+
+	if err := s.indexExpiry(ctx, &successor, block.ContractOptions.ExpiryDate); err != nil {
+		return nil, err
+	}
+
+	successor.Changes = append(successor.Changes, Change{
+		PackageHash: originalHash,
+		PackageDate: t,
+		Action:      "amend",
+		NewState:    successor.State,
+	})
+
+	for _, sp := range cc.ImmutableContract.ContractSignatures.Signatures {
+		if err := s.indexContentSignatures(ctx, &sp.ContractSignaturePackage); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.removeEffectiveIndex(ctx, predecessor); err != nil {
+		return nil, err
+	}
+
+	if err := s.removeExpiryIndex(ctx, predecessor); err != nil {
+		return nil, err
+	}
+
+	if err := s.closeVoidProposal(ctx, predecessor.ContractId, VoidProposalStateSuperseded, now); err != nil {
+		return nil, err
+	}
+
+	predecessor.State = ContractStateSuperseded
+	predecessor.SuccessorId = successor.ContractId
+	predecessor.UpdatedAt = t
+
+	predecessor.Changes = append(predecessor.Changes, Change{
+		PackageHash: icHash,
+		PackageDate: t,
+		Action:      "supersede",
+		NewState:    predecessor.State,
+	})
+
+	if err := s.putAsset(ctx, predecessor); err != nil {
+		return nil, err
+	}
+
+	if err := s.putAsset(ctx, &successor); err != nil {
+		return nil, err
+	}
+
+	addEvent(ctx, predecessor, "supersede")
+	addEvent(ctx, &successor, "amend")
+
+	log.Println("contract amended:", predecessor.ContractId, "->", successor.ContractId, ctx.GetStub().GetTxID())
+
+	return &AmendContractResponse{
+		successor.ContractId,
+		predecessor.ContractId,
+		successor.State,
+		ctx.GetStub().GetTxID(),
+	}, nil
+}
+
+// GetContractLineage returns the chain of amendments the contract belongs to,
+// from the original contract to the latest amendment.
+func (s *SmartContract) GetContractLineage(ctx contractapi.TransactionContextInterface, id string) ([]*Contract, error) {
+	asset, err := s.ReadAsset(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[int64]bool{asset.ContractId: true}
+
+	lineage := []*Contract{asset}
+	for c := asset; c.PredecessorId != 0; {
+		if seen[c.PredecessorId] {
+			return nil, fmt.Errorf("lineage of contract %s has a cycle at contract %d", id, c.PredecessorId)
+		}
+		seen[c.PredecessorId] = true
+
+		if c, err = s.ReadAsset(ctx, fmt.Sprint(c.PredecessorId)); err != nil {
+			return nil, err
+		}
+		lineage = append([]*Contract{c}, lineage...)
+	}
+
+	for c := asset; c.SuccessorId != 0; {
+		if seen[c.SuccessorId] {
+			return nil, fmt.Errorf("lineage of contract %s has a cycle at contract %d", id, c.SuccessorId)
+		}
+		seen[c.SuccessorId] = true
+
+		if c, err = s.ReadAsset(ctx, fmt.Sprint(c.SuccessorId)); err != nil {
+			return nil, err
+		}
+		lineage = append(lineage, c)
+	}
+
+	return lineage, nil
+}
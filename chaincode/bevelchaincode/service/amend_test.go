@@ -0,0 +1,216 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Subskribo-BV/dnn-fabric-chaincode/common/contract"
+	"github.com/Subskribo-BV/dnn-fabric-chaincode/common/ledgertest"
+)
+
+// amendment builds the amendment of the contract with a new id, sealed the day after it.
+func amendment(original *contract.ImmutableContract, originalHash string, id int64) *ledgertest.ContractBuilder {
+	return ledgertest.NewContract(id, original.SealedOnDate.Add(24*time.Hour)).Edit(func(ic *contract.ImmutableContract) {
+		ic.Contract.Amends = &contract.ContractAmendment{ContractId: original.Contract.ContractID, ImmutableContractHash: originalHash}
+	})
+}
+
+// amendContract anchors the amendment of the original contract.
+func amendContract(t *testing.T, l *ledgertest.Ledger, s *SmartContract, original *contract.ImmutableContract, originalHash string, b *ledgertest.ContractBuilder) (*contract.ImmutableContract, string, error) {
+	t.Helper()
+
+	ic, hash := buildContract(t, b)
+	data := encode(t, AmendContractReq{Original: *original, OriginalHash: originalHash, ImmutableContract: *ic, ImmutableContractHash: hash})
+
+	_, err := submit(t, l, func(ctx *ledgertest.TransactionContext) (*AmendContractResponse, error) {
+		return s.AmendContract(ctx, data)
+	})
+
+	return ic, hash, err
+}
+
+// lineageIds returns the ids of the contracts in the lineage of the contract.
+func lineageIds(t *testing.T, l *ledgertest.Ledger, s *SmartContract, id string) string {
+	t.Helper()
+
+	lineage, err := s.GetContractLineage(l.NewTx(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids := []string{}
+	for _, c := range lineage {
+		ids = append(ids, fmt.Sprint(c.ContractId))
+	}
+
+	return strings.Join(ids, ",")
+}
+
+func TestAmendContract(t *testing.T) {
+	l := testLedger(t)
+	s := new(SmartContract)
+
+	original, originalHash := createContract(t, l, s, ledgertest.NewContract(1001, testSealedOn))
+
+	// the amendment is signed after it is sealed
+	l.SetTime(testSealedOn.Add(10 * 24 * time.Hour))
+
+	_, _, err := amendContract(t, l, s, original, "other", amendment(original, originalHash, 1002))
+	assertErrorContains(t, err, "invalid original immutable contract hash")
+
+	_, _, err = amendContract(t, l, s, original, originalHash, amendment(original, originalHash, 1001))
+	assertResponseErrors(t, err, "invalid /contract/contract_id")
+
+	_, _, err = amendContract(t, l, s, original, originalHash, amendment(original, "other", 1002))
+	assertResponseErrors(t, err, "mismatch /contract/amends/immutable_contract_hash")
+
+	// an amendment not signed by a party to the contract it amends
+	_, _, err = amendContract(t, l, s, original, originalHash, amendment(original, originalHash, 1002).Edit(func(ic *contract.ImmutableContract) {
+		ic.Contract.Participants[1].UserId = "c104"
+		ic.ContractSignatures.Signatures[1].ContractSignaturePackage.UserId = "c104"
+	}))
+	assertResponseErrors(t, err, "required /contract_signatures/signatures")
+
+	if exists, _ := s.AssetExists(l.NewTx(), "1002"); exists {
+		t.Fatal("rejected amendment anchored")
+	}
+
+	first, firstHash, err := amendContract(t, l, s, original, originalHash, amendment(original, originalHash, 1002))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	predecessor := readContract(t, l, s, 1001)
+	if predecessor.State != ContractStateSuperseded || predecessor.SuccessorId != 1002 {
+		t.Errorf("unexpected predecessor: %+v", predecessor)
+	}
+
+	if successor := readContract(t, l, s, 1002); successor.State != ContractStateActive || successor.PredecessorId != 1001 {
+		t.Errorf("unexpected successor: %+v", successor)
+	}
+
+	// a superseded contract is neither amended again nor changed
+	_, _, err = amendContract(t, l, s, original, originalHash, amendment(original, originalHash, 1003))
+	assertErrorContains(t, err, "only an active contract can be amended")
+
+	_, err = submit(t, l, func(ctx *ledgertest.TransactionContext) (*VoidResponse, error) {
+		return s.VoidAsset(ctx, changeRequest(t, original, originalHash))
+	})
+	assertResponseErrors(t, err, "state ")
+
+	_, _, err = amendContract(t, l, s, first, firstHash, amendment(first, firstHash, 1001))
+	assertErrorContains(t, err, "already exists")
+
+	if _, _, err := amendContract(t, l, s, first, firstHash, amendment(first, firstHash, 1003)); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range []string{"1001", "1002", "1003"} {
+		if got := lineageIds(t, l, s, id); got != "1001,1002,1003" {
+			t.Errorf("lineage of %s = %s", id, got)
+		}
+	}
+
+	if _, err := s.GetContractLineage(l.NewTx(), "1004"); err == nil {
+		t.Error("lineage of an unknown contract")
+	}
+}
+
+func TestAmendStoredContract(t *testing.T) {
+	l := testLedger(t)
+	s := new(SmartContract)
+
+	original, originalHash := storeContract(t, l, s, ledgertest.NewContract(1001, testSealedOn), &StoreOptions{})
+	l.SetTime(testSealedOn.Add(10 * 24 * time.Hour))
+
+	amend := func(req AmendContractReq) error {
+		_, err := submit(t, l, func(ctx *ledgertest.TransactionContext) (*AmendContractResponse, error) {
+			return s.AmendContract(ctx, encode(t, req))
+		})
+		return err
+	}
+
+	ic, hash := buildContract(t, amendment(original, originalHash, 1002))
+
+	// a reference including the original is ambiguous
+	err := amend(AmendContractReq{Original: *original, OriginalHash: originalHash, StoredOriginal: true, ImmutableContract: *ic, ImmutableContractHash: hash})
+	assertResponseErrors(t, err, "invalid /original")
+
+	unlinked, unlinkedHash := buildContract(t, ledgertest.NewContract(1002, testSealedOn.Add(24*time.Hour)))
+	err = amend(AmendContractReq{OriginalHash: originalHash, StoredOriginal: true, ImmutableContract: *unlinked, ImmutableContractHash: unlinkedHash})
+	assertResponseErrors(t, err, "required /immutable_contract/contract/amends")
+
+	err = amend(AmendContractReq{OriginalHash: "other", StoredOriginal: true, ImmutableContract: *ic, ImmutableContractHash: hash})
+	assertErrorContains(t, err, "invalid original immutable contract hash")
+
+	if err := amend(AmendContractReq{OriginalHash: originalHash, StoredOriginal: true, ImmutableContract: *ic, ImmutableContractHash: hash}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := lineageIds(t, l, s, "1002"); got != "1001,1002" {
+		t.Errorf("lineage = %s", got)
+	}
+
+	// the original of a contract anchored without storing it must be sent
+	_, err = submit(t, l, func(ctx *ledgertest.TransactionContext) (*AmendContractResponse, error) {
+		next, nextHash := buildContract(t, amendment(ic, hash, 1003))
+		return s.AmendContract(ctx, encode(t, AmendContractReq{OriginalHash: hash, StoredOriginal: true, ImmutableContract: *next, ImmutableContractHash: nextHash}))
+	})
+	assertResponseErrors(t, err, "required /original")
+}
+
+func TestAmendContractClosesPredecessor(t *testing.T) {
+	l := testLedger(t)
+	s := new(SmartContract)
+
+	expiry := testSealedOn.AddDate(1, 0, 0)
+	original, originalHash := createContract(t, l, s, voidableContract(1001).WithExpiry(expiry))
+
+	if _, err := proposeVoid(t, l, s, original, originalHash, "c102", 5); err != nil {
+		t.Fatal(err)
+	}
+
+	l.SetTime(testSealedOn.Add(2 * 24 * time.Hour))
+
+	if _, _, err := amendContract(t, l, s, original, originalHash, amendment(original, originalHash, 1002)); err != nil {
+		t.Fatal(err)
+	}
+
+	if l.State(expiryIndexKey(1001, expiry)) != nil {
+		t.Error("expiry date index entry of a superseded contract")
+	}
+
+	detail, err := s.ReadVoidProposal(l.NewTx(), "1001")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if detail.Proposal.State != VoidProposalStateSuperseded || detail.Proposal.ClosedAt == "" {
+		t.Errorf("unexpected void proposal: %+v", detail.Proposal)
+	}
+
+	_, err = answerVoid(t, l, s.ApproveVoid, "1001", "c101")
+	assertErrorContains(t, err, "does not have an open void proposal")
+}
+
+func TestAmendContractSignatureDeadline(t *testing.T) {
+	l := testLedger(t)
+	s := new(SmartContract)
+
+	original, originalHash := createContract(t, l, s, ledgertest.NewContract(1001, testSealedOn))
+	l.SetTime(testSealedOn.Add(10 * 24 * time.Hour))
+
+	// signed after the days to sign of the amendment have elapsed
+	late := amendment(original, originalHash, 1002).Edit(func(ic *contract.ImmutableContract) {
+		ic.ContractSignatures.Signatures[0].ContractSignaturePackage.DateSigned = ic.Contract.SealedOnDate.AddDate(0, 0, 8)
+	})
+
+	_, _, err := amendContract(t, l, s, original, originalHash, late)
+	assertResponseErrors(t, err, "out_of_range /contract_signatures/signatures/0/contract_signature_package/date_signed")
+
+	if state := readContract(t, l, s, 1001).State; state != ContractStateActive {
+		t.Errorf("state = %s, want %s", state, ContractStateActive)
+	}
+}
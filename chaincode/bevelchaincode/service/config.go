@@ -0,0 +1,354 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Subskribo-BV/dnn-fabric-chaincode/common/contract"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	configObjectType = "config"
+	rulesObjectType  = "rules"
+
+	ConfigUpdatedEvent = "ConfigUpdated"
+
+	// audit retention of a ledger initialized by InitLedger; configurations from before the setting keep audit records forever
+	DefaultAuditRetentionDays = 365
+)
+
+// GovernanceConfig is the governance configuration of the ledger, read by the validators at runtime.
+// Each update increments its version; earlier versions are kept in the history of its key.
+type GovernanceConfig struct {
+	Version      int64          `json:"version"`
+	AdminMSPs    []string       `json:"admin_msps"` // MSPs whose clients hold the platform-admin role
+	RoleBindings []RoleBinding  `json:"role_bindings"`
+	Rules        contract.Rules `json:"rules"`
+
+	AuditRetentionDays int64 `json:"audit_retention_days,omitempty" metadata:"audit_retention_days,optional"` // days audit records are kept before PruneAuditRecords deletes them, 0 to keep them forever
+
+	UpdatedAt string `json:"updated_at"`
+	UpdatedBy Caller `json:"updated_by"`
+	TxId      string `json:"tx_id"`
+}
+
+type InitLedgerReq struct {
+	AdminMSPs []string `json:"admin_msps"` // MSPs whose clients hold the platform-admin role
+}
+
+type UpdateConfigReq struct {
+	Version      int64          `json:"version"` // version being updated, so concurrent updates are not lost
+	AdminMSPs    []string       `json:"admin_msps"`
+	RoleBindings []RoleBinding  `json:"role_bindings"`
+	Rules        contract.Rules `json:"rules"`
+
+	AuditRetentionDays int64 `json:"audit_retention_days"`
+}
+
+type UpdateConfigResponse struct {
+	Version int64  `json:"version"`
+	TxId    string `json:"txId"`
+}
+
+// InitLedger seeds the governance configuration with the default rules and the admin MSPs of the request.
+// It may only be called once, by a client of the MSP of the endorsing peer; later changes are made with UpdateConfig.
+// The MSP of the peer is read from CORE_PEER_LOCALMSPID, which a chaincode server must be given in its environment.
+// Earlier versions took no arguments; the request is now required.
+func (s *SmartContract) InitLedger(ctx contractapi.TransactionContextInterface, data string) error {
+	req, err := decodeRequest[InitLedgerReq](ctx, data)
+	if err != nil {
+		return err
+	}
+
+	config, err := s.getConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	if config != nil {
+		return errors.New("the ledger is already initialized, its configuration is updated with UpdateConfig")
+	}
+
+	caller, err := resolveCaller(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	peerMSPID, err := shim.GetMSPID()
+	if err != nil {
+		return fmt.Errorf("the MSP of the peer is not known, set CORE_PEER_LOCALMSPID in the environment of the chaincode: %v", err)
+	}
+
+	if caller.MSPID != peerMSPID {
+		return fmt.Errorf("InitLedger may only be called by a client of the MSP of the peer, %s", peerMSPID)
+	}
+
+	errs := contract.ValidationErrors{}
+	errs.Append("/admin_msps", validateAdminMSPs(req.AdminMSPs))
+
+	if len(errs) > 0 {
+		return responseError(errs)
+	}
+
+	config = &GovernanceConfig{
+		Version:      1,
+		AdminMSPs:    req.AdminMSPs,
+		RoleBindings: []RoleBinding{},
+		Rules:        *contract.DefaultRules(),
+
+		AuditRetentionDays: DefaultAuditRetentionDays,
+	}
+
+	return s.putConfig(ctx, config, caller)
+}
+
+// UpdateConfig replaces the governance configuration. Only platform admins may update it.
+func (s *SmartContract) UpdateConfig(ctx contractapi.TransactionContextInterface, data string) (*UpdateConfigResponse, error) {
+	req, err := decodeRequest[UpdateConfigReq](ctx, data)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := s.getConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if config == nil {
+		return nil, errors.New("the ledger is not initialized, call InitLedger first")
+	}
+
+	caller, err := resolveCaller(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := authorize(caller, "UpdateConfig", []AccessRole{RolePlatformAdmin}); err != nil {
+		return nil, err
+	}
+
+	if req.Version != config.Version {
+		return nil, fmt.Errorf("configuration version %d is not the current version %d", req.Version, config.Version)
+	}
+
+	errs := contract.ValidationErrors{}
+	errs.Append("/rules", req.Rules.Validate())
+
+	errs.Append("/admin_msps", validateAdminMSPs(req.AdminMSPs))
+	errs.Append("/role_bindings", validateRoleBindings(req.RoleBindings))
+
+	if req.AuditRetentionDays < 0 {
+		errs.Add(contract.CodeOutOfRange, "/audit_retention_days", "audit retention days must not be negative")
+	}
+
+	if len(errs) > 0 {
+		return nil, responseError(errs)
+	}
+
+	config.Version++
+	config.AdminMSPs = req.AdminMSPs
+	config.RoleBindings = req.RoleBindings
+	config.Rules = req.Rules
+	config.AuditRetentionDays = req.AuditRetentionDays
+
+	if config.RoleBindings == nil {
+		config.RoleBindings = []RoleBinding{}
+	}
+
+	if err := s.putConfig(ctx, config, caller); err != nil {
+		return nil, err
+	}
+
+	eventJSON, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ctx.GetStub().SetEvent(ConfigUpdatedEvent, eventJSON); err != nil {
+		return nil, err
+	}
+
+	return &UpdateConfigResponse{
+		config.Version,
+		ctx.GetStub().GetTxID(),
+	}, nil
+}
+
+// ReadConfig returns the governance configuration. Before InitLedger it is version 0 with the default rules.
+func (s *SmartContract) ReadConfig(ctx contractapi.TransactionContextInterface) (*GovernanceConfig, error) {
+	config, err := s.getConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if config == nil {
+		config = &GovernanceConfig{AdminMSPs: []string{}, RoleBindings: []RoleBinding{}, Rules: *contract.DefaultRules()}
+	}
+
+	return config, nil
+}
+
+// GetConfigHistory returns every version of the governance configuration, the most recent first.
+func (s *SmartContract) GetConfigHistory(ctx contractapi.TransactionContextInterface) ([]*GovernanceConfig, error) {
+	key, err := configKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resultsIterator, err := ctx.GetStub().GetHistoryForKey(key)
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	history := []*GovernanceConfig{}
+	for resultsIterator.HasNext() {
+		mod, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		if mod.IsDelete {
+			continue
+		}
+
+		config := new(GovernanceConfig)
+		if err := json.Unmarshal(mod.Value, config); err != nil {
+			return nil, err
+		}
+
+		history = append(history, config)
+	}
+
+	return history, nil
+}
+
+// rules returns the validation rules of the governance configuration, which apply to new contracts, and its version;
+// nil and 0 for the default rules before InitLedger.
+func (s *SmartContract) rules(ctx contractapi.TransactionContextInterface) (*contract.Rules, int64, error) {
+	config, err := s.getConfig(ctx)
+	if err != nil || config == nil {
+		return nil, 0, err
+	}
+
+	return &config.Rules, config.Version, nil
+}
+
+// rulesOf returns the validation rules the contract was anchored with, those of the configuration version recorded
+// on the asset, so later configuration updates do not change the rules its changes of state are validated by.
+func (s *SmartContract) rulesOf(ctx contractapi.TransactionContextInterface, asset *Contract) (*contract.Rules, error) {
+	if asset.RulesVersion == 0 {
+		return nil, nil
+	}
+
+	key, err := rulesKey(ctx, asset.RulesVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	rulesJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+
+	if rulesJSON == nil {
+		return nil, fmt.Errorf("the rules of configuration version %d of contract %d are not on ledger", asset.RulesVersion, asset.ContractId)
+	}
+
+	rules := new(contract.Rules)
+	if err := json.Unmarshal(rulesJSON, rules); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+// validateAdminMSPs checks the admin MSPs of a configuration.
+func validateAdminMSPs(msps []string) error {
+	errs := contract.ValidationErrors{}
+
+	if len(msps) == 0 {
+		errs.Add(contract.CodeRequired, "", "at least one admin MSP is required")
+	}
+
+	for i, msp := range msps {
+		if msp == "" {
+			errs.Add(contract.CodeInvalid, fmt.Sprintf("/%d", i), "admin MSP is empty")
+		}
+	}
+
+	return errs.Err()
+}
+
+func (s *SmartContract) getConfig(ctx contractapi.TransactionContextInterface) (*GovernanceConfig, error) {
+	key, err := configKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	configJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+
+	if configJSON == nil {
+		return nil, nil
+	}
+
+	config := new(GovernanceConfig)
+	if err := json.Unmarshal(configJSON, config); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+func (s *SmartContract) putConfig(ctx contractapi.TransactionContextInterface, config *GovernanceConfig, caller *Caller) error {
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	config.UpdatedAt = now.Format(time.RFC3339)
+	config.UpdatedBy = *caller
+	config.TxId = ctx.GetStub().GetTxID()
+
+	key, err := configKey(ctx)
+	if err != nil {
+		return err
+	}
+
+	configJSON, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+
+	if err := ctx.GetStub().PutState(key, configJSON); err != nil {
+		return err
+	}
+
+	// the rules of each version are kept for the contracts anchored with them
+	key, err = rulesKey(ctx, config.Version)
+	if err != nil {
+		return err
+	}
+
+	rulesJSON, err := json.Marshal(config.Rules)
+	if err != nil {
+		return err
+	}
+
+	return ctx.GetStub().PutState(key, rulesJSON)
+}
+
+func configKey(ctx contractapi.TransactionContextInterface) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(configObjectType, []string{})
+}
+
+func rulesKey(ctx contractapi.TransactionContextInterface, version int64) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(rulesObjectType, []string{fmt.Sprint(version)})
+}
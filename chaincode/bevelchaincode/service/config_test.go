@@ -0,0 +1,173 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Subskribo-BV/dnn-fabric-chaincode/common/contract"
+	"github.com/Subskribo-BV/dnn-fabric-chaincode/common/ledgertest"
+)
+
+func TestGovernanceConfig(t *testing.T) {
+	l := testLedger(t)
+	cc := testChaincode(t, NewSmartContract())
+	other := ledgertest.NewClientIdentity("Org2MSP", "other", nil)
+
+	config := new(GovernanceConfig)
+	if err := invoke(t, l, cc, config, "query:ReadConfig"); err != nil || config.Version != 0 {
+		t.Fatalf("config before InitLedger: %+v %v", config, err)
+	}
+
+	initLedger := func(adminMSPs ...string) string {
+		return encode(t, InitLedgerReq{AdminMSPs: adminMSPs})
+	}
+
+	// only a client of the MSP of the peer may initialize the ledger
+	err := invokeAs(t, l, cc, other, nil, "admin:InitLedger", initLedger("Org2MSP"))
+	if err == nil || !strings.Contains(err.Error(), "only be called by a client of the MSP of the peer") {
+		t.Errorf("init by a client of another MSP: %v", err)
+	}
+
+	assertResponseErrors(t, invoke(t, l, cc, nil, "admin:InitLedger", initLedger()), "required /admin_msps")
+
+	if err := invoke(t, l, cc, nil, "admin:InitLedger", initLedger("Org1MSP")); err != nil {
+		t.Fatal(err)
+	}
+
+	err = invoke(t, l, cc, nil, "admin:InitLedger", initLedger("Org2MSP"))
+	if err == nil || !strings.Contains(err.Error(), "already initialized") {
+		t.Errorf("second init: %v", err)
+	}
+
+	if err := invoke(t, l, cc, config, "query:ReadConfig"); err != nil {
+		t.Fatal(err)
+	}
+	if config.Version != 1 || len(config.AdminMSPs) != 1 || config.AdminMSPs[0] != "Org1MSP" || config.Rules.MaxStorageYears != contract.MaxStorageYears {
+		t.Fatalf("unexpected seeded config: %+v", config)
+	}
+
+	rules := *contract.DefaultRules()
+	rules.SignatureProviders = []string{"Connective"}
+	bindings := []RoleBinding{{Role: RoleServerSubmitter, MSPID: "Org1MSP"}}
+	update := func(version int64, adminMSPs []string, rules contract.Rules) string {
+		return encode(t, UpdateConfigReq{Version: version, AdminMSPs: adminMSPs, RoleBindings: bindings, Rules: rules})
+	}
+
+	err = invokeAs(t, l, cc, other, nil, "admin:UpdateConfig", update(1, []string{"Org2MSP"}, rules))
+	if err == nil || !strings.Contains(err.Error(), "may not call UpdateConfig") {
+		t.Errorf("update by a client of another MSP: %v", err)
+	}
+
+	err = invoke(t, l, cc, nil, "admin:UpdateConfig", update(2, []string{"Org1MSP"}, rules))
+	if err == nil || !strings.Contains(err.Error(), "not the current version") {
+		t.Errorf("update of a stale version: %v", err)
+	}
+
+	invalid := rules
+	invalid.MaxStorageYears = 31
+	invalid.SignatureTypes = nil
+	bindings = []RoleBinding{{Role: "janitor", MSPID: "Org1MSP"}, {Role: RoleAuditor, Value: "x"}}
+	assertResponseErrors(t, invoke(t, l, cc, nil, "admin:UpdateConfig", update(1, nil, invalid)),
+		"out_of_range /rules/max_storage_years", "required /rules/signature_types", "required /admin_msps",
+		"unsupported /role_bindings/0/role", "required /role_bindings/1/msp_id", "invalid /role_bindings/1/value")
+
+	bindings = []RoleBinding{{Role: RoleServerSubmitter, MSPID: "Org1MSP"}}
+
+	res := new(UpdateConfigResponse)
+	if err := invoke(t, l, cc, res, "admin:UpdateConfig", update(1, []string{"Org1MSP", "Org2MSP"}, rules)); err != nil || res.Version != 2 {
+		t.Fatalf("update: %+v %v", res, err)
+	}
+
+	if events := l.Events(); events[len(events)-1].Name != ConfigUpdatedEvent {
+		t.Errorf("unexpected events: %+v", events)
+	}
+
+	// validators apply the updated rules
+	ic, hash := buildContract(t, ledgertest.NewContract(1001, testSealedOn))
+	assertResponseErrors(t, invoke(t, l, cc, nil, "lifecycle:CreateAsset", encode(t, NewAssetReq{ImmutableContract: *ic, ImmutableContractHash: hash})),
+		"invalid /contract/signature_method/signature_provider")
+
+	ic, hash = buildContract(t, ledgertest.NewContract(1001, testSealedOn).Edit(func(c *contract.ImmutableContract) {
+		c.Contract.SignatureMethod.SignatureProvider = "Connective"
+	}))
+	if err := invoke(t, l, cc, nil, "lifecycle:CreateAsset", encode(t, NewAssetReq{ImmutableContract: *ic, ImmutableContractHash: hash})); err != nil {
+		t.Errorf("create with an allowed provider: %v", err)
+	}
+
+	// the added admin MSP may update
+	if err := invokeAs(t, l, cc, other, nil, "admin:UpdateConfig", update(2, []string{"Org2MSP"}, *contract.DefaultRules())); err != nil {
+		t.Fatal(err)
+	}
+
+	history := []*GovernanceConfig{}
+	if err := invokeAs(t, l, cc, other, &history, "query:GetConfigHistory"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(history) != 3 || history[0].Version != 3 || history[2].Version != 1 || history[0].UpdatedBy.MSPID != "Org2MSP" {
+		t.Errorf("unexpected history: %+v", history)
+	}
+}
+
+func TestRulesOfAnchoredContract(t *testing.T) {
+	l := testLedger(t)
+	s := NewSmartContract()
+	cc := testChaincode(t, s)
+	grantRoles(t, l, cc)
+
+	ic, hash := buildContract(t, ledgertest.NewContract(1001, testSealedOn))
+	if err := invoke(t, l, cc, nil, "lifecycle:CreateAsset", encode(t, NewAssetReq{ImmutableContract: *ic, ImmutableContractHash: hash})); err != nil {
+		t.Fatal(err)
+	}
+
+	config := new(GovernanceConfig)
+	if err := invoke(t, l, cc, config, "query:ReadConfig"); err != nil {
+		t.Fatal(err)
+	}
+
+	if asset := readContract(t, l, s, 1001); asset.RulesVersion != config.Version {
+		t.Fatalf("rules version = %d, want %d", asset.RulesVersion, config.Version)
+	}
+
+	rules := config.Rules
+	rules.SignatureProviders = []string{"Connective"}
+	req := UpdateConfigReq{Version: config.Version, AdminMSPs: config.AdminMSPs, RoleBindings: config.RoleBindings, Rules: rules}
+	if err := invoke(t, l, cc, nil, "admin:UpdateConfig", encode(t, req)); err != nil {
+		t.Fatal(err)
+	}
+
+	// new contracts are validated by the updated rules
+	other, otherHash := buildContract(t, ledgertest.NewContract(1002, testSealedOn))
+	assertResponseErrors(t, invoke(t, l, cc, nil, "lifecycle:CreateAsset", encode(t, NewAssetReq{ImmutableContract: *other, ImmutableContractHash: otherHash})),
+		"invalid /contract/signature_method/signature_provider")
+
+	// the anchored contract is changed under the rules it was anchored with
+	if err := invoke(t, l, cc, nil, "lifecycle:VoidAsset", changeRequest(t, ic, hash)); err != nil {
+		t.Fatalf("void under the anchored rules: %v", err)
+	}
+
+	if state := readContract(t, l, s, 1001).State; state != ContractStateVoided {
+		t.Errorf("state = %q, want %q", state, ContractStateVoided)
+	}
+}
+
+func TestInitLedgerOfChaincodeServer(t *testing.T) {
+	l := testLedger(t)
+	cc := testChaincode(t, NewSmartContract())
+
+	// a chaincode server is not given the MSP of the peer unless set in its environment
+	t.Setenv("CHAINCODE_SERVER_ADDRESS", "0.0.0.0:7052")
+	t.Setenv("CHAINCODE_ID", "dnn:0123")
+	t.Setenv("CORE_PEER_LOCALMSPID", "")
+
+	err := invoke(t, l, cc, nil, "admin:InitLedger", encode(t, InitLedgerReq{AdminMSPs: []string{"Org1MSP"}}))
+	if err == nil || !strings.Contains(err.Error(), "set CORE_PEER_LOCALMSPID") {
+		t.Fatalf("init without the MSP of the peer: %v", err)
+	}
+
+	t.Setenv("CORE_PEER_LOCALMSPID", "Org1MSP")
+
+	if err := invoke(t, l, cc, nil, "admin:InitLedger", encode(t, InitLedgerReq{AdminMSPs: []string{"Org1MSP"}})); err != nil {
+		t.Fatal(err)
+	}
+}
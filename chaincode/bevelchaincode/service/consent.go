@@ -0,0 +1,544 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Subskribo-BV/dnn-fabric-chaincode/common/contract"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const consentObjectType = "consent"
+
+type ConsentResponse struct {
+	ContractId int64  `json:"contractId"`
+	State      string `json:"state"`
+	TxId       string `json:"txId"`
+}
+
+// BeginConsent anchors a sealed contract block which has entered the consent phase.
+// The contract is pending consent until every signatory has signed through SubmitSignature.
+func (s *SmartContract) BeginConsent(ctx contractapi.TransactionContextInterface, data string) (*ConsentResponse, error) {
+
+	cc, err := decodeRequest[ConsentAssetReq](ctx, data)
+	if err != nil {
+		return nil, err
+	}
+
+	blockHash, err := JsonHashS256(cc.ContractBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	if blockHash != cc.ContractBlockHash {
+		return nil, errors.New("invalid contract block hash")
+	}
+
+	block := &cc.ContractBlock
+
+	validator, err := contract.ValidatorFor(block)
+	if err != nil {
+		return nil, responseError(err)
+	}
+
+	rules, rulesVersion, err := s.rules(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validator.ValidateConsent(block, rules); err != nil {
+		return nil, responseError(err)
+	}
+
+	if block.Amends != nil {
+		return nil, errors.New("an amendment is anchored through AmendContract")
+	}
+
+	if block.GetSignatoryCountFromParticipants() == 0 {
+		return nil, errors.New("contract block does not have any signatories")
+	}
+
+	if block.SignatureMethod.PackageMethodId == int64(contract.SignPackageMethodId_Constructed) {
+		errs := contract.ValidationErrors{}
+		errs.Append("/constructed_content_items", block.ValidateConstructedContent(cc.ConstructedContentItems))
+
+		if err := errs.Err(); err != nil {
+			return nil, responseError(err)
+		}
+	} else if len(cc.ConstructedContentItems) > 0 {
+		return nil, errors.New("constructed content items are only for the constructed package method")
+	}
+
+	contractIdStr := fmt.Sprint(block.ContractID)
+
+	exists, err := s.AssetExists(ctx, contractIdStr)
+	if err != nil {
+		return nil, err
+	}
+
+	if exists {
+		return nil, fmt.Errorf("the contract %d already exists", block.ContractID)
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	consent := Consent{
+		ContractId:              block.ContractID,
+		ContractBlock:           cc.ContractBlock,
+		ContractBlockHash:       blockHash,
+		Signatures:              []contract.SignedContractSignature{},
+		ConstructedContentItems: cc.ConstructedContentItems,
+		Store:                   cc.Store,
+	}
+
+	deadline := newSignatureDeadline(block)
+
+	if now.After(block.SealedOnDate.AddDate(0, 0, int(block.ContractOptions.DaysToSign))) {
+		return nil, errors.New("consent period has already elapsed")
+	}
+
+	t := now.Format(time.RFC3339)
+
+	asset := Contract{
+		ContractId:   block.ContractID,
+		ContractHash: blockHash,
+		CreatedAt:    t,
+		UpdatedAt:    t,
+		State:        ContractStatePendingConsent,
+		Version:      block.SchemaVersion,
+		RulesVersion: rulesVersion,
+		Changes:      []Change{},
+	}
+
+	if err := s.putConsent(ctx, &consent); err != nil {
+		return nil, err
+	}
+
+	if err := s.putSignatureDeadline(ctx, deadline); err != nil {
+		return nil, err
+	}
+
+	if err := s.putAsset(ctx, &asset); err != nil {
+		return nil, err
+	}
+
+	addEvent(ctx, &asset, "consent")
+
+	log.Println("contract entered consent:", asset.ContractId, ctx.GetStub().GetTxID())
+
+	return &ConsentResponse{
+		asset.ContractId,
+		asset.State,
+		ctx.GetStub().GetTxID(),
+	}, nil
+}
+
+// SubmitSignature appends a signature to a contract pending consent, submitted by the signatory whose user id
+// is in the certificate of the caller. Approvers must sign before any other signatory.
+// Once the last signature is received and the signatures are complete, the contract is activated.
+func (s *SmartContract) SubmitSignature(ctx contractapi.TransactionContextInterface, data string) (*ConsentResponse, error) {
+
+	req, err := decodeRequest[SubmitSignatureReq](ctx, data)
+	if err != nil {
+		return nil, err
+	}
+
+	contractIdStr := fmt.Sprint(req.ContractId)
+
+	asset, err := s.ReadAsset(ctx, contractIdStr)
+	if err != nil {
+		return nil, err
+	}
+
+	if asset.State != ContractStatePendingConsent {
+		return nil, fmt.Errorf("contract %d is not pending consent", asset.ContractId)
+	}
+
+	consent, err := s.readConsent(ctx, contractIdStr)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline, err := s.ReadSignatureDeadline(ctx, contractIdStr)
+	if err != nil {
+		return nil, err
+	}
+
+	deadlineDate, err := parseDate(deadline.DeadlineDate)
+	if err != nil {
+		return nil, err
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if now.After(deadlineDate) {
+		return nil, errors.New("consent period has expired")
+	}
+
+	block := &consent.ContractBlock
+	sig := req.Signature
+	pkg := &sig.ContractSignaturePackage
+
+	pkgHash, err := JsonHashS256(pkg)
+	if err != nil {
+		return nil, err
+	}
+
+	if pkgHash != sig.ContractSignaturePackageHash {
+		return nil, errors.New("invalid contract signature package hash")
+	}
+
+	if err := pkg.Validate(block.SignatureMethod.PackageMethodId); err != nil {
+		return nil, responseError(err)
+	}
+
+	v, err := contract.ValidatorFor(block)
+	if err != nil {
+		return nil, responseError(err)
+	}
+
+	if err := v.ValidateSignature(block, pkg); err != nil {
+		errs := contract.ValidationErrors{}
+		errs.Append("/signature/contract_signature_package", err)
+		return nil, responseError(errs)
+	}
+
+	if pkg.ContractId != block.ContractID {
+		return nil, errors.New("contract id does not match contract id in signature package")
+	}
+
+	if pkg.ContractHash != consent.ContractBlockHash {
+		return nil, errors.New("contract hash does not match contract hash in signature package")
+	}
+
+	if pkg.DateSigned.Before(block.SealedOnDate) {
+		return nil, errors.New("signature package is dated before the contract was sealed")
+	}
+
+	if block.SignatureMethod.PackageMethodId == int64(contract.SignPackageMethodId_Constructed) &&
+		pkg.ConstructedContentId != consent.ConstructedContentItems[0].ContentId {
+		return nil, errors.New("constructed content id does not match the constructed content of the contract")
+	}
+
+	var signer *contract.ContractParticipant
+	for i := range block.Participants {
+		if block.Participants[i].UserId == pkg.UserId {
+			signer = &block.Participants[i]
+			break
+		}
+	}
+
+	if signer == nil || !signer.IsRole(contract.Signatory) {
+		return nil, fmt.Errorf("user id '%v' is not a signatory of the contract", pkg.UserId)
+	}
+
+	callerId, err := callerUserId(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if callerId != pkg.UserId {
+		return nil, fmt.Errorf("user id '%v' may not submit the signature of user id '%v'", callerId, pkg.UserId)
+	}
+
+	if pkg.IsApprover != signer.IsRole(contract.Approver) {
+		return nil, fmt.Errorf("approver flag in signature package for user id '%v' does not match participant roles", pkg.UserId)
+	}
+
+	approverCount, signedApproverCount := 0, 0
+	for _, p := range block.Participants {
+		if p.IsRole(contract.Signatory) && p.IsRole(contract.Approver) {
+			approverCount++
+		}
+	}
+
+	for _, existing := range consent.Signatures {
+		if existing.ContractSignaturePackage.UserId == pkg.UserId {
+			return nil, fmt.Errorf("user id '%v' has already signed the contract", pkg.UserId)
+		}
+
+		if existing.ContractSignaturePackage.IsApprover {
+			signedApproverCount++
+		}
+	}
+
+	if !pkg.IsApprover && signedApproverCount < approverCount {
+		return nil, errors.New("all approvers must sign before other signatories")
+	}
+
+	consent.Signatures = append(consent.Signatures, sig)
+
+	if pkg.IsApprover && signedApproverCount+1 == approverCount {
+		consent.ApproverSealedOnDate = &now
+	}
+
+	t := now.Format(time.RFC3339)
+	asset.UpdatedAt = t
+
+	asset.Changes = append(asset.Changes, Change{
+		PackageHash: sig.ContractSignaturePackageHash,
+		PackageDate: t,
+		Action:      "sign",
+		NewState:    asset.State,
+	})
+
+	complete := len(consent.Signatures) == block.GetSignatoryCountFromParticipants()
+
+	if req.FinalizedContent != nil {
+		if !complete || block.SignatureMethod.PackageMethodId != int64(contract.SignPackageMethodId_Embedded) {
+			return nil, errors.New("finalized content is only submitted with the last signature of the embedded package method")
+		}
+
+		consent.FinalizedContent = req.FinalizedContent
+	}
+
+	if complete {
+		ic, err := consent.ImmutableContract(now)
+		if err != nil {
+			return nil, err
+		}
+
+		validator, err := contract.ValidatorFor(block)
+		if err != nil {
+			return nil, responseError(err)
+		}
+
+		rules, err := s.rulesOf(ctx, asset)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := validator.ValidateCreate(ic, rules); err != nil {
+			return nil, responseError(err)
+		}
+
+		icHash, err := JsonHashS256(ic)
+		if err != nil {
+			return nil, err
+		}
+
+		asset.ContractHash = icHash
+
+		if err := s.scheduleOrActivate(ctx, asset, block.ContractOptions.EffectiveDate, now); err != nil {
+			return nil, err
+		}
+
+		if err := s.indexExpiry(ctx, asset, block.ContractOptions.ExpiryDate); err != nil {
+			return nil, err
+		}
+
+		if consent.Store != nil {
+			if err := s.storeImmutableContract(ctx, asset, ic, consent.Store); err != nil {
+				return nil, err
+			}
+		}
+
+		asset.Changes = append(asset.Changes, Change{
+			PackageHash: icHash,
+			PackageDate: t,
+			Action:      "activate",
+			NewState:    asset.State,
+		})
+
+		addEvent(ctx, asset, "activate")
+
+		log.Println("contract activated:", asset.ContractId, ctx.GetStub().GetTxID())
+	}
+
+	if err := s.putConsent(ctx, consent); err != nil {
+		return nil, err
+	}
+
+	if err := s.indexContentSignatures(ctx, pkg); err != nil {
+		return nil, err
+	}
+
+	if err := s.putAsset(ctx, asset); err != nil {
+		return nil, err
+	}
+
+	return &ConsentResponse{
+		asset.ContractId,
+		asset.State,
+		ctx.GetStub().GetTxID(),
+	}, nil
+}
+
+// ExpireConsent moves a contract which was not fully signed within its days to sign into the consent expired state.
+func (s *SmartContract) ExpireConsent(ctx contractapi.TransactionContextInterface, id string) (*ConsentResponse, error) {
+
+	asset, err := s.ReadAsset(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if asset.State != ContractStatePendingConsent {
+		return nil, fmt.Errorf("contract %d is not pending consent", asset.ContractId)
+	}
+
+	deadline, err := s.ReadSignatureDeadline(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	deadlineDate, err := parseDate(deadline.DeadlineDate)
+	if err != nil {
+		return nil, err
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if !now.After(deadlineDate) {
+		return nil, errors.New("consent period has not yet expired")
+	}
+
+	t := now.Format(time.RFC3339)
+	asset.State = ContractStateConsentExpired
+	asset.UpdatedAt = t
+
+	asset.Changes = append(asset.Changes, Change{
+		PackageDate: t,
+		Action:      "consent-expire",
+		NewState:    asset.State,
+	})
+
+	if err := s.putAsset(ctx, asset); err != nil {
+		return nil, err
+	}
+
+	addEvent(ctx, asset, "consent-expire")
+
+	return &ConsentResponse{
+		asset.ContractId,
+		asset.State,
+		ctx.GetStub().GetTxID(),
+	}, nil
+}
+
+// ReadConsentStatus returns the signatories who have signed and who are yet to sign a contract in consent.
+func (s *SmartContract) ReadConsentStatus(ctx contractapi.TransactionContextInterface, id string) (*ConsentStatus, error) {
+	consent, err := s.readConsent(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline, err := s.ReadSignatureDeadline(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &ConsentStatus{
+		ContractId:        consent.ContractId,
+		ContractBlockHash: consent.ContractBlockHash,
+		Signed:            []string{},
+		Pending:           []string{},
+		ApproversSealed:   consent.ApproverSealedOnDate != nil,
+		DeadlineDate:      deadline.DeadlineDate,
+	}
+
+	signed := map[string]bool{}
+	for _, sp := range consent.Signatures {
+		signed[sp.ContractSignaturePackage.UserId] = true
+	}
+
+	for _, p := range consent.ContractBlock.Participants {
+		if !p.IsRole(contract.Signatory) {
+			continue
+		}
+
+		if signed[p.UserId] {
+			status.Signed = append(status.Signed, p.UserId)
+		} else {
+			status.Pending = append(status.Pending, p.UserId)
+		}
+	}
+
+	return status, nil
+}
+
+// readConsent returns the sealed contract block and signatures collected so far for a contract in consent.
+func (s *SmartContract) readConsent(ctx contractapi.TransactionContextInterface, id string) (*Consent, error) {
+	key, err := ctx.GetStub().CreateCompositeKey(consentObjectType, []string{id})
+	if err != nil {
+		return nil, err
+	}
+
+	consentJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+
+	if consentJSON == nil {
+		return nil, fmt.Errorf("the consent for contract %s does not exist", id)
+	}
+
+	var consent Consent
+	if err := json.Unmarshal(consentJSON, &consent); err != nil {
+		return nil, err
+	}
+
+	return &consent, nil
+}
+
+func (s *SmartContract) putConsent(ctx contractapi.TransactionContextInterface, consent *Consent) error {
+	key, err := ctx.GetStub().CreateCompositeKey(consentObjectType, []string{fmt.Sprint(consent.ContractId)})
+	if err != nil {
+		return err
+	}
+
+	consentJSON, err := json.Marshal(consent)
+	if err != nil {
+		return err
+	}
+
+	return ctx.GetStub().PutState(key, consentJSON)
+}
+
+func (s *SmartContract) putAsset(ctx contractapi.TransactionContextInterface, asset *Contract) error {
+	contractJSON, err := json.Marshal(asset)
+	if err != nil {
+		return err
+	}
+
+	return ctx.GetStub().PutState(fmt.Sprint(asset.ContractId), contractJSON)
+}
+
+// ImmutableContract assembles the immutable contract from the sealed contract block and collected signatures,
+// sealing the signatures block and container on the given date.
+func (c *Consent) ImmutableContract(sealedOn time.Time) (*contract.ImmutableContract, error) {
+	signatures := contract.ContractSignatures{
+		ContractHash:         c.ContractBlockHash,
+		HasApprovers:         c.ApproverSealedOnDate != nil,
+		ApproverSealedOnDate: c.ApproverSealedOnDate,
+		SealedOnDate:         sealedOn,
+		Signatures:           c.Signatures,
+
+		ConstructedContentItems: c.ConstructedContentItems,
+	}
+
+	signaturesHash, err := JsonHashS256(signatures)
+	if err != nil {
+		return nil, err
+	}
+
+	return &contract.ImmutableContract{
+		Contract:               c.ContractBlock,
+		ContractHash:           c.ContractBlockHash,
+		ContractSignatures:     signatures,
+		ContractSignaturesHash: signaturesHash,
+		FinalizedContent:       c.FinalizedContent,
+		SealedOnDate:           sealedOn,
+	}, nil
+}
@@ -0,0 +1,206 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Subskribo-BV/dnn-fabric-chaincode/common/contract"
+	"github.com/Subskribo-BV/dnn-fabric-chaincode/common/ledgertest"
+)
+
+// beginConsent anchors the contract block of the contract built by b for consent.
+// The built contract is returned, whose signatures are submitted with signRequest.
+func beginConsent(t *testing.T, l *ledgertest.Ledger, s *SmartContract, b *ledgertest.ContractBuilder) *contract.ImmutableContract {
+	t.Helper()
+
+	ic, _ := buildContract(t, b)
+	data := encode(t, ConsentAssetReq{ContractBlock: ic.Contract, ContractBlockHash: ic.ContractHash})
+
+	if _, err := submit(t, l, func(ctx *ledgertest.TransactionContext) (*ConsentResponse, error) {
+		return s.BeginConsent(ctx, data)
+	}); err != nil {
+		t.Fatalf("begin consent failed: %v", err)
+	}
+
+	return ic
+}
+
+// signRequest returns the request submitting the i-th signature of the contract.
+func signRequest(t *testing.T, ic *contract.ImmutableContract, i int) string {
+	t.Helper()
+
+	return encode(t, SubmitSignatureReq{ContractId: ic.Contract.ContractID, Signature: ic.ContractSignatures.Signatures[i]})
+}
+
+// submitSignature submits the i-th signature of the contract as its signatory, returning the error of the transaction.
+func submitSignature(t *testing.T, l *ledgertest.Ledger, s *SmartContract, ic *contract.ImmutableContract, i int) error {
+	t.Helper()
+
+	_, err := submit(t, l, func(ctx *ledgertest.TransactionContext) (*ConsentResponse, error) {
+		return s.SubmitSignature(ctx, signRequest(t, ic, i))
+	}, asUser(ic.ContractSignatures.Signatures[i].ContractSignaturePackage.UserId))
+
+	return err
+}
+
+// assertErrorContains checks err is set and its message contains want.
+func assertErrorContains(t *testing.T, err error, want string) {
+	t.Helper()
+
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("error = %v, want %q", err, want)
+	}
+}
+
+func TestConsent(t *testing.T) {
+	l := testLedger(t)
+	s := new(SmartContract)
+
+	// the approver signs before the other signatories
+	b := ledgertest.NewContract(1001, testSealedOn).
+		WithParticipant("c104", "Cat Approver", contract.Signatory, contract.Approver).
+		Edit(func(ic *contract.ImmutableContract) {
+			ic.ContractSignatures.Signatures[2].ContractSignaturePackage.IsApprover = true
+		})
+	ic := beginConsent(t, l, s, b)
+
+	asset := readContract(t, l, s, 1001)
+	if asset.State != ContractStatePendingConsent || asset.ContractHash != ic.ContractHash {
+		t.Fatalf("unexpected contract: %+v", asset)
+	}
+
+	_, err := submit(t, l, func(ctx *ledgertest.TransactionContext) (*ConsentResponse, error) {
+		return s.BeginConsent(ctx, encode(t, ConsentAssetReq{ContractBlock: ic.Contract, ContractBlockHash: ic.ContractHash}))
+	})
+	assertErrorContains(t, err, "already exists")
+
+	assertErrorContains(t, submitSignature(t, l, s, ic, 0), "all approvers must sign before other signatories")
+
+	// a signatory submits its own signature only
+	_, err = submit(t, l, func(ctx *ledgertest.TransactionContext) (*ConsentResponse, error) {
+		return s.SubmitSignature(ctx, signRequest(t, ic, 2))
+	}, asUser("c102"))
+	assertErrorContains(t, err, "user id 'c102' may not submit the signature of user id 'c104'")
+
+	tampered := *ic
+	tampered.ContractSignatures.Signatures = append([]contract.SignedContractSignature{}, ic.ContractSignatures.Signatures...)
+	tampered.ContractSignatures.Signatures[2].ContractSignaturePackage.IpAddress = "10.0.0.2"
+	assertErrorContains(t, submitSignature(t, l, s, &tampered, 2), "invalid contract signature package hash")
+
+	for _, i := range []int{2, 0} {
+		if err := submitSignature(t, l, s, ic, i); err != nil {
+			t.Fatalf("signature %d: %v", i, err)
+		}
+	}
+
+	assertErrorContains(t, submitSignature(t, l, s, ic, 0), "has already signed")
+
+	status, err := s.ReadConsentStatus(l.NewTx(), "1001")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !status.ApproversSealed || len(status.Signed) != 2 || len(status.Pending) != 1 || status.Pending[0] != "c103" {
+		t.Errorf("unexpected consent status: %+v", status)
+	}
+
+	if err := submitSignature(t, l, s, ic, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	asset = readContract(t, l, s, 1001)
+	if asset.State != ContractStateActive || asset.ContractHash == ic.ContractHash {
+		t.Fatalf("unexpected contract: %+v", asset)
+	}
+
+	actions := []string{}
+	for _, c := range asset.Changes {
+		actions = append(actions, c.Action)
+	}
+	if got := strings.Join(actions, ","); got != "sign,sign,sign,activate" {
+		t.Errorf("changes = %s", got)
+	}
+
+	assertErrorContains(t, submitSignature(t, l, s, ic, 1), "not pending consent")
+
+	_, err = submit(t, l, func(ctx *ledgertest.TransactionContext) (*ConsentResponse, error) {
+		return s.ExpireConsent(ctx, "1001")
+	})
+	assertErrorContains(t, err, "not pending consent")
+}
+
+func TestExpireConsent(t *testing.T) {
+	l := testLedger(t)
+	s := new(SmartContract)
+
+	ic := beginConsent(t, l, s, ledgertest.NewContract(1001, testSealedOn))
+
+	if err := submitSignature(t, l, s, ic, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := submit(t, l, func(ctx *ledgertest.TransactionContext) (*ConsentResponse, error) {
+		return s.ExpireConsent(ctx, "1001")
+	})
+	assertErrorContains(t, err, "not yet expired")
+
+	// the days to sign of the contract have elapsed
+	l.SetTime(testSealedOn.AddDate(0, 0, 8))
+
+	assertErrorContains(t, submitSignature(t, l, s, ic, 1), "consent period has expired")
+
+	res, err := submit(t, l, func(ctx *ledgertest.TransactionContext) (*ConsentResponse, error) {
+		return s.ExpireConsent(ctx, "1001")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.State != ContractStateConsentExpired {
+		t.Errorf("state = %q, want %q", res.State, ContractStateConsentExpired)
+	}
+
+	_, err = submit(t, l, func(ctx *ledgertest.TransactionContext) (*ConsentResponse, error) {
+		return s.ExpireConsent(ctx, "1001")
+	})
+	assertErrorContains(t, err, "not pending consent")
+
+	// a contract whose consent expired is not activated
+	ic2, hash := buildContract(t, ledgertest.NewContract(1001, testSealedOn))
+	_, err = submit(t, l, func(ctx *ledgertest.TransactionContext) (*CreateAssetResponse, error) {
+		return s.CreateAsset(ctx, encode(t, NewAssetReq{ImmutableContract: *ic2, ImmutableContractHash: hash}))
+	})
+	assertResponseErrors(t, err, "state ")
+
+	_, err = submit(t, l, func(ctx *ledgertest.TransactionContext) (*ConsentResponse, error) {
+		return s.BeginConsent(ctx, encode(t, ConsentAssetReq{ContractBlock: ic.Contract, ContractBlockHash: ic.ContractHash}))
+	})
+	assertErrorContains(t, err, "already exists")
+}
+
+func TestSubmitSignatureContentSignatures(t *testing.T) {
+	// a content signature holding only the content id, as anchored under definition version 1
+	contentIdOnly := func(ic *contract.ImmutableContract) {
+		pkg := &ic.ContractSignatures.Signatures[0].ContractSignaturePackage
+		pkg.ContentSignatures = []contract.ContentSignature{{ContentId: 11}}
+	}
+
+	l := testLedger(t)
+	s := new(SmartContract)
+
+	ic := beginConsent(t, l, s, ledgertest.NewContract(1001, testSealedOn).Edit(contentIdOnly))
+	assertResponseErrors(t, submitSignature(t, l, s, ic, 0),
+		"required /signature/contract_signature_package/content_signatures/0/key_info",
+		"required /signature/contract_signature_package/content_signatures/0/date_signed")
+
+	ic = beginConsent(t, l, s, ledgertest.NewContract(1002, testSealedOn).Edit(func(ic *contract.ImmutableContract) {
+		ic.Contract.DefinitionVersion = 1
+		for i := range ic.Contract.Participants {
+			ic.Contract.Participants[i].IdentityClaims = nil
+		}
+		contentIdOnly(ic)
+	}))
+	if err := submitSignature(t, l, s, ic, 0); err != nil {
+		t.Errorf("definition version 1: %v", err)
+	}
+}
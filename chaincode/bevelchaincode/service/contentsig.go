@@ -0,0 +1,97 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Subskribo-BV/dnn-fabric-chaincode/common/contract"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const contentSignatureObjectType = "contentsig"
+
+// ReadContentSignatures returns who signed which content item of a contract.
+// If contentId is empty, the signatures of every content item are returned.
+func (s *SmartContract) ReadContentSignatures(ctx contractapi.TransactionContextInterface, id string, contentId string) ([]*ContentSignatureRecord, error) {
+	attrs := []string{id}
+
+	if contentId != "" {
+		if _, err := strconv.ParseInt(contentId, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid content id %q", contentId)
+		}
+		attrs = append(attrs, contentId)
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(contentSignatureObjectType, attrs)
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	records := []*ContentSignatureRecord{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		var r ContentSignatureRecord
+		if err := json.Unmarshal(queryResponse.Value, &r); err != nil {
+			return nil, err
+		}
+		records = append(records, &r)
+	}
+
+	return records, nil
+}
+
+// indexContentSignatures records the content signatures of a signature package, keyed by contract, content item and user.
+// A signature already recorded is kept, so the record refers to the transaction which first anchored it.
+func (s *SmartContract) indexContentSignatures(ctx contractapi.TransactionContextInterface, pkg *contract.ContractSignaturePackage) error {
+	for _, cs := range pkg.ContentSignatures {
+		key, err := ctx.GetStub().CreateCompositeKey(contentSignatureObjectType, []string{fmt.Sprint(pkg.ContractId), fmt.Sprint(cs.ContentId), pkg.UserId})
+		if err != nil {
+			return err
+		}
+
+		existing, err := ctx.GetStub().GetState(key)
+		if err != nil {
+			return fmt.Errorf("failed to read from world state: %v", err)
+		}
+
+		if existing != nil {
+			continue
+		}
+
+		record := ContentSignatureRecord{
+			ContractId:   pkg.ContractId,
+			ContentId:    cs.ContentId,
+			UserId:       pkg.UserId,
+			UserFullName: pkg.UserFullName,
+			ContentHash:  cs.ContentHash,
+			TxId:         ctx.GetStub().GetTxID(),
+		}
+
+		// content signatures anchored with only their content id have no key info or date signed
+		if cs.KeyInfo != nil {
+			record.KeyId = cs.KeyInfo.KeyId
+		}
+
+		if cs.DateSigned != nil {
+			record.DateSigned = cs.DateSigned.UTC().Format(time.RFC3339)
+		}
+
+		recordJSON, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+
+		if err := ctx.GetStub().PutState(key, recordJSON); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
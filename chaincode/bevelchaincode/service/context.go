@@ -0,0 +1,105 @@
+package service
+
+import (
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// TransactionContextInterface is the context of the transactions of SmartContract,
+// resolved by the before transaction hook.
+type TransactionContextInterface interface {
+	contractapi.TransactionContextInterface
+
+	// GetFunction returns the name of the transaction function invoked, without its contract namespace.
+	GetFunction() string
+
+	// GetCaller returns the identity of the client submitting the transaction.
+	GetCaller() *Caller
+
+	// GetRequest returns the decoded request of the transaction, or nil if it does not take one.
+	GetRequest() any
+
+	// GetTxTime returns the timestamp of the transaction proposal.
+	GetTxTime() time.Time
+
+	// AddEvent records a change of state of a contract, emitted by the after transaction hook.
+	AddEvent(event ContractEvent)
+}
+
+// Caller is the resolved identity of the client submitting a transaction.
+type Caller struct {
+	MSPID  string       `json:"msp_id"`
+	ID     string       `json:"id"`                                            // unique id of the client within its MSP
+	UserId string       `json:"user_id,omitempty" metadata:"user_id,optional"` // user_id attribute of the enrollment certificate, if it has one
+	Roles  []AccessRole `json:"roles,omitempty" metadata:"roles,optional"`
+}
+
+// TransactionContext implements TransactionContextInterface.
+type TransactionContext struct {
+	contractapi.TransactionContext
+
+	function string
+	caller   *Caller
+	request  any
+	txTime   time.Time
+	events   []ContractEvent
+}
+
+func (ctx *TransactionContext) GetFunction() string {
+	return ctx.function
+}
+
+func (ctx *TransactionContext) GetCaller() *Caller {
+	return ctx.caller
+}
+
+func (ctx *TransactionContext) GetRequest() any {
+	return ctx.request
+}
+
+func (ctx *TransactionContext) GetTxTime() time.Time {
+	return ctx.txTime
+}
+
+func (ctx *TransactionContext) AddEvent(event ContractEvent) {
+	ctx.events = append(ctx.events, event)
+}
+
+// decodeRequest returns the request decoded by the before transaction hook,
+// or decodes data if the hook has not run, as when a transaction is called directly.
+func decodeRequest[T any](ctx contractapi.TransactionContextInterface, data string) (*T, error) {
+	if tc, ok := ctx.(TransactionContextInterface); ok {
+		if req, ok := tc.GetRequest().(*T); ok {
+			return req, nil
+		}
+	}
+
+	req := new(T)
+	if err := ParseRequest(data, req); err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// addEvent records a change of state of a contract for the event of the transaction.
+func addEvent(ctx contractapi.TransactionContextInterface, asset *Contract, action string) {
+	if tc, ok := ctx.(TransactionContextInterface); ok {
+		event := ContractEvent{
+			ContractId:  asset.ContractId,
+			Action:      action,
+			NewState:    asset.State,
+			PackageHash: asset.ContractHash,
+		}
+
+		if n := len(asset.Changes); n > 0 && asset.Changes[n-1].Action == action {
+			event.PackageId = asset.Changes[n-1].PackageID
+			if asset.Changes[n-1].PackageHash != "" {
+				event.PackageHash = asset.Changes[n-1].PackageHash
+			}
+		}
+
+		tc.AddEvent(event)
+	}
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"reflect"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Names of the contracts of the chaincode. A transaction is invoked as "<contract>:<function>",
+// or by its function name alone on the default contract.
+const (
+	LifecycleContractName = "lifecycle" // anchoring contracts and changing their state
+	QueryContractName     = "query"     // reading contracts, consents and audit records
+	AdminContractName     = "admin"     // administration of the ledger
+
+	// LegacyContractName is the contract of earlier versions of the chaincode, holding every transaction.
+	LegacyContractName = "SmartContract"
+)
+
+// NamedContract is a named contract of the chaincode, exposing a group of the transactions of SmartContract
+// so each group can be governed and addressed by clients on its own.
+type NamedContract struct {
+	SmartContract
+
+	functions []string
+}
+
+// NewSmartContract returns the contract with its transaction context and the transaction hooks set.
+func NewSmartContract() *SmartContract {
+	s := new(SmartContract)
+	s.Name = LegacyContractName
+	s.setHooks()
+
+	return s
+}
+
+func (s *SmartContract) setHooks() {
+	s.TransactionContextHandler = new(TransactionContext)
+	s.BeforeTransaction = s.beforeTransaction
+	s.AfterTransaction = s.afterTransaction
+	s.UnknownTransaction = s.unknownTransaction
+}
+
+// Contracts returns the contracts of the chaincode for contractapi.NewChaincode, sharing the rate limiter and maximum request size of s.
+// The lifecycle contract is the default contract. With legacy set, the legacy contract s is included as
+// the default contract instead, so clients invoking functions by name alone keep working.
+func Contracts(s *SmartContract, legacy bool) []contractapi.ContractInterface {
+	contracts := []contractapi.ContractInterface{}
+	if legacy {
+		contracts = append(contracts, s)
+	}
+
+	for _, name := range []string{LifecycleContractName, QueryContractName, AdminContractName} {
+		c := &NamedContract{functions: contractFunctions(name)}
+		c.Name = name
+		c.Limiter = s.Limiter
+		c.MaxRequestSize = s.MaxRequestSize
+		c.setHooks()
+
+		contracts = append(contracts, c)
+	}
+
+	return contracts
+}
+
+// GetIgnoredFunctions hides the transactions of SmartContract which belong to other contracts.
+func (c *NamedContract) GetIgnoredFunctions() []string {
+	ignored := []string{}
+
+	t := reflect.TypeOf(c)
+	for i := 0; i < t.NumMethod(); i++ {
+		if name := t.Method(i).Name; !containsString(c.functions, name) {
+			ignored = append(ignored, name)
+		}
+	}
+
+	return ignored
+}
+
+// GetEvaluateTransactions marks the transactions of the query contract as evaluate transactions.
+func (c *NamedContract) GetEvaluateTransactions() []string {
+	if c.Name != QueryContractName {
+		return []string{}
+	}
+
+	return c.functions
+}
+
+// GetEvaluateTransactions marks the transactions of the query contract as evaluate transactions.
+func (s *SmartContract) GetEvaluateTransactions() []string {
+	return contractFunctions(QueryContractName)
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Subskribo-BV/dnn-fabric-chaincode/common/ledgertest"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func TestContractFunctions(t *testing.T) {
+	owner := map[string]string{}
+	for _, name := range []string{LifecycleContractName, QueryContractName, AdminContractName} {
+		for _, fn := range contractFunctions(name) {
+			owner[fn] = name
+		}
+	}
+
+	for fn, tx := range transactions {
+		if owner[fn] != tx.contract {
+			t.Errorf("%s belongs to unknown contract %q", fn, tx.contract)
+		}
+
+		for _, role := range tx.roles {
+			if !containsRole(AccessRoles, role) {
+				t.Errorf("%s requires unknown role %q", fn, role)
+			}
+		}
+	}
+
+	base := reflect.TypeOf(new(contractapi.Contract))
+	st := reflect.TypeOf(new(SmartContract))
+	for i := 0; i < st.NumMethod(); i++ {
+		name := st.Method(i).Name
+		if _, ok := base.MethodByName(name); ok || name == "GetEvaluateTransactions" {
+			continue
+		}
+
+		if _, ok := owner[name]; !ok {
+			t.Errorf("transaction %s does not belong to a contract", name)
+		}
+	}
+}
+
+func TestNamedContracts(t *testing.T) {
+	l := testLedger(t)
+	cc := testChaincode(t, NewSmartContract())
+	grantRoles(t, l, cc)
+
+	ic, hash := buildContract(t, ledgertest.NewContract(1001, testSealedOn))
+	data := encode(t, NewAssetReq{ImmutableContract: *ic, ImmutableContractHash: hash})
+
+	if err := invoke(t, l, cc, nil, "lifecycle:CreateAsset", data); err != nil {
+		t.Fatal(err)
+	}
+
+	asset := new(Contract)
+	if err := invoke(t, l, cc, asset, "query:ReadAsset", "1001"); err != nil || asset.ContractId != 1001 {
+		t.Fatalf("read through query contract: %+v %v", asset, err)
+	}
+
+	// the legacy contract is the default contract
+	exists := false
+	if err := invoke(t, l, cc, &exists, "AssetExists", "1001"); err != nil || !exists {
+		t.Errorf("read through default contract: %t %v", exists, err)
+	}
+
+	if err := invoke(t, l, cc, nil, "SmartContract:AssetExists", "1001"); err != nil {
+		t.Errorf("read through legacy contract: %v", err)
+	}
+
+	assertResponseErrors(t, invoke(t, l, cc, nil, "query:CreateAsset", data), "unsupported ")
+	assertResponseErrors(t, invoke(t, l, cc, nil, "lifecycle:DeleteAsset", "1001"), "unsupported ")
+}
+
+func TestContractsWithoutLegacy(t *testing.T) {
+	l := testLedger(t)
+
+	cc, err := contractapi.NewChaincode(Contracts(NewSmartContract(), false)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cc.DefaultContract != LifecycleContractName {
+		t.Errorf("default contract = %q, want %q", cc.DefaultContract, LifecycleContractName)
+	}
+
+	if err := invoke(t, l, cc, nil, "SmartContract:AssetExists", "1001"); err == nil {
+		t.Error("legacy contract invoked")
+	}
+
+	if err := invoke(t, l, cc, nil, "AssetExists", "1001"); err == nil {
+		t.Error("query invoked on the default lifecycle contract")
+	}
+}
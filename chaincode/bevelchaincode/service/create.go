@@ -1,9 +1,6 @@
 package service
 
 import (
-	"encoding/json"
-	"errors"
-	"fmt"
 	"log"
 	"time"
 
@@ -17,29 +14,28 @@ type CreateAssetResponse struct {
 
 func (s *SmartContract) CreateAsset(ctx contractapi.TransactionContextInterface, data string) (*CreateAssetResponse, error) {
 
-	cc := new(NewAssetReq)
-	if err := ParseRequest(data, cc); err != nil {
+	cc, err := decodeRequest[NewAssetReq](ctx, data)
+	if err != nil {
 		return nil, err
 	}
 
-	icHash, err := JsonHashS256(cc.ImmutableContract)
+	pending, errs, err := s.checkCreate(ctx, cc)
 	if err != nil {
 		return nil, err
 	}
 
-	if icHash != cc.ImmutableContractHash {
-		return nil, errors.New("invalid immutable contract hash")
+	if len(errs) > 0 {
+		return nil, responseError(errs)
 	}
 
-	// todo: Check the schema version of the immutable contract, as well as schema version of definition, to see if any routing to different validators is needed.
-
-	if err := cc.ImmutableContract.Contract.Validate(); err != nil {
+	now, err := txTime(ctx)
+	if err != nil {
 		return nil, err
 	}
 
-	t := time.Now().Format(time.RFC3339)
+	t := now.Format(time.RFC3339)
 
-	contract := Contract{
+	asset := Contract{
 		ContractHash: cc.ImmutableContractHash,
 		CreatedAt:    t,
 		UpdatedAt:    t,
@@ -48,31 +44,54 @@ func (s *SmartContract) CreateAsset(ctx contractapi.TransactionContextInterface,
 		Changes:      []Change{},
 	}
 
-	contract.ContractId = cc.ImmutableContract.Contract.ContractID
-	contractIdStr := fmt.Sprint(contract.ContractId)
+	asset.ContractId = cc.ImmutableContract.Contract.ContractID
 
-	exists, err := s.AssetExists(ctx, contractIdStr)
-	if err != nil {
+	if pending != nil {
+		asset.RulesVersion = pending.RulesVersion
+	} else if _, asset.RulesVersion, err = s.rules(ctx); err != nil {
 		return nil, err
 	}
 
-	if exists {
-		return nil, fmt.Errorf("the contract %d already exists", contract.ContractId)
+	if err := s.scheduleOrActivate(ctx, &asset, cc.ImmutableContract.Contract.ContractOptions.EffectiveDate, now); err != nil {
+		return nil, err
 	}
 
-	contractJSON, err := json.Marshal(contract)
-	if err != nil {
+	if err := s.indexExpiry(ctx, &asset, cc.ImmutableContract.Contract.ContractOptions.ExpiryDate); err != nil {
 		return nil, err
 	}
 
-	if err := ctx.GetStub().PutState(contractIdStr, contractJSON); err != nil {
+	for _, sp := range cc.ImmutableContract.ContractSignatures.Signatures {
+		if err := s.indexContentSignatures(ctx, &sp.ContractSignaturePackage); err != nil {
+			return nil, err
+		}
+	}
+
+	if cc.Store != nil {
+		if err := s.storeImmutableContract(ctx, &asset, &cc.ImmutableContract, cc.Store); err != nil {
+			return nil, err
+		}
+	}
+
+	if pending != nil {
+		asset.CreatedAt = pending.CreatedAt
+		asset.Changes = append(pending.Changes, Change{
+			PackageHash: cc.ImmutableContractHash,
+			PackageDate: t,
+			Action:      "activate",
+			NewState:    asset.State,
+		})
+	}
+
+	if err := s.putAsset(ctx, &asset); err != nil {
 		return nil, err
 	}
 
-	log.Println("contract instantiated:", contract.ContractId, ctx.GetStub().GetTxID())
+	addEvent(ctx, &asset, "create")
+
+	log.Println("contract instantiated:", asset.ContractId, ctx.GetStub().GetTxID())
 
 	return &CreateAssetResponse{
-		contract.ContractId,
+		asset.ContractId,
 		ctx.GetStub().GetTxID(),
 	}, nil
 }
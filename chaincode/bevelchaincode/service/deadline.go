@@ -0,0 +1,211 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Subskribo-BV/dnn-fabric-chaincode/common/contract"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const deadlineObjectType = "deadline"
+
+type ExtendSignatureDeadlineResponse struct {
+	DeadlineDate string `json:"deadlineDate"`
+	TxId         string `json:"txId"`
+}
+
+// ExtendSignatureDeadline extends the signing deadline of a contract in consent.
+// Only the creator of the contract can extend, and only if the contract allows signature extension.
+// The total days from the contract's sealed on date must not exceed the contract's max days to sign.
+func (s *SmartContract) ExtendSignatureDeadline(ctx contractapi.TransactionContextInterface, data string) (*ExtendSignatureDeadlineResponse, error) {
+
+	req, err := decodeRequest[ExtendSignatureDeadlineReq](ctx, data)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Days < 1 {
+		return nil, errors.New("invalid days to extend")
+	}
+
+	contractIdStr := fmt.Sprint(req.ContractId)
+
+	asset, err := s.ReadAsset(ctx, contractIdStr)
+	if err != nil {
+		return nil, err
+	}
+
+	if asset.State != ContractStatePendingConsent {
+		return nil, fmt.Errorf("contract %d is not pending consent", asset.ContractId)
+	}
+
+	consent, err := s.readConsent(ctx, contractIdStr)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline, err := s.ReadSignatureDeadline(ctx, contractIdStr)
+	if err != nil {
+		return nil, err
+	}
+
+	if !deadline.AllowSignatureExtension {
+		return nil, errors.New("contract does not allow signature extension")
+	}
+
+	callerId, err := callerUserId(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	isCreator := false
+	for _, p := range consent.ContractBlock.Participants {
+		if p.UserId == callerId && p.IsRole(contract.Creator) {
+			isCreator = true
+			break
+		}
+	}
+
+	if !isCreator {
+		return nil, fmt.Errorf("user id '%v' is not the creator of the contract", callerId)
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	deadlineDate, err := parseDate(deadline.DeadlineDate)
+	if err != nil {
+		return nil, err
+	}
+
+	if now.After(deadlineDate) {
+		return nil, errors.New("consent period has expired")
+	}
+
+	if deadline.DaysToSign+req.Days > deadline.MaxDaysToSign {
+		return nil, fmt.Errorf("extension exceeds max days to sign of %d", deadline.MaxDaysToSign)
+	}
+
+	sealedOnDate, err := parseDate(deadline.SealedOnDate)
+	if err != nil {
+		return nil, err
+	}
+
+	extension := DeadlineExtension{
+		PreviousDate: deadline.DeadlineDate,
+		ExtendedBy:   callerId,
+		ExtendedAt:   now.Format(time.RFC3339),
+		TxId:         ctx.GetStub().GetTxID(),
+	}
+
+	deadline.DaysToSign += req.Days
+	deadline.DeadlineDate = sealedOnDate.AddDate(0, 0, int(deadline.DaysToSign)).Format(time.RFC3339)
+
+	extension.NewDate = deadline.DeadlineDate
+	deadline.Extensions = append(deadline.Extensions, extension)
+
+	if err := s.putSignatureDeadline(ctx, deadline); err != nil {
+		return nil, err
+	}
+
+	log.Println("signature deadline extended:", deadline.ContractId, extension.PreviousDate, "->", extension.NewDate, ctx.GetStub().GetTxID())
+
+	return &ExtendSignatureDeadlineResponse{
+		deadline.DeadlineDate,
+		ctx.GetStub().GetTxID(),
+	}, nil
+}
+
+// ReadSignatureDeadline returns the signing deadline and its extensions for a contract which entered consent.
+func (s *SmartContract) ReadSignatureDeadline(ctx contractapi.TransactionContextInterface, id string) (*SignatureDeadline, error) {
+	deadline, err := s.getSignatureDeadline(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if deadline == nil {
+		return nil, fmt.Errorf("the signature deadline for contract %s does not exist", id)
+	}
+
+	return deadline, nil
+}
+
+// effectiveSignatureDeadline returns the anchored signing deadline of the contract if it entered consent on ledger,
+// otherwise the deadline derived from the days to sign of the contract block. Nil if the block has no days to sign.
+func (s *SmartContract) effectiveSignatureDeadline(ctx contractapi.TransactionContextInterface, block *contract.ContractBlock) (*time.Time, error) {
+	deadline, err := s.getSignatureDeadline(ctx, fmt.Sprint(block.ContractID))
+	if err != nil {
+		return nil, err
+	}
+
+	if deadline == nil {
+		if block.ContractOptions.DaysToSign < 1 {
+			return nil, nil
+		}
+		deadline = newSignatureDeadline(block)
+	}
+
+	deadlineDate, err := parseDate(deadline.DeadlineDate)
+	if err != nil {
+		return nil, err
+	}
+
+	return &deadlineDate, nil
+}
+
+func (s *SmartContract) getSignatureDeadline(ctx contractapi.TransactionContextInterface, id string) (*SignatureDeadline, error) {
+	key, err := ctx.GetStub().CreateCompositeKey(deadlineObjectType, []string{id})
+	if err != nil {
+		return nil, err
+	}
+
+	deadlineJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+
+	if deadlineJSON == nil {
+		return nil, nil
+	}
+
+	var deadline SignatureDeadline
+	if err := json.Unmarshal(deadlineJSON, &deadline); err != nil {
+		return nil, err
+	}
+
+	return &deadline, nil
+}
+
+func (s *SmartContract) putSignatureDeadline(ctx contractapi.TransactionContextInterface, deadline *SignatureDeadline) error {
+	key, err := ctx.GetStub().CreateCompositeKey(deadlineObjectType, []string{fmt.Sprint(deadline.ContractId)})
+	if err != nil {
+		return err
+	}
+
+	deadlineJSON, err := json.Marshal(deadline)
+	if err != nil {
+		return err
+	}
+
+	return ctx.GetStub().PutState(key, deadlineJSON)
+}
+
+func newSignatureDeadline(block *contract.ContractBlock) *SignatureDeadline {
+	opt := block.ContractOptions
+
+	return &SignatureDeadline{
+		ContractId:              block.ContractID,
+		SealedOnDate:            block.SealedOnDate.UTC().Format(time.RFC3339),
+		DaysToSign:              opt.DaysToSign,
+		MaxDaysToSign:           opt.MaxDaysToSign,
+		AllowSignatureExtension: opt.AllowSignatureExtension,
+		DeadlineDate:            block.SealedOnDate.UTC().AddDate(0, 0, int(opt.DaysToSign)).Format(time.RFC3339),
+		Extensions:              []DeadlineExtension{},
+	}
+}
@@ -1,19 +1,27 @@
 package service
 
 import (
-	"fmt"
-
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 )
 
-// DeleteAsset deletes an given asset from the world state.
+// DeleteAsset deletes an given asset from the world state, with its immutable contract if stored on ledger
+// and its entries in the effective and expiry date indexes.
 func (s *SmartContract) DeleteAsset(ctx contractapi.TransactionContextInterface, id string) error {
-	exists, err := s.AssetExists(ctx, id)
+	asset, err := s.getAsset(ctx, id)
 	if err != nil {
 		return err
 	}
-	if !exists {
-		return fmt.Errorf("the asset %s does not exist", id)
+
+	if err := s.deleteImmutableContract(ctx, asset); err != nil {
+		return err
+	}
+
+	if err := s.removeEffectiveIndex(ctx, asset); err != nil {
+		return err
+	}
+
+	if err := s.removeExpiryIndex(ctx, asset); err != nil {
+		return err
 	}
 
 	return ctx.GetStub().DelState(id)
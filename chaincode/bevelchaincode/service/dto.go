@@ -4,26 +4,77 @@ import (
 	"crypto/md5"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/Subskribo-BV/dnn-fabric-chaincode/common/contract"
 )
 
 const (
-	ContractStateActive   = "active"
-	ContractStateVoided   = "voided"
-	ContractStateExpired  = "expired"
-	ContractStateReleased = "released"
+	ContractStatePendingConsent = "pending_consent"
+	ContractStateConsentExpired = "consent_expired"
+	ContractStateScheduled      = "scheduled"
+	ContractStateActive         = "active"
+	ContractStateVoided         = "voided"
+	ContractStateExpired        = "expired"
+	ContractStateReleased       = "released"
+	ContractStateSuperseded     = "superseded"
 )
 
 type NewAssetReq struct {
 	ImmutableContract     contract.ImmutableContract `json:"immutable_contract"`
 	ImmutableContractHash string                     `json:"immutable_contract_hash"`
 	NotaryOU              string                     `json:"notary_ou"`
+
+	Store *StoreOptions `json:"store,omitempty"` // stores the immutable contract on ledger if set
+}
+
+type AmendContractReq struct {
+	Original              contract.ImmutableContract `json:"original"` // immutable contract anchored for the contract to amend
+	OriginalHash          string                     `json:"original_hash"`
+	StoredOriginal        bool                       `json:"stored_original,omitempty"` // the original is left out and read from the ledger
+	ImmutableContract     contract.ImmutableContract `json:"immutable_contract"`        // the amendment
+	ImmutableContractHash string                     `json:"immutable_contract_hash"`
+}
+
+type ConsentAssetReq struct {
+	ContractBlock     contract.ContractBlock `json:"contract_block"`
+	ContractBlockHash string                 `json:"contract_block_hash"`
+
+	// required for the constructed package method, the constructed content viewed and signed by each signee
+	ConstructedContentItems []contract.ConstructedContentItem `json:"constructed_content_items,omitempty"`
+
+	// stores the immutable contract on ledger when the contract is activated by its last signature
+	Store *StoreOptions `json:"store,omitempty"`
+}
+
+type SubmitSignatureReq struct {
+	ContractId int64                            `json:"contract_id"`
+	Signature  contract.SignedContractSignature `json:"signature"`
+
+	// required with the last signature for the embedded package method, the document with all signatures embedded
+	FinalizedContent *contract.ConstructedContentItem `json:"finalized_content,omitempty"`
 }
 
+type ExtendSignatureDeadlineReq struct {
+	ContractId int64 `json:"contract_id"`
+	Days       int64 `json:"days"`
+}
+
+type ProposeVoidReq struct {
+	ImmutableContract     contract.ImmutableContract `json:"immutable_contract"`
+	ImmutableContractHash string                     `json:"immutable_contract_hash"`
+
+	ContractId    int64  `json:"contract_id"`
+	Reason        string `json:"reason"`
+	DaysToApprove int64  `json:"days_to_approve"`
+}
+
+// VoidAssetReq is the request of VoidAsset, ExpireAsset and ReleaseAsset. For a contract whose immutable contract
+// is stored on ledger, a request setting StoredContract leaves the immutable contract out, referencing it by its id and hash.
 type VoidAssetReq struct {
 	ImmutableContract     contract.ImmutableContract `json:"immutable_contract"`
 	ImmutableContractHash string                     `json:"immutable_contract_hash"`
+	StoredContract        bool                       `json:"stored_contract,omitempty"`
 
 	ContractId  int64  `json:"contract_id"`
 	PackageId   int64  `json:"packageId"`
@@ -34,6 +85,7 @@ type VoidAssetReq struct {
 type ExpireAssetReq struct {
 	ImmutableContract     contract.ImmutableContract `json:"immutable_contract"`
 	ImmutableContractHash string                     `json:"immutable_contract_hash"`
+	StoredContract        bool                       `json:"stored_contract,omitempty"`
 	NotaryOU              string                     `json:"notary_ou"`
 
 	ContractId  int64  `json:"contract_id"`
@@ -45,6 +97,7 @@ type ExpireAssetReq struct {
 type ReleaseAssetReq struct {
 	ImmutableContract     contract.ImmutableContract `json:"immutable_contract"`
 	ImmutableContractHash string                     `json:"immutable_contract_hash"`
+	StoredContract        bool                       `json:"stored_contract,omitempty"`
 	NotaryOU              string                     `json:"notary_ou"`
 
 	ContractId  int64  `json:"contract_id"`
@@ -54,13 +107,119 @@ type ReleaseAssetReq struct {
 }
 
 type Contract struct {
-	ContractId   int64    `json:"contract_id"`
-	Version      int64    `json:"version"`
-	ContractHash string   `json:"contractHash"`
-	CreatedAt    string   `json:"created_at"`
-	UpdatedAt    string   `json:"updated_at"`
-	State        string   `json:"state"`
-	Changes      []Change `json:"changes"`
+	ContractId    int64    `json:"contract_id"`
+	Version       int64    `json:"version"`
+	ContractHash  string   `json:"contractHash"`
+	CreatedAt     string   `json:"created_at"`
+	UpdatedAt     string   `json:"updated_at"`
+	State         string   `json:"state"`
+	EffectiveDate string   `json:"effective_date,omitempty" metadata:"effective_date,optional"` // empty if effective immediately upon consent
+	ExpiryDate    string   `json:"expiry_date,omitempty" metadata:"expiry_date,optional"`       // empty if no expiry date
+	PredecessorId int64    `json:"predecessor_id,omitempty" metadata:"predecessor_id,optional"` // contract amended and superseded by this contract
+	SuccessorId   int64    `json:"successor_id,omitempty" metadata:"successor_id,optional"`     // amendment which superseded this contract
+	RulesVersion  int64    `json:"rules_version,omitempty" metadata:"rules_version,optional"`   // configuration version whose rules the contract was anchored with, 0 for the default rules
+	Changes       []Change `json:"changes"`
+
+	Storage *ContractStorage `json:"storage,omitempty" metadata:"storage,optional"` // nil if the immutable contract is not stored on ledger
+}
+
+// Consent is the sealed contract block and the signatures collected for it
+// while the contract is in the consent phase.
+// It holds the contract block as is, so it is not returned by transactions; see ConsentStatus.
+type Consent struct {
+	ContractId           int64                              `json:"contract_id"`
+	ContractBlock        contract.ContractBlock             `json:"contract_block"`
+	ContractBlockHash    string                             `json:"contract_block_hash"`
+	Signatures           []contract.SignedContractSignature `json:"signatures"`
+	ApproverSealedOnDate *time.Time                         `json:"approver_sealed_on_date"`
+
+	ConstructedContentItems []contract.ConstructedContentItem `json:"constructed_content_items,omitempty"`
+	FinalizedContent        *contract.ConstructedContentItem  `json:"finalized_content,omitempty"`
+	Store                   *StoreOptions                     `json:"store,omitempty"`
+}
+
+// ConsentStatus summarizes the signatures collected for a contract in consent.
+type ConsentStatus struct {
+	ContractId        int64    `json:"contract_id"`
+	ContractBlockHash string   `json:"contract_block_hash"`
+	Signed            []string `json:"signed"`  // user ids of signatories who have signed
+	Pending           []string `json:"pending"` // user ids of signatories yet to sign
+	ApproversSealed   bool     `json:"approvers_sealed"`
+	DeadlineDate      string   `json:"deadline_date"`
+}
+
+// SignatureDeadline is the date by which all signatures must be collected for a contract in consent.
+// DaysToSign starts at the contract option and grows with each extension, up to MaxDaysToSign.
+type SignatureDeadline struct {
+	ContractId              int64               `json:"contract_id"`
+	SealedOnDate            string              `json:"sealed_on_date"`
+	DaysToSign              int64               `json:"days_to_sign"`
+	MaxDaysToSign           int64               `json:"max_days_to_sign"`
+	AllowSignatureExtension bool                `json:"allow_signature_extension"`
+	DeadlineDate            string              `json:"deadline_date"`
+	Extensions              []DeadlineExtension `json:"extensions"`
+}
+
+type DeadlineExtension struct {
+	PreviousDate string `json:"previous_date"`
+	NewDate      string `json:"new_date"`
+	ExtendedBy   string `json:"extended_by"`
+	ExtendedAt   string `json:"extended_at"`
+	TxId         string `json:"tx_id"`
+}
+
+const (
+	VoidProposalStateOpen       = "open"
+	VoidProposalStateApproved   = "approved"
+	VoidProposalStateRejected   = "rejected"
+	VoidProposalStateWithdrawn  = "withdrawn"
+	VoidProposalStateTimedOut   = "timed_out"
+	VoidProposalStateSuperseded = "superseded" // closed when its contract was superseded by an amendment
+)
+
+// VoidProposal is a proposal by a participant to void a contract by consensus of the participants.
+// A contract has at most one open proposal at a time.
+type VoidProposal struct {
+	ContractId        int64    `json:"contract_id"`
+	ProposalId        string   `json:"proposal_id"` // transaction id of the proposal
+	ProposedBy        string   `json:"proposed_by"`
+	Reason            string   `json:"reason"`
+	RequiredApprovers []string `json:"required_approvers"`
+	State             string   `json:"state"`
+	CreatedAt         string   `json:"created_at"`
+	ExpiresAt         string   `json:"expires_at"`
+	ClosedAt          string   `json:"closed_at"` // empty while open
+}
+
+// VoidResponseRecord is the response of a required participant to a void proposal.
+type VoidResponseRecord struct {
+	ContractId int64  `json:"contract_id"`
+	ProposalId string `json:"proposal_id"`
+	UserId     string `json:"user_id"`
+	Approved   bool   `json:"approved"`
+	Date       string `json:"date"`
+}
+
+// ContentSignatureRecord records who signed which content item of a contract.
+type ContentSignatureRecord struct {
+	ContractId   int64  `json:"contract_id"`
+	ContentId    int64  `json:"content_id"`
+	UserId       string `json:"user_id"`
+	UserFullName string `json:"user_full_name"`
+	ContentHash  string `json:"content_hash"`
+	KeyId        string `json:"key_id"`
+	DateSigned   string `json:"date_signed"`
+	TxId         string `json:"tx_id"` // transaction which anchored the signature
+}
+
+// ContractEvent describes a change of state of a single contract in a chaincode event payload.
+// The package is the one recorded with the change of state, or the anchored immutable contract if none was.
+type ContractEvent struct {
+	ContractId  int64  `json:"contract_id"`
+	Action      string `json:"action"`
+	NewState    string `json:"new_state"`
+	PackageId   int64  `json:"package_id,omitempty" metadata:"package_id,optional"` // 0 if the change was not requested with a package, as by the sweeps
+	PackageHash string `json:"package_hash"`
 }
 
 type Change struct {
@@ -0,0 +1,233 @@
+package service
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Encodings of the payload of a request envelope.
+const (
+	EncodingJSON       = "json"        // the payload is the JSON request itself
+	EncodingBase64     = "base64"      // standard base64 of the JSON request, with padding
+	EncodingBase64Raw  = "base64raw"   // standard base64 of the JSON request, without padding
+	EncodingGzipBase64 = "gzip+base64" // standard base64, with or without padding, of the gzip compressed JSON request
+)
+
+const (
+	// RequestContentType is the content type of the requests of the chaincode, and the default of an envelope.
+	RequestContentType = "application/json"
+
+	// RequestSchemaVersion is the schema version of the requests of the chaincode, and the default of an envelope.
+	RequestSchemaVersion = 1
+
+	// DefaultMaxRequestSize is the maximum size in bytes of a decoded request, unless the contract sets another.
+	DefaultMaxRequestSize = 8 << 20
+)
+
+// RequestEnvelope wraps the request of a transaction with its encoding, content type and schema version.
+// An argument starting with '{' is decoded as an envelope:
+//
+//	{"encoding": "gzip+base64", "content_type": "application/json", "schema_version": 1, "payload": "H4sI..."}
+//
+// Any other argument is the base64 encoded JSON request of earlier versions, with or without padding.
+type RequestEnvelope struct {
+	Encoding      string          `json:"encoding"`
+	ContentType   string          `json:"content_type,omitempty"`   // RequestContentType if empty
+	SchemaVersion int             `json:"schema_version,omitempty"` // RequestSchemaVersion if 0
+	Payload       json.RawMessage `json:"payload"`                  // a JSON value for the json encoding, otherwise a JSON string
+}
+
+// payloadDecoders decode the payload of an envelope by encoding, returning the JSON request.
+var payloadDecoders = map[string]func(payload json.RawMessage, maxSize int) ([]byte, error){
+	EncodingJSON: func(payload json.RawMessage, _ int) ([]byte, error) {
+		return payload, nil
+	},
+	EncodingBase64: func(payload json.RawMessage, _ int) ([]byte, error) {
+		s, err := payloadString(payload)
+		if err != nil {
+			return nil, err
+		}
+		data, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid base64 payload: %v", err)
+		}
+		return data, nil
+	},
+	EncodingBase64Raw: func(payload json.RawMessage, _ int) ([]byte, error) {
+		s, err := payloadString(payload)
+		if err != nil {
+			return nil, err
+		}
+		data, err := base64.RawStdEncoding.DecodeString(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid base64raw payload: %v", err)
+		}
+		return data, nil
+	},
+	EncodingGzipBase64: func(payload json.RawMessage, maxSize int) ([]byte, error) {
+		s, err := payloadString(payload)
+		if err != nil {
+			return nil, err
+		}
+
+		compressed, err := decodeBase64(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip+base64 payload: %v", err)
+		}
+
+		data, err := gunzip(compressed, maxSize)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip payload: %v", err)
+		}
+
+		if len(data) > maxSize {
+			return nil, fmt.Errorf("decompressed request exceeds the maximum request size of %d bytes", maxSize)
+		}
+
+		return data, nil
+	},
+}
+
+// DecodeRequest decodes a request argument, an envelope or base64 encoded JSON, into obj.
+// The argument and the decoded JSON request may not exceed maxSize bytes.
+func DecodeRequest(data string, obj any, maxSize int) error {
+	if data == "" {
+		return errors.New("data is empty")
+	}
+
+	if len(data) > maxSize {
+		return fmt.Errorf("request of %d bytes exceeds the maximum request size of %d bytes", len(data), maxSize)
+	}
+
+	var requestJSON []byte
+	var err error
+
+	if strings.HasPrefix(strings.TrimSpace(data), "{") {
+		requestJSON, err = decodeEnvelope(data, maxSize)
+	} else {
+		requestJSON, err = decodeBase64(data)
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(requestJSON) > maxSize {
+		return fmt.Errorf("decoded request of %d bytes exceeds the maximum request size of %d bytes", len(requestJSON), maxSize)
+	}
+
+	return json.Unmarshal(requestJSON, obj)
+}
+
+// EncodeRequest encodes a request in an envelope with the encoding, for DecodeRequest.
+func EncodeRequest(req any, encoding string) (string, error) {
+	requestJSON, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+
+	var payload any
+	switch encoding {
+	case EncodingJSON:
+		payload = json.RawMessage(requestJSON)
+	case EncodingBase64:
+		payload = base64.StdEncoding.EncodeToString(requestJSON)
+	case EncodingBase64Raw:
+		payload = base64.RawStdEncoding.EncodeToString(requestJSON)
+	case EncodingGzipBase64:
+		compressed, err := gzipBytes(requestJSON)
+		if err != nil {
+			return "", err
+		}
+		payload = base64.StdEncoding.EncodeToString(compressed)
+	default:
+		return "", fmt.Errorf("unsupported request encoding %q", encoding)
+	}
+
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	envelopeJSON, err := json.Marshal(RequestEnvelope{
+		Encoding:      encoding,
+		ContentType:   RequestContentType,
+		SchemaVersion: RequestSchemaVersion,
+		Payload:       payloadJSON,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return string(envelopeJSON), nil
+}
+
+// decodeEnvelope checks the content type and schema version of an envelope, and decodes its payload by its encoding.
+func decodeEnvelope(data string, maxSize int) ([]byte, error) {
+	env := new(RequestEnvelope)
+	if err := json.Unmarshal([]byte(data), env); err != nil {
+		return nil, fmt.Errorf("invalid request envelope: %v", err)
+	}
+
+	if env.ContentType != "" && env.ContentType != RequestContentType {
+		return nil, fmt.Errorf("unsupported request content type %q, want %q", env.ContentType, RequestContentType)
+	}
+
+	if env.SchemaVersion != 0 && env.SchemaVersion != RequestSchemaVersion {
+		return nil, fmt.Errorf("unsupported request schema version %d, want %d", env.SchemaVersion, RequestSchemaVersion)
+	}
+
+	decode, ok := payloadDecoders[env.Encoding]
+	if !ok {
+		return nil, fmt.Errorf("unsupported request encoding %q", env.Encoding)
+	}
+
+	if len(env.Payload) == 0 || string(env.Payload) == "null" {
+		return nil, errors.New("request envelope has no payload")
+	}
+
+	return decode(env.Payload, maxSize)
+}
+
+func payloadString(payload json.RawMessage) (string, error) {
+	var s string
+	if err := json.Unmarshal(payload, &s); err != nil {
+		return "", errors.New("payload of a base64 encoding is not a string")
+	}
+
+	return s, nil
+}
+
+// decodeBase64 decodes standard base64, with or without padding.
+func decodeBase64(data string) ([]byte, error) {
+	return base64.RawStdEncoding.DecodeString(strings.TrimRight(data, "="))
+}
+
+func gzipBytes(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// gunzip decompresses gzip data, reading one byte past limit so larger data is detected without decompressing it all.
+func gunzip(data []byte, limit int) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	return io.ReadAll(io.LimitReader(zr, int64(limit)+1))
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/Subskribo-BV/dnn-fabric-chaincode/common/ledgertest"
+)
+
+func TestDecodeRequest(t *testing.T) {
+	req := ExtendSignatureDeadlineReq{ContractId: 1001, Days: 3}
+	reqJSON := `{"contract_id":1001,"days":3}`
+
+	envelope := func(encoding string) string {
+		data, err := EncodeRequest(req, encoding)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+
+	for name, data := range map[string]string{
+		"base64 without padding": base64.RawStdEncoding.EncodeToString([]byte(reqJSON)),
+		"base64 with padding":    base64.StdEncoding.EncodeToString([]byte(reqJSON)),
+		"json envelope":          `{"encoding": "json", "payload": ` + reqJSON + `}`,
+		EncodingJSON:             envelope(EncodingJSON),
+		EncodingBase64:           envelope(EncodingBase64),
+		EncodingBase64Raw:        envelope(EncodingBase64Raw),
+		EncodingGzipBase64:       envelope(EncodingGzipBase64),
+	} {
+		got := ExtendSignatureDeadlineReq{}
+		if err := DecodeRequest(data, &got, DefaultMaxRequestSize); err != nil || got != req {
+			t.Errorf("%s: %+v %v", name, got, err)
+		}
+	}
+
+	var zeros bytes.Buffer
+	zw := gzip.NewWriter(&zeros)
+	zw.Write(make([]byte, 1<<20))
+	zw.Close()
+	bomb := `{"encoding": "gzip+base64", "payload": "` + base64.StdEncoding.EncodeToString(zeros.Bytes()) + `"}`
+
+	for name, tc := range map[string]struct {
+		data    string
+		maxSize int
+		err     string
+	}{
+		"empty":               {"", DefaultMaxRequestSize, "data is empty"},
+		"invalid base64":      {"not base64!", DefaultMaxRequestSize, "illegal base64 data"},
+		"unknown encoding":    {`{"encoding": "zstd", "payload": "x"}`, DefaultMaxRequestSize, `unsupported request encoding "zstd"`},
+		"content type":        {`{"encoding": "json", "content_type": "application/xml", "payload": {}}`, DefaultMaxRequestSize, "unsupported request content type"},
+		"schema version":      {`{"encoding": "json", "schema_version": 2, "payload": {}}`, DefaultMaxRequestSize, "unsupported request schema version 2"},
+		"no payload":          {`{"encoding": "json"}`, DefaultMaxRequestSize, "no payload"},
+		"payload not string":  {`{"encoding": "base64", "payload": {}}`, DefaultMaxRequestSize, "not a string"},
+		"argument too large":  {envelope(EncodingBase64), 40, "exceeds the maximum request size of 40 bytes"},
+		"decompression limit": {bomb, 64 << 10, "decompressed request exceeds the maximum request size"},
+	} {
+		got := ExtendSignatureDeadlineReq{}
+		if err := DecodeRequest(tc.data, &got, tc.maxSize); err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%s: error %v, want %q", name, err, tc.err)
+		}
+	}
+
+	if _, err := EncodeRequest(req, "zstd"); err == nil {
+		t.Error("request encoded with an unknown encoding")
+	}
+}
+
+func TestRequestEnvelope(t *testing.T) {
+	l := testLedger(t)
+	s := NewSmartContract()
+	s.MaxRequestSize = 64 << 10
+	cc := testChaincode(t, s)
+	grantRoles(t, l, cc)
+
+	ic, hash := buildContract(t, ledgertest.NewContract(1001, testSealedOn))
+	req := NewAssetReq{ImmutableContract: *ic, ImmutableContractHash: hash}
+
+	data, err := EncodeRequest(req, EncodingGzipBase64)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := invoke(t, l, cc, nil, "lifecycle:CreateAsset", data); err != nil {
+		t.Fatal(err)
+	}
+
+	if readContract(t, l, s, 1001).State != ContractStateActive {
+		t.Error("contract of an enveloped request is not active")
+	}
+
+	// the maximum request size of the contract applies to the named contracts
+	ic.Contract.ContractID = 1002
+	ic.Contract.ContractName = strings.Repeat("x", 64<<10)
+	data, err = EncodeRequest(NewAssetReq{ImmutableContract: *ic}, EncodingGzipBase64)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = invoke(t, l, cc, nil, "lifecycle:CreateAsset", data)
+	if err == nil || !strings.Contains(err.Error(), "invalid request: decompressed request exceeds the maximum request size of 65536 bytes") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
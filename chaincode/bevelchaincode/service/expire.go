@@ -1,11 +1,14 @@
 package service
 
 import (
-	"encoding/json"
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"log"
+	"strings"
 	"time"
 
+	"github.com/Subskribo-BV/dnn-fabric-chaincode/common/contract"
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 )
 
@@ -15,55 +18,28 @@ type ExpireResponse struct {
 
 func (s *SmartContract) ExpireAsset(ctx contractapi.TransactionContextInterface, data string) (*ExpireResponse, error) {
 
-	cc := new(VoidAssetReq)
-	if err := ParseRequest(data, cc); err != nil {
+	cc, err := decodeRequest[VoidAssetReq](ctx, data)
+	if err != nil {
 		return nil, err
 	}
 
-	icHash, err := JsonHashS256(cc.ImmutableContract)
+	asset, errs, err := s.checkChange(ctx, cc, contract.ActionExpire)
 	if err != nil {
 		return nil, err
 	}
 
-	if icHash != cc.ImmutableContractHash {
-		return nil, errors.New("invalid immutable contract hash")
-	}
-
-	if cc.ImmutableContract.Contract.SchemaVersion != cc.ImmutableContract.Contract.Definition.SchemaVersion {
-		return nil, errors.New("contract schema version does not match with definition")
-	}
-
-	if err := cc.ImmutableContract.Contract.Validate(); err != nil {
-		return nil, err
+	if len(errs) > 0 {
+		return nil, responseError(errs)
 	}
 
 	// todo: validate permission of the calling party
 
-	asset, err := s.ReadAsset(ctx, fmt.Sprint(cc.ContractId))
+	now, err := txTime(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	switch asset.State {
-	case ContractStateVoided:
-		return nil, errors.New("contract voided, cannot expire")
-
-	case ContractStateExpired:
-		return nil, errors.New("contract already expired")
-
-	case ContractStateReleased:
-		return nil, errors.New("contract released, cannot expire")
-
-	}
-
-	/*
-		todo: Validate that the change of state is allowed as per contract rules.
-		For example, if content is to be released, that if using a notary, then the notary is the caller (through a manged process), or if verifiers, then a common key is used.
-		If contract is to be voided, that voiding is allowed.
-		If to be set as expired, that the expiration data has been reached, etc.
-	*/
-
-	t := time.Now().Format(time.RFC3339)
+	t := now.Format(time.RFC3339)
 	asset.State = ContractStateExpired
 	asset.UpdatedAt = t
 
@@ -75,16 +51,176 @@ func (s *SmartContract) ExpireAsset(ctx contractapi.TransactionContextInterface,
 		NewState:    asset.State,
 	})
 
-	contractJSON, err := json.Marshal(asset)
-	if err != nil {
+	if err := s.removeExpiryIndex(ctx, asset); err != nil {
 		return nil, err
 	}
 
-	if err := ctx.GetStub().PutState(fmt.Sprint(cc.ContractId), contractJSON); err != nil {
+	if err := s.putAsset(ctx, asset); err != nil {
 		return nil, err
 	}
 
+	addEvent(ctx, asset, "expire")
+
 	return &ExpireResponse{
 		ctx.GetStub().GetTxID(),
 	}, nil
 }
+
+const (
+	// the expiry date index is kept in simple keys ordered by date, so a sweep can range over the due entries
+	// after its bookmark; composite keys only support prefix queries. See indexKeyNamespace.
+	expiryIndexPrefix = indexKeyNamespace + "expiry/"
+
+	ContractsExpiredEvent = "ContractsExpired"
+)
+
+type ExpireDueResponse struct {
+	Expired  []int64 `json:"expired"`
+	Bookmark string  `json:"bookmark"` // empty when no further contracts are due
+	TxId     string  `json:"txId"`
+}
+
+// ExpireDueContracts expires up to limit active contracts whose expiry date has passed.
+// Contracts are found through the expiry date index in date order, starting after the bookmark if supplied.
+// The returned bookmark is passed to the next call to continue; it is empty once no further contracts are due.
+//
+// The after transaction hook emits one ContractsExpired event holding an entry for each contract expired,
+// as Fabric keeps a single event per transaction. The entries carry the contract hash as their package hash.
+func (s *SmartContract) ExpireDueContracts(ctx contractapi.TransactionContextInterface, limit int, bookmark string) (*ExpireDueResponse, error) {
+
+	if limit < 1 || limit > MaxSweepBatch {
+		return nil, fmt.Errorf("limit must be between 1 and %d", MaxSweepBatch)
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	startKey := expiryIndexPrefix
+	if bookmark != "" {
+		b, err := base64.RawURLEncoding.DecodeString(bookmark)
+		if err != nil || !strings.HasPrefix(string(b), expiryIndexPrefix) {
+			return nil, errors.New("invalid bookmark")
+		}
+
+		// the first key after the bookmark key
+		startKey = string(b) + "\x00"
+	}
+
+	// the entries due sort before the first key of the second after the transaction time
+	endKey := expiryIndexPrefix + now.UTC().Truncate(time.Second).Add(time.Second).Format(indexDateLayout)
+
+	resultsIterator, err := ctx.GetStub().GetStateByRange(startKey, endKey)
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	t := now.Format(time.RFC3339)
+	res := &ExpireDueResponse{
+		Expired: []int64{},
+		TxId:    ctx.GetStub().GetTxID(),
+	}
+	examined := 0
+	lastKey := ""
+
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		if examined == limit {
+			res.Bookmark = base64.RawURLEncoding.EncodeToString([]byte(lastKey))
+			break
+		}
+
+		attrs := strings.Split(strings.TrimPrefix(queryResponse.Key, expiryIndexPrefix), "/")
+		if len(attrs) != 2 {
+			return nil, fmt.Errorf("invalid expiry date index key %q", queryResponse.Key)
+		}
+
+		exists, err := s.AssetExists(ctx, attrs[1])
+		if err != nil {
+			return nil, err
+		}
+
+		examined++
+		lastKey = queryResponse.Key
+
+		// the entry of a deleted contract is only removed
+		if !exists {
+			if err := ctx.GetStub().DelState(queryResponse.Key); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		asset, err := s.ReadAsset(ctx, attrs[1])
+		if err != nil {
+			return nil, err
+		}
+
+		// contracts not yet effective keep their index entry and are passed over by the bookmark
+		if asset.State == ContractStateScheduled {
+			continue
+		}
+
+		// contracts voided or released before expiry only have their index entry removed
+		if asset.State == ContractStateActive {
+			asset.State = ContractStateExpired
+			asset.UpdatedAt = t
+
+			asset.Changes = append(asset.Changes, Change{
+				PackageHash: asset.ContractHash,
+				PackageDate: t,
+				Action:      "expire",
+				NewState:    asset.State,
+			})
+
+			if err := s.putAsset(ctx, asset); err != nil {
+				return nil, err
+			}
+
+			res.Expired = append(res.Expired, asset.ContractId)
+			addEvent(ctx, asset, "expire")
+		}
+
+		if err := ctx.GetStub().DelState(queryResponse.Key); err != nil {
+			return nil, err
+		}
+	}
+
+	log.Println("due contracts expired:", len(res.Expired), ctx.GetStub().GetTxID())
+
+	return res, nil
+}
+
+// indexExpiry records the expiry date of an instantiated contract in the expiry date index used by ExpireDueContracts.
+func (s *SmartContract) indexExpiry(ctx contractapi.TransactionContextInterface, asset *Contract, expiryDate *time.Time) error {
+	if expiryDate == nil {
+		return nil
+	}
+
+	asset.ExpiryDate = expiryDate.UTC().Format(time.RFC3339)
+
+	return ctx.GetStub().PutState(expiryIndexKey(asset.ContractId, *expiryDate), []byte{0x00})
+}
+
+func (s *SmartContract) removeExpiryIndex(ctx contractapi.TransactionContextInterface, asset *Contract) error {
+	if asset.ExpiryDate == "" {
+		return nil
+	}
+
+	expiryDate, err := parseDate(asset.ExpiryDate)
+	if err != nil {
+		return err
+	}
+
+	return ctx.GetStub().DelState(expiryIndexKey(asset.ContractId, expiryDate))
+}
+
+func expiryIndexKey(contractId int64, expiryDate time.Time) string {
+	return expiryIndexPrefix + expiryDate.UTC().Format(indexDateLayout) + "/" + fmt.Sprint(contractId)
+}
@@ -0,0 +1,319 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Subskribo-BV/dnn-fabric-chaincode/common/contract"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	auditObjectType = "audit"
+
+	ContractsChangedEvent = "ContractsChanged"
+)
+
+// AuditRecord records a transaction which wrote to the ledger, with the caller and the contracts it changed.
+type AuditRecord struct {
+	TxId      string          `json:"tx_id"`
+	Function  string          `json:"function"`
+	Caller    Caller          `json:"caller"`
+	Timestamp string          `json:"timestamp"`
+	Contracts []ContractEvent `json:"contracts"`
+}
+
+// beforeTransaction resolves the caller, its roles and the timestamp of the transaction, checks the caller holds
+// a role the transaction requires, applies the rate limit of the caller, and decodes the request of a submit transaction.
+func (s *SmartContract) beforeTransaction(ctx TransactionContextInterface) error {
+	tc, ok := ctx.(*TransactionContext)
+	if !ok {
+		return fmt.Errorf("unexpected transaction context %T", ctx)
+	}
+
+	function, params := ctx.GetStub().GetFunctionAndParameters()
+	if i := strings.LastIndex(function, ":"); i >= 0 {
+		function = function[i+1:]
+	}
+	tc.function = function
+
+	config, err := s.getConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	caller, err := resolveCaller(ctx, config)
+	if err != nil {
+		return err
+	}
+	tc.caller = caller
+
+	tx, ok := transactions[function]
+	if ok {
+		if err := authorize(caller, function, tx.roles); err != nil {
+			return err
+		}
+	}
+
+	if tc.txTime, err = txTime(ctx); err != nil {
+		return err
+	}
+
+	if s.Limiter != nil && !s.Limiter.Allow(caller.MSPID+"/"+caller.ID) {
+		return fmt.Errorf("rate limit exceeded for caller %s of %s, retry later", caller.ID, caller.MSPID)
+	}
+
+	if !ok || tx.request == nil || len(params) == 0 {
+		return nil
+	}
+
+	req := tx.request()
+	if err := s.parseRequest(params[0], req); err != nil {
+		return fmt.Errorf("invalid request: %v", err)
+	}
+	tc.request = req
+
+	return nil
+}
+
+// afterTransaction emits the event of the contracts changed by a submit transaction, and records it in the audit trail.
+// Fabric delivers a single event per transaction, so the changes of all contracts are emitted in one event.
+func (s *SmartContract) afterTransaction(ctx TransactionContextInterface, _ interface{}) error {
+	tc, ok := ctx.(*TransactionContext)
+	if !ok {
+		return fmt.Errorf("unexpected transaction context %T", ctx)
+	}
+
+	tx, ok := transactions[tc.function]
+	if !ok || !tx.submit() {
+		return nil
+	}
+
+	events := tc.events
+	if events == nil {
+		events = []ContractEvent{}
+	}
+
+	if len(events) > 0 {
+		name := tx.event
+		if name == "" {
+			name = ContractsChangedEvent
+		}
+
+		eventJSON, err := json.Marshal(events)
+		if err != nil {
+			return err
+		}
+
+		if err := ctx.GetStub().SetEvent(name, eventJSON); err != nil {
+			return err
+		}
+	}
+
+	record := AuditRecord{
+		TxId:      ctx.GetStub().GetTxID(),
+		Function:  tc.function,
+		Caller:    *tc.caller,
+		Timestamp: tc.txTime.Format(time.RFC3339),
+		Contracts: events,
+	}
+
+	key, err := ctx.GetStub().CreateCompositeKey(auditObjectType, []string{tc.txTime.Format(indexDateLayout), record.TxId})
+	if err != nil {
+		return err
+	}
+
+	recordJSON, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	if err := ctx.GetStub().PutState(key, recordJSON); err != nil {
+		return err
+	}
+
+	log.Println("transaction:", record.Function, "caller:", record.Caller.MSPID, record.Caller.ID, "contracts:", len(events), record.TxId)
+
+	return nil
+}
+
+// unknownTransaction rejects a call of a function the contract does not have with an ErrorResponse.
+func (s *SmartContract) unknownTransaction(ctx TransactionContextInterface) error {
+	return responseError(&contract.ValidationError{
+		Code:    contract.CodeUnsupported,
+		Path:    "",
+		Message: fmt.Sprintf("unknown transaction %q", ctx.GetFunction()),
+	})
+}
+
+// GetAuditRecords returns the audit records of the transactions between from and to, given in RFC3339 format,
+// in time order. An empty date leaves the period open.
+func (s *SmartContract) GetAuditRecords(ctx contractapi.TransactionContextInterface, from string, to string) ([]*AuditRecord, error) {
+	start, end := "", ""
+
+	if from != "" {
+		t, err := parseDate(from)
+		if err != nil {
+			return nil, err
+		}
+		start = t.UTC().Format(indexDateLayout)
+	}
+
+	if to != "" {
+		t, err := parseDate(to)
+		if err != nil {
+			return nil, err
+		}
+		end = t.UTC().Format(indexDateLayout)
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(auditObjectType, []string{})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	records := []*AuditRecord{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		_, attrs, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(attrs) != 2 {
+			return nil, fmt.Errorf("invalid audit record key %q", queryResponse.Key)
+		}
+
+		if attrs[0] < start {
+			continue
+		}
+
+		if end != "" && attrs[0] > end {
+			break
+		}
+
+		record := new(AuditRecord)
+		if err := json.Unmarshal(queryResponse.Value, record); err != nil {
+			return nil, err
+		}
+
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+type PruneAuditResponse struct {
+	Pruned int    `json:"pruned"`
+	More   bool   `json:"more"` // further audit records are past the retention
+	TxId   string `json:"txId"`
+}
+
+// PruneAuditRecords deletes up to limit audit records older than the audit retention of the governance configuration,
+// oldest first. Nothing is deleted if the retention is 0. Deleted records remain in the blocks of the channel.
+//
+// Pruning is a sweep rather than part of every transaction, so concurrent transactions do not conflict on the
+// records they would delete.
+func (s *SmartContract) PruneAuditRecords(ctx contractapi.TransactionContextInterface, limit int) (*PruneAuditResponse, error) {
+
+	if limit < 1 || limit > MaxSweepBatch {
+		return nil, fmt.Errorf("limit must be between 1 and %d", MaxSweepBatch)
+	}
+
+	res := &PruneAuditResponse{TxId: ctx.GetStub().GetTxID()}
+
+	config, err := s.getConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if config == nil || config.AuditRetentionDays == 0 {
+		return res, nil
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cutoff := now.UTC().AddDate(0, 0, -int(config.AuditRetentionDays)).Format(indexDateLayout)
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(auditObjectType, []string{})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		_, attrs, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(attrs) != 2 {
+			return nil, fmt.Errorf("invalid audit record key %q", queryResponse.Key)
+		}
+
+		if attrs[0] >= cutoff {
+			break
+		}
+
+		if res.Pruned == limit {
+			res.More = true
+			break
+		}
+
+		if err := ctx.GetStub().DelState(queryResponse.Key); err != nil {
+			return nil, err
+		}
+		res.Pruned++
+	}
+
+	log.Println("audit records pruned:", res.Pruned, ctx.GetStub().GetTxID())
+
+	return res, nil
+}
+
+// resolveCaller returns the identity of the client submitting the transaction and the roles it holds under config.
+// A client whose identity cannot be read is not authenticated.
+func resolveCaller(ctx contractapi.TransactionContextInterface, config *GovernanceConfig) (*Caller, error) {
+	ci := ctx.GetClientIdentity()
+	if ci == nil {
+		return nil, fmt.Errorf("caller identity could not be read")
+	}
+
+	mspId, err := ci.GetMSPID()
+	if err != nil || mspId == "" {
+		return nil, fmt.Errorf("caller identity has no MSP ID")
+	}
+
+	id, err := ci.GetID()
+	if err != nil {
+		return nil, fmt.Errorf("failed getting caller id: %v", err)
+	}
+
+	userId, _, err := ci.GetAttributeValue("user_id")
+	if err != nil {
+		return nil, fmt.Errorf("failed getting caller attributes: %v", err)
+	}
+
+	roles, err := callerRoles(ci, mspId, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Caller{MSPID: mspId, ID: id, UserId: userId, Roles: roles}, nil
+}
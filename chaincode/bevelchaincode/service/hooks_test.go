@@ -0,0 +1,275 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Subskribo-BV/dnn-fabric-chaincode/common/ledgertest"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+var testServer = ledgertest.NewClientIdentity("Org1MSP", "server", map[string]string{"user_id": "c102"})
+
+// testChaincode returns the chaincode of the contracts of s, with the legacy contract as default contract.
+func testChaincode(t *testing.T, s *SmartContract) *contractapi.ContractChaincode {
+	t.Helper()
+
+	cc, err := contractapi.NewChaincode(Contracts(s, true)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return cc
+}
+
+// invoke invokes a transaction of the chaincode as testServer, committing it and decoding its response into res if it succeeds.
+func invoke(t *testing.T, l *ledgertest.Ledger, cc *contractapi.ContractChaincode, res any, function string, args ...string) error {
+	t.Helper()
+
+	return invokeAs(t, l, cc, testServer, res, function, args...)
+}
+
+func invokeAs(t *testing.T, l *ledgertest.Ledger, cc *contractapi.ContractChaincode, id *ledgertest.ClientIdentity, res any, function string, args ...string) error {
+	t.Helper()
+
+	resp, _ := l.Invoke(cc, ledgertest.WithIdentity(id), ledgertest.WithArgs(function, args...))
+	if resp.Status != 200 {
+		return errors.New(resp.Message)
+	}
+
+	if res != nil && len(resp.Payload) > 0 {
+		if err := json.Unmarshal(resp.Payload, res); err != nil {
+			t.Fatalf("invalid response %s: %v", resp.Payload, err)
+		}
+	}
+
+	return nil
+}
+
+// grantRoles initializes the ledger as testServer, making Org1MSP the admin MSP, and binds the roles to the clients
+// of Org1MSP, with server-submitter by default.
+func grantRoles(t *testing.T, l *ledgertest.Ledger, cc *contractapi.ContractChaincode, roles ...AccessRole) {
+	t.Helper()
+
+	if len(roles) == 0 {
+		roles = []AccessRole{RoleServerSubmitter}
+	}
+
+	bindings := []RoleBinding{}
+	for _, role := range roles {
+		bindings = append(bindings, RoleBinding{Role: role, MSPID: "Org1MSP"})
+	}
+
+	if err := invoke(t, l, cc, nil, "admin:InitLedger", encode(t, InitLedgerReq{AdminMSPs: []string{"Org1MSP"}})); err != nil {
+		t.Fatal(err)
+	}
+
+	config := new(GovernanceConfig)
+	if err := invoke(t, l, cc, config, "query:ReadConfig"); err != nil {
+		t.Fatal(err)
+	}
+
+	req := UpdateConfigReq{Version: config.Version, AdminMSPs: config.AdminMSPs, RoleBindings: bindings, Rules: config.Rules, AuditRetentionDays: config.AuditRetentionDays}
+	if err := invoke(t, l, cc, nil, "admin:UpdateConfig", encode(t, req)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTransactionHooks(t *testing.T) {
+	l := testLedger(t)
+	cc := testChaincode(t, NewSmartContract())
+	grantRoles(t, l, cc)
+
+	ic, hash := buildContract(t, ledgertest.NewContract(1001, testSealedOn))
+
+	res := new(CreateAssetResponse)
+	if err := invoke(t, l, cc, res, "CreateAsset", encode(t, NewAssetReq{ImmutableContract: *ic, ImmutableContractHash: hash})); err != nil {
+		t.Fatal(err)
+	}
+
+	events := l.Events()[1:]
+	if len(events) != 1 || events[0].Name != ContractsChangedEvent || events[0].TxId != res.TxId {
+		t.Fatalf("unexpected events: %+v", events)
+	}
+
+	changed := []ContractEvent{}
+	if err := json.Unmarshal(events[0].Payload, &changed); err != nil {
+		t.Fatal(err)
+	}
+	if len(changed) != 1 || changed[0] != (ContractEvent{ContractId: 1001, Action: "create", NewState: ContractStateActive, PackageHash: hash}) {
+		t.Errorf("unexpected event payload: %+v", changed)
+	}
+
+	// queries are not audited
+	if err := invoke(t, l, cc, nil, "ReadAsset", "1001"); err != nil {
+		t.Fatal(err)
+	}
+
+	records := []*AuditRecord{}
+	if err := invoke(t, l, cc, &records, "GetAuditRecords", "", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	// InitLedger and UpdateConfig of grantRoles, then CreateAsset
+	if len(records) != 3 {
+		t.Fatalf("got %d audit records, want 3", len(records))
+	}
+
+	r := records[2]
+	if r.TxId != res.TxId || r.Function != "CreateAsset" || r.Caller.MSPID != "Org1MSP" || r.Caller.UserId != "c102" || r.Caller.ID == "" {
+		t.Errorf("unexpected audit record: %+v", r)
+	}
+
+	if r.Timestamp != l.Now().Format(time.RFC3339) || len(r.Contracts) != 1 {
+		t.Errorf("unexpected audit record: %+v", r)
+	}
+
+	records = []*AuditRecord{}
+	if err := invoke(t, l, cc, &records, "GetAuditRecords", l.Now().Add(time.Second).Format(time.RFC3339), ""); err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 0 {
+		t.Errorf("got %d audit records after the transaction", len(records))
+	}
+}
+
+func TestPruneAuditRecords(t *testing.T) {
+	l := testLedger(t)
+	cc := testChaincode(t, NewSmartContract())
+	grantRoles(t, l, cc)
+
+	prune := func() *PruneAuditResponse {
+		t.Helper()
+
+		res := new(PruneAuditResponse)
+		if err := invoke(t, l, cc, res, "admin:PruneAuditRecords", "1"); err != nil {
+			t.Fatal(err)
+		}
+
+		return res
+	}
+
+	config := new(GovernanceConfig)
+	if err := invoke(t, l, cc, config, "query:ReadConfig"); err != nil {
+		t.Fatal(err)
+	}
+
+	if config.AuditRetentionDays != DefaultAuditRetentionDays {
+		t.Errorf("audit retention days = %d, want %d", config.AuditRetentionDays, DefaultAuditRetentionDays)
+	}
+
+	update := func(days int64) error {
+		req := UpdateConfigReq{Version: config.Version, AdminMSPs: config.AdminMSPs, RoleBindings: config.RoleBindings, Rules: config.Rules, AuditRetentionDays: days}
+		return invoke(t, l, cc, config, "admin:UpdateConfig", encode(t, req))
+	}
+
+	assertResponseErrors(t, update(-1), "out_of_range /audit_retention_days")
+
+	// InitLedger and UpdateConfig are recorded before the retention
+	l.Advance(100 * 24 * time.Hour)
+
+	if err := update(0); err != nil {
+		t.Fatal(err)
+	}
+
+	if res := prune(); res.Pruned != 0 || res.More {
+		t.Errorf("records pruned while kept forever: %+v", res)
+	}
+
+	if err := invoke(t, l, cc, config, "query:ReadConfig"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := update(30); err != nil {
+		t.Fatal(err)
+	}
+
+	if res := prune(); res.Pruned != 1 || !res.More {
+		t.Errorf("first prune: %+v", res)
+	}
+
+	if res := prune(); res.Pruned != 1 || res.More {
+		t.Errorf("second prune: %+v", res)
+	}
+
+	records := []*AuditRecord{}
+	if err := invoke(t, l, cc, &records, "GetAuditRecords", "", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	// the updates of the configuration and the prunes themselves
+	if len(records) != 5 {
+		t.Errorf("got %d audit records after pruning, want 5", len(records))
+	}
+
+	for _, r := range records {
+		if r.Function == "InitLedger" {
+			t.Errorf("audit record past the retention: %+v", r)
+		}
+	}
+
+	err := invokeAs(t, l, cc, ledgertest.NewClientIdentity("Org2MSP", "client", nil), nil, "admin:PruneAuditRecords", "1")
+	assertErrorContains(t, err, "PruneAuditRecords")
+}
+
+func TestInvalidRequest(t *testing.T) {
+	l := testLedger(t)
+	cc := testChaincode(t, NewSmartContract())
+	grantRoles(t, l, cc)
+	keys := l.Keys()
+
+	err := invoke(t, l, cc, nil, "CreateAsset", "not base64!")
+	if err == nil || !strings.Contains(err.Error(), "invalid request") {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if len(l.Keys()) != len(keys) {
+		t.Errorf("failed transaction wrote %v", l.Keys())
+	}
+}
+
+func TestUnknownTransaction(t *testing.T) {
+	l := testLedger(t)
+	cc := testChaincode(t, NewSmartContract())
+
+	err := invoke(t, l, cc, nil, "Frobnicate")
+	assertResponseErrors(t, err, "unsupported ")
+
+	if !strings.Contains(err.Error(), "Frobnicate") {
+		t.Errorf("error does not name the function: %v", err)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	l := testLedger(t)
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := NewSmartContract()
+	s.Limiter = NewRateLimiter(1, 2)
+	s.Limiter.now = func() time.Time { return now }
+	cc := testChaincode(t, s)
+
+	other := ledgertest.NewClientIdentity("Org2MSP", "other", nil)
+
+	for i := 0; i < 2; i++ {
+		if err := invoke(t, l, cc, nil, "AssetExists", "1001"); err != nil {
+			t.Fatalf("call %d within burst: %v", i, err)
+		}
+	}
+
+	if err := invoke(t, l, cc, nil, "AssetExists", "1001"); err == nil || !strings.Contains(err.Error(), "rate limit") {
+		t.Errorf("call beyond burst: %v", err)
+	}
+
+	if err := invokeAs(t, l, cc, other, nil, "AssetExists", "1001"); err != nil {
+		t.Errorf("other caller limited: %v", err)
+	}
+
+	now = now.Add(time.Second)
+	if err := invoke(t, l, cc, nil, "AssetExists", "1001"); err != nil {
+		t.Errorf("call after refill: %v", err)
+	}
+}
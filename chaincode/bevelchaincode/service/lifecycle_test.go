@@ -0,0 +1,589 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Subskribo-BV/dnn-fabric-chaincode/common/contract"
+	"github.com/Subskribo-BV/dnn-fabric-chaincode/common/ledgertest"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+var testSealedOn = time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+
+// testLedger is an in-memory ledger with its clock set after the test contracts are signed.
+func testLedger(t *testing.T) *ledgertest.Ledger {
+	t.Helper()
+
+	// InitLedger reads the MSP of the peer from the environment
+	t.Setenv("CORE_PEER_LOCALMSPID", "Org1MSP")
+
+	l := ledgertest.NewLedger()
+	l.SetTime(testSealedOn.Add(72 * time.Hour))
+
+	return l
+}
+
+// submit runs a transaction on the ledger, committing it if it succeeds.
+func submit[T any](t *testing.T, l *ledgertest.Ledger, tx func(ctx *ledgertest.TransactionContext) (T, error), opts ...ledgertest.TxOption) (T, error) {
+	t.Helper()
+
+	ctx := l.NewTx(opts...)
+	res, err := tx(ctx)
+	if err != nil {
+		return res, err
+	}
+
+	if err := ctx.Commit(); err != nil {
+		t.Fatalf("commit failed: %v", err)
+	}
+
+	return res, nil
+}
+
+func buildContract(t *testing.T, b *ledgertest.ContractBuilder) (*contract.ImmutableContract, string) {
+	t.Helper()
+
+	ic, hash, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return ic, hash
+}
+
+func encode(t *testing.T, req any) string {
+	t.Helper()
+
+	data, err := ledgertest.Encode(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return data
+}
+
+func createContract(t *testing.T, l *ledgertest.Ledger, s *SmartContract, b *ledgertest.ContractBuilder) (*contract.ImmutableContract, string) {
+	t.Helper()
+
+	ic, hash := buildContract(t, b)
+	data := encode(t, NewAssetReq{ImmutableContract: *ic, ImmutableContractHash: hash})
+
+	if _, err := submit(t, l, func(ctx *ledgertest.TransactionContext) (*CreateAssetResponse, error) {
+		return s.CreateAsset(ctx, data)
+	}); err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+
+	return ic, hash
+}
+
+// changeRequest returns the request to void, expire or release the contract.
+func changeRequest(t *testing.T, ic *contract.ImmutableContract, hash string) string {
+	t.Helper()
+
+	return encode(t, VoidAssetReq{
+		ImmutableContract:     *ic,
+		ImmutableContractHash: hash,
+		ContractId:            ic.Contract.ContractID,
+		PackageId:             1,
+		PackageHash:           hash,
+	})
+}
+
+func readContract(t *testing.T, l *ledgertest.Ledger, s *SmartContract, id int64) *Contract {
+	t.Helper()
+
+	asset, err := s.ReadAsset(l.NewTx(), fmt.Sprint(id))
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+
+	return asset
+}
+
+// assertResponseErrors checks err is an ErrorResponse holding each of the codes, given as "code path".
+func assertResponseErrors(t *testing.T, err error, want ...string) {
+	t.Helper()
+
+	if err == nil {
+		t.Fatalf("expected errors %v", want)
+	}
+
+	res := ErrorResponse{}
+	if jsonErr := json.Unmarshal([]byte(err.Error()), &res); jsonErr != nil {
+		t.Fatalf("expected an error response, got: %v", err)
+	}
+
+	got := map[string]bool{}
+	for _, ve := range res.Errors {
+		got[ve.Code+" "+ve.Path] = true
+	}
+
+	for _, w := range want {
+		if !got[w] {
+			t.Errorf("missing error %q in %v", w, got)
+		}
+	}
+}
+
+func TestContractLifecycle(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(s *SmartContract, ctx *ledgertest.TransactionContext, data string) (string, error)
+		action string
+		state  string
+	}{
+		{"void", func(s *SmartContract, ctx *ledgertest.TransactionContext, data string) (string, error) {
+			res, err := s.VoidAsset(ctx, data)
+			if err != nil {
+				return "", err
+			}
+			return res.TxId, nil
+		}, "void", ContractStateVoided},
+		{"expire", func(s *SmartContract, ctx *ledgertest.TransactionContext, data string) (string, error) {
+			res, err := s.ExpireAsset(ctx, data)
+			if err != nil {
+				return "", err
+			}
+			return res.TxId, nil
+		}, "expire", ContractStateExpired},
+		{"release", func(s *SmartContract, ctx *ledgertest.TransactionContext, data string) (string, error) {
+			res, err := s.ReleaseAsset(ctx, data)
+			if err != nil {
+				return "", err
+			}
+			return res.TxId, nil
+		}, "release", ContractStateReleased},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := testLedger(t)
+			s := new(SmartContract)
+
+			ic, hash := createContract(t, l, s, ledgertest.NewContract(1001, testSealedOn).
+				WithStandardRelease(strings.Repeat("r", contract.InstructionMinLength), 3))
+
+			asset := readContract(t, l, s, 1001)
+			if asset.State != ContractStateActive || asset.ContractHash != hash {
+				t.Fatalf("unexpected contract after create: %+v", asset)
+			}
+
+			data := changeRequest(t, ic, hash)
+			txId, err := submit(t, l, func(ctx *ledgertest.TransactionContext) (string, error) {
+				return tt.change(s, ctx, data)
+			})
+			if err != nil {
+				t.Fatalf("%s failed: %v", tt.action, err)
+			}
+
+			asset = readContract(t, l, s, 1001)
+			if asset.State != tt.state {
+				t.Errorf("state = %q, want %q", asset.State, tt.state)
+			}
+
+			last := asset.Changes[len(asset.Changes)-1]
+			if last.Action != tt.action || last.NewState != tt.state || last.PackageHash != hash {
+				t.Errorf("unexpected change: %+v", last)
+			}
+
+			history, err := l.NewTx().GetStub().GetHistoryForKey("1001")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer history.Close()
+
+			mod, err := history.Next()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if mod.TxId != txId {
+				t.Errorf("latest history tx = %q, want %q", mod.TxId, txId)
+			}
+
+			// no further transition is allowed from a final state
+			for _, next := range tests {
+				_, err := submit(t, l, func(ctx *ledgertest.TransactionContext) (string, error) {
+					return next.change(s, ctx, data)
+				})
+				assertResponseErrors(t, err, "state ")
+			}
+		})
+	}
+}
+
+func TestChangeContractOfDefinitionVersion1(t *testing.T) {
+	l := testLedger(t)
+	s := new(SmartContract)
+
+	// a contract anchored under definition version 1, which did not require days to sign or identity claims
+	b := ledgertest.NewContract(1001, testSealedOn).Edit(func(ic *contract.ImmutableContract) {
+		ic.Contract.DefinitionVersion = 1
+		ic.Contract.ContractOptions.DaysToSign = 0
+		for i := range ic.Contract.Participants {
+			ic.Contract.Participants[i].IdentityClaims = nil
+		}
+	})
+
+	ic, hash := createContract(t, l, s, b)
+
+	v2 := *ic
+	v2.Contract.DefinitionVersion = 2
+	if err := v2.ValidateFor(contract.ActionVoid, nil); err == nil {
+		t.Fatal("contract passes the rules of definition version 2")
+	}
+
+	if _, err := submit(t, l, func(ctx *ledgertest.TransactionContext) (*VoidResponse, error) {
+		return s.VoidAsset(ctx, changeRequest(t, ic, hash))
+	}); err != nil {
+		t.Fatalf("void failed: %v", err)
+	}
+
+	if state := readContract(t, l, s, 1001).State; state != ContractStateVoided {
+		t.Errorf("state = %q, want %q", state, ContractStateVoided)
+	}
+}
+
+func TestConsentOfDefinitionVersion1(t *testing.T) {
+	l := testLedger(t)
+	s := new(SmartContract)
+
+	// identity claims were not required by definition version 1
+	ic := beginConsent(t, l, s, ledgertest.NewContract(1001, testSealedOn).Edit(func(ic *contract.ImmutableContract) {
+		ic.Contract.DefinitionVersion = 1
+		for i := range ic.Contract.Participants {
+			ic.Contract.Participants[i].IdentityClaims = nil
+		}
+	}))
+
+	for i := range ic.ContractSignatures.Signatures {
+		if err := submitSignature(t, l, s, ic, i); err != nil {
+			t.Fatalf("signature %d: %v", i, err)
+		}
+	}
+
+	if state := readContract(t, l, s, 1001).State; state != ContractStateActive {
+		t.Errorf("state = %q, want %q", state, ContractStateActive)
+	}
+}
+
+func TestCreateAssetRejected(t *testing.T) {
+	l := testLedger(t)
+	s := new(SmartContract)
+
+	ic, hash := createContract(t, l, s, ledgertest.NewContract(1001, testSealedOn))
+
+	_, err := submit(t, l, func(ctx *ledgertest.TransactionContext) (*CreateAssetResponse, error) {
+		return s.CreateAsset(ctx, encode(t, NewAssetReq{ImmutableContract: *ic, ImmutableContractHash: hash}))
+	})
+	assertResponseErrors(t, err, "state ")
+
+	_, err = submit(t, l, func(ctx *ledgertest.TransactionContext) (*CreateAssetResponse, error) {
+		other, _ := buildContract(t, ledgertest.NewContract(1002, testSealedOn))
+		return s.CreateAsset(ctx, encode(t, NewAssetReq{ImmutableContract: *other, ImmutableContractHash: hash}))
+	})
+	assertResponseErrors(t, err, "mismatch ")
+
+	if exists, _ := s.AssetExists(l.NewTx(), "1002"); exists {
+		t.Error("rejected contract was written")
+	}
+}
+
+func TestChangeUnknownContract(t *testing.T) {
+	l := testLedger(t)
+	s := new(SmartContract)
+
+	ic, hash := buildContract(t, ledgertest.NewContract(1001, testSealedOn))
+
+	_, err := submit(t, l, func(ctx *ledgertest.TransactionContext) (*VoidResponse, error) {
+		return s.VoidAsset(ctx, changeRequest(t, ic, hash))
+	})
+	assertResponseErrors(t, err, "not_found ")
+}
+
+func TestVoidAssetVoidableByParticipants(t *testing.T) {
+	l := testLedger(t)
+	s := new(SmartContract)
+
+	ic, hash := createContract(t, l, s, ledgertest.NewContract(1001, testSealedOn).Edit(func(ic *contract.ImmutableContract) {
+		ic.Contract.ContractOptions.VoidableByParticipants = true
+		ic.Contract.Definition.Options.AllowVoidableByParticipants = true
+	}))
+
+	_, err := submit(t, l, func(ctx *ledgertest.TransactionContext) (*VoidResponse, error) {
+		return s.VoidAsset(ctx, changeRequest(t, ic, hash))
+	})
+	assertResponseErrors(t, err, "state /contract/contract_options/voidable_by_participants")
+
+	if state := readContract(t, l, s, 1001).State; state != ContractStateActive {
+		t.Errorf("state = %q, want %q", state, ContractStateActive)
+	}
+}
+
+func TestChangeOtherContractVersion(t *testing.T) {
+	l := testLedger(t)
+	s := new(SmartContract)
+
+	createContract(t, l, s, ledgertest.NewContract(1001, testSealedOn))
+
+	// a valid immutable contract of the same id which is not the one anchored
+	other, otherHash := buildContract(t, ledgertest.NewContract(1001, testSealedOn).Edit(func(ic *contract.ImmutableContract) {
+		ic.Contract.ContractName = "Other"
+	}))
+
+	_, err := submit(t, l, func(ctx *ledgertest.TransactionContext) (*VoidResponse, error) {
+		return s.VoidAsset(ctx, changeRequest(t, other, otherHash))
+	})
+	assertResponseErrors(t, err, "mismatch /immutable_contract_hash")
+
+	// the immutable contract of another contract referenced by the id of the anchored one
+	another, anotherHash := buildContract(t, ledgertest.NewContract(1002, testSealedOn))
+	_, err = submit(t, l, func(ctx *ledgertest.TransactionContext) (*VoidResponse, error) {
+		return s.VoidAsset(ctx, encode(t, VoidAssetReq{
+			ImmutableContract:     *another,
+			ImmutableContractHash: anotherHash,
+			ContractId:            1001,
+			PackageId:             1,
+			PackageHash:           anotherHash,
+		}))
+	})
+	assertResponseErrors(t, err, "mismatch /contract_id", "mismatch /immutable_contract_hash")
+
+	if state := readContract(t, l, s, 1001).State; state != ContractStateActive {
+		t.Errorf("state = %q, want %q", state, ContractStateActive)
+	}
+}
+
+func TestScheduledContract(t *testing.T) {
+	l := testLedger(t)
+	s := new(SmartContract)
+
+	effective := l.Now().Add(24 * time.Hour)
+	ic, hash := createContract(t, l, s, ledgertest.NewContract(1001, testSealedOn).WithEffectiveDate(effective))
+
+	if state := readContract(t, l, s, 1001).State; state != ContractStateScheduled {
+		t.Fatalf("state = %q, want %q", state, ContractStateScheduled)
+	}
+
+	_, err := submit(t, l, func(ctx *ledgertest.TransactionContext) (*ReleaseResponse, error) {
+		return s.ReleaseAsset(ctx, changeRequest(t, ic, hash))
+	})
+	assertResponseErrors(t, err, "state ")
+
+	l.SetTime(effective)
+
+	res, err := submit(t, l, func(ctx *ledgertest.TransactionContext) (*ActivateDueResponse, error) {
+		return s.ActivateDueContracts(ctx, 10, "")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(res.Activated) != 1 || res.Activated[0] != 1001 {
+		t.Errorf("activated = %v, want [1001]", res.Activated)
+	}
+
+	if state := readContract(t, l, s, 1001).State; state != ContractStateActive {
+		t.Errorf("state = %q, want %q", state, ContractStateActive)
+	}
+}
+
+func TestActivateDueContractsDeleted(t *testing.T) {
+	l := testLedger(t)
+	s := new(SmartContract)
+
+	effective := l.Now().Add(24 * time.Hour)
+	for _, id := range []int64{1001, 1002} {
+		createContract(t, l, s, ledgertest.NewContract(id, testSealedOn).WithEffectiveDate(effective))
+	}
+
+	if _, err := submit(t, l, func(ctx *ledgertest.TransactionContext) (any, error) {
+		return nil, s.DeleteAsset(ctx, "1001")
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	deleted, _ := shim.CreateCompositeKey(effectiveIndexObjectType, []string{effective.UTC().Format(indexDateLayout), "1001"})
+	if l.State(deleted) != nil {
+		t.Error("effective date index entry of a deleted contract")
+	}
+
+	// an entry left for a contract which no longer exists is dropped
+	stale, _ := shim.CreateCompositeKey(effectiveIndexObjectType, []string{effective.UTC().Format(indexDateLayout), "1000"})
+	l.PutState(stale, []byte{0x00})
+
+	l.SetTime(effective)
+
+	res, err := submit(t, l, func(ctx *ledgertest.TransactionContext) (*ActivateDueResponse, error) {
+		return s.ActivateDueContracts(ctx, 10, "")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fmt.Sprint(res.Activated) != "[1002]" {
+		t.Errorf("activated = %v, want [1002]", res.Activated)
+	}
+
+	if l.State(stale) != nil {
+		t.Error("stale effective date index entry not removed")
+	}
+}
+
+func TestActivateDueContractsLimit(t *testing.T) {
+	l := testLedger(t)
+	s := new(SmartContract)
+
+	effective := l.Now().Add(24 * time.Hour)
+	for _, id := range []int64{1002, 1003} {
+		createContract(t, l, s, ledgertest.NewContract(id, testSealedOn).WithEffectiveDate(effective))
+	}
+
+	// stale entries count against the limit
+	stale, _ := shim.CreateCompositeKey(effectiveIndexObjectType, []string{effective.UTC().Format(indexDateLayout), "1001"})
+	l.PutState(stale, []byte{0x00})
+
+	l.SetTime(effective)
+
+	activated := []int64{}
+	bookmark := ""
+	for calls := 1; ; calls++ {
+		res, err := submit(t, l, func(ctx *ledgertest.TransactionContext) (*ActivateDueResponse, error) {
+			return s.ActivateDueContracts(ctx, 1, bookmark)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(res.Activated) > 1 {
+			t.Fatalf("activated %v past the limit", res.Activated)
+		}
+
+		activated = append(activated, res.Activated...)
+		if bookmark = res.Bookmark; bookmark == "" {
+			if calls != 3 {
+				t.Errorf("calls = %d, want 3", calls)
+			}
+			break
+		}
+	}
+
+	if fmt.Sprint(activated) != "[1002 1003]" {
+		t.Errorf("activated = %v, want [1002 1003]", activated)
+	}
+
+	_, err := submit(t, l, func(ctx *ledgertest.TransactionContext) (*ActivateDueResponse, error) {
+		return s.ActivateDueContracts(ctx, 1, "bm90IGEga2V5")
+	})
+	assertErrorContains(t, err, "invalid bookmark")
+}
+
+func TestExpireDueContracts(t *testing.T) {
+	l := testLedger(t)
+	s := new(SmartContract)
+
+	now := l.Now()
+	hashes := map[int64]string{}
+	for i, days := range []int{3, 1, 2, 30} {
+		_, hashes[int64(1001+i)] = createContract(t, l, s, ledgertest.NewContract(int64(1001+i), testSealedOn).WithExpiry(now.AddDate(0, 0, days)))
+	}
+
+	// the entries of the expiry date index are not assets
+	if assets, err := s.GetAllAssets(l.NewTx()); err != nil || len(assets) != 4 {
+		t.Fatalf("got %d assets, want 4: %v", len(assets), err)
+	}
+
+	l.Advance(5 * 24 * time.Hour)
+
+	cc := testChaincode(t, NewSmartContract())
+	grantRoles(t, l, cc)
+	expired := []int64{}
+	bookmark := ""
+	for {
+		res := new(ExpireDueResponse)
+		if err := invoke(t, l, cc, res, "ExpireDueContracts", "2", bookmark); err != nil {
+			t.Fatal(err)
+		}
+
+		expired = append(expired, res.Expired...)
+		if bookmark = res.Bookmark; bookmark == "" {
+			break
+		}
+	}
+
+	if fmt.Sprint(expired) != "[1002 1003 1001]" {
+		t.Errorf("expired = %v, want contracts in expiry date order", expired)
+	}
+
+	if state := readContract(t, l, s, 1004).State; state != ContractStateActive {
+		t.Errorf("contract not yet due has state %q", state)
+	}
+
+	// the first event is the ConfigUpdated event of grantRoles
+	events := l.Events()[1:]
+	if len(events) != 2 || events[0].Name != ContractsExpiredEvent {
+		t.Fatalf("unexpected events: %+v", events)
+	}
+
+	// one event per transaction, with an entry for each contract expired by it
+	entries := []ContractEvent{}
+	if err := json.Unmarshal(events[0].Payload, &entries); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 2 {
+		t.Fatalf("unexpected event payload: %+v", entries)
+	}
+
+	for _, e := range entries {
+		if e.Action != "expire" || e.NewState != ContractStateExpired || e.PackageHash != hashes[e.ContractId] || e.PackageId != 0 {
+			t.Errorf("unexpected event entry: %+v", e)
+		}
+	}
+}
+
+func TestExpireDueContractsDeleted(t *testing.T) {
+	l := testLedger(t)
+	s := new(SmartContract)
+
+	expiry := l.Now().Add(24 * time.Hour)
+	for _, id := range []int64{1001, 1002} {
+		createContract(t, l, s, ledgertest.NewContract(id, testSealedOn).WithExpiry(expiry))
+	}
+
+	if _, err := submit(t, l, func(ctx *ledgertest.TransactionContext) (any, error) {
+		return nil, s.DeleteAsset(ctx, "1001")
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if l.State(expiryIndexKey(1001, expiry)) != nil {
+		t.Error("expiry date index entry of a deleted contract")
+	}
+
+	// an entry left for a contract which no longer exists is dropped
+	stale := expiryIndexKey(1000, expiry)
+	l.PutState(stale, []byte{0x00})
+
+	l.SetTime(expiry)
+
+	res, err := submit(t, l, func(ctx *ledgertest.TransactionContext) (*ExpireDueResponse, error) {
+		return s.ExpireDueContracts(ctx, 10, "")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fmt.Sprint(res.Expired) != "[1002]" || res.Bookmark != "" {
+		t.Errorf("expired = %v, bookmark %q, want [1002]", res.Expired, res.Bookmark)
+	}
+
+	if l.State(stale) != nil {
+		t.Error("stale expiry date index entry not removed")
+	}
+}
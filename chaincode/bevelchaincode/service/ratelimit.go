@@ -0,0 +1,81 @@
+package service
+
+import (
+	"sync"
+	"time"
+)
+
+// RateLimiter limits the rate of transactions of each caller with a token bucket per caller.
+//
+// The limiter runs in the chaincode process of each peer and keeps its buckets in memory, refilled by the
+// peer's clock rather than the transaction timestamp. It is therefore not consensus-safe: the peers endorsing
+// a transaction may disagree on whether a caller is over the limit, and the buckets are lost on restart.
+// It only protects the individual peer from a caller flooding it with proposals; a proposal rejected by one
+// peer fails endorsement like any other error. Clients keep under the limit with client.RateLimitedSubmitter.
+type RateLimiter struct {
+	mu      sync.Mutex
+	rate    float64 // tokens added per second
+	burst   float64
+	buckets map[string]*bucket
+	now     func() time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter returns a limiter allowing each caller rate transactions per second on average,
+// and up to burst transactions at once.
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	return &RateLimiter{
+		rate:    rate,
+		burst:   float64(burst),
+		buckets: map[string]*bucket{},
+		now:     time.Now,
+	}
+}
+
+// Allow takes a token from the bucket of the caller, reporting whether one was available.
+func (l *RateLimiter) Allow(caller string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+
+	b, ok := l.buckets[caller]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[caller] = b
+	}
+
+	b.tokens += now.Sub(b.last).Seconds() * l.rate
+	if b.tokens > l.burst {
+		b.tokens = l.burst
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+
+	b.tokens--
+
+	// full buckets hold no state worth keeping
+	if len(l.buckets) > maxRateLimitBuckets {
+		l.evictFull(now)
+	}
+
+	return true
+}
+
+// upper bound on buckets kept before full buckets are evicted
+const maxRateLimitBuckets = 10000
+
+func (l *RateLimiter) evictFull(now time.Time) {
+	for caller, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, caller)
+		}
+	}
+}
@@ -9,14 +9,55 @@ import (
 )
 
 // ReadAsset returns the asset stored in the world state with given id.
+// A scheduled contract whose effective date has passed is reported as active, even if not yet swept.
 func (s *SmartContract) ReadAsset(ctx contractapi.TransactionContextInterface, id string) (*Contract, error) {
+	asset, err := s.getAsset(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if asset.State == ContractStateScheduled && asset.EffectiveDate != "" {
+		now, err := txTime(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		effectiveDate, err := parseDate(asset.EffectiveDate)
+		if err != nil {
+			return nil, err
+		}
+
+		if !effectiveDate.After(now) {
+			asset.State = ContractStateActive
+		}
+	}
+
+	return asset, nil
+}
+
+// getAsset returns the asset exactly as stored in the world state.
+func (s *SmartContract) getAsset(ctx contractapi.TransactionContextInterface, id string) (*Contract, error) {
+	asset, err := s.findAsset(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if asset == nil {
+		return nil, fmt.Errorf("the asset %s does not exist", id)
+	}
+
+	return asset, nil
+}
+
+// findAsset returns the asset exactly as stored in the world state, or nil if it does not exist.
+func (s *SmartContract) findAsset(ctx contractapi.TransactionContextInterface, id string) (*Contract, error) {
 	contractJSON, err := ctx.GetStub().GetState(id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read from world state: %v", err)
 	}
 
 	if contractJSON == nil {
-		return nil, fmt.Errorf("the asset %s does not exist", id)
+		return nil, nil
 	}
 
 	var asset Contract
@@ -41,7 +82,8 @@ func (s *SmartContract) AssetExists(ctx contractapi.TransactionContextInterface,
 func (s *SmartContract) GetAllAssets(ctx contractapi.TransactionContextInterface) ([]Contract, error) {
 	var cSlice []Contract
 
-	resultsIterator, err := ctx.GetStub().GetStateByRange("", "")
+	// the range ends before the simple keys of indexes
+	resultsIterator, err := ctx.GetStub().GetStateByRange("", indexKeyNamespace)
 	if err != nil {
 		log.Println("GetStateByRange err:", err)
 		return cSlice, err
@@ -0,0 +1,198 @@
+package service
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	effectiveIndexObjectType = "effective~id"
+
+	// fixed width UTC layout, so index keys sort in date order
+	indexDateLayout = "2006-01-02T15:04:05Z"
+
+	// simple keys of indexes start with indexKeyNamespace, sorting after the decimal contract ids of the assets
+	indexKeyNamespace = "~"
+
+	// upper bound on contracts processed by one sweep, keeping read/write sets small
+	MaxSweepBatch = 100
+)
+
+type ActivateDueResponse struct {
+	Activated []int64 `json:"activated"`
+	Bookmark  string  `json:"bookmark"` // empty when no further contracts are due
+	TxId      string  `json:"txId"`
+}
+
+// ActivateDueContracts activates scheduled contracts whose effective date has passed.
+// Contracts are found through the effective date index in date order, starting after the bookmark if supplied.
+// Up to limit index entries are processed, including the entries of contracts voided or deleted while scheduled,
+// which are only removed. The returned bookmark is passed to the next call to continue; it is empty once no
+// further contracts are due.
+func (s *SmartContract) ActivateDueContracts(ctx contractapi.TransactionContextInterface, limit int, bookmark string) (*ActivateDueResponse, error) {
+
+	if limit < 1 || limit > MaxSweepBatch {
+		return nil, fmt.Errorf("limit must be between 1 and %d", MaxSweepBatch)
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// composite keys only support prefix queries, so the entries up to the bookmark are passed over
+	afterKey := ""
+	if bookmark != "" {
+		prefix, err := ctx.GetStub().CreateCompositeKey(effectiveIndexObjectType, []string{})
+		if err != nil {
+			return nil, err
+		}
+
+		b, err := base64.RawURLEncoding.DecodeString(bookmark)
+		if err != nil || !strings.HasPrefix(string(b), prefix) {
+			return nil, errors.New("invalid bookmark")
+		}
+		afterKey = string(b)
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(effectiveIndexObjectType, []string{})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	t := now.Format(time.RFC3339)
+	res := &ActivateDueResponse{
+		Activated: []int64{},
+		TxId:      ctx.GetStub().GetTxID(),
+	}
+	processed := 0
+	lastKey := ""
+
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		if queryResponse.Key <= afterKey {
+			continue
+		}
+
+		_, attrs, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(attrs) != 2 {
+			return nil, fmt.Errorf("invalid effective date index key %q", queryResponse.Key)
+		}
+
+		effectiveDate, err := time.Parse(indexDateLayout, attrs[0])
+		if err != nil {
+			return nil, err
+		}
+
+		if effectiveDate.After(now) {
+			break
+		}
+
+		if processed == limit {
+			res.Bookmark = base64.RawURLEncoding.EncodeToString([]byte(lastKey))
+			break
+		}
+
+		processed++
+		lastKey = queryResponse.Key
+
+		asset, err := s.findAsset(ctx, attrs[1])
+		if err != nil {
+			return nil, err
+		}
+
+		// the contract may have been voided while scheduled, or deleted, in which case only the index entry is removed
+		if asset != nil && asset.State == ContractStateScheduled {
+			asset.State = ContractStateActive
+			asset.UpdatedAt = t
+
+			asset.Changes = append(asset.Changes, Change{
+				PackageDate: t,
+				Action:      "activate",
+				NewState:    asset.State,
+			})
+
+			if err := s.putAsset(ctx, asset); err != nil {
+				return nil, err
+			}
+
+			addEvent(ctx, asset, "activate")
+			res.Activated = append(res.Activated, asset.ContractId)
+		}
+
+		if err := ctx.GetStub().DelState(queryResponse.Key); err != nil {
+			return nil, err
+		}
+	}
+
+	log.Println("scheduled contracts activated:", len(res.Activated), "index entries processed:", processed, ctx.GetStub().GetTxID())
+
+	return res, nil
+}
+
+// scheduleOrActivate sets a contract which has completed consent as active,
+// or as scheduled if its effective date is in the future, indexing it for ActivateDueContracts.
+func (s *SmartContract) scheduleOrActivate(ctx contractapi.TransactionContextInterface, asset *Contract, effectiveDate *time.Time, now time.Time) error {
+	if effectiveDate == nil {
+		asset.State = ContractStateActive
+		return nil
+	}
+
+	asset.EffectiveDate = effectiveDate.UTC().Format(time.RFC3339)
+
+	if !effectiveDate.After(now) {
+		asset.State = ContractStateActive
+		return nil
+	}
+
+	if asset.ContractId < 1 {
+		return errors.New("invalid contract id")
+	}
+
+	asset.State = ContractStateScheduled
+
+	key, err := effectiveIndexKey(ctx, asset.ContractId, *effectiveDate)
+	if err != nil {
+		return err
+	}
+
+	return ctx.GetStub().PutState(key, []byte{0x00})
+}
+
+// removeEffectiveIndex removes the entry of a contract from the effective date index, if it was scheduled.
+func (s *SmartContract) removeEffectiveIndex(ctx contractapi.TransactionContextInterface, asset *Contract) error {
+	if asset.EffectiveDate == "" {
+		return nil
+	}
+
+	effectiveDate, err := parseDate(asset.EffectiveDate)
+	if err != nil {
+		return err
+	}
+
+	key, err := effectiveIndexKey(ctx, asset.ContractId, effectiveDate)
+	if err != nil {
+		return err
+	}
+
+	return ctx.GetStub().DelState(key)
+}
+
+func effectiveIndexKey(ctx contractapi.TransactionContextInterface, contractId int64, effectiveDate time.Time) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(effectiveIndexObjectType, []string{effectiveDate.UTC().Format(indexDateLayout), fmt.Sprint(contractId)})
+}
@@ -1,60 +1,109 @@
 package service
 
 import (
-	"bytes"
-	"crypto/sha256"
-	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"time"
 
+	"github.com/Subskribo-BV/dnn-fabric-chaincode/common/contract"
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 )
 
+// ErrorResponse is the error message returned by the chaincode when validation fails.
+// It is serialized as JSON, so clients can parse the code and path of each failure.
+type ErrorResponse struct {
+	Errors contract.ValidationErrors `json:"errors"`
+}
+
 // SmartContract provides functions for managing an Asset
 type SmartContract struct {
 	contractapi.Contract
-}
 
-// InitLedger adds a base set of assets to the ledger
-func (s *SmartContract) InitLedger(ctx contractapi.TransactionContextInterface) error {
-	return nil
+	Limiter        *RateLimiter // limits the transaction rate of each caller if set
+	MaxRequestSize int          // maximum size in bytes of a request, DefaultMaxRequestSize if not set
 }
 
 func JsonHashS256(data interface{}) (string, error) {
-	if data == nil {
-		return "", errors.New("data is nil")
+	return contract.JsonHashS256(data)
+}
+
+// ParseRequest decodes a request argument into obj, with the default maximum request size. See DecodeRequest.
+func ParseRequest(data string, obj interface{}) error {
+	return DecodeRequest(data, obj, DefaultMaxRequestSize)
+}
+
+// parseRequest decodes a request argument into obj, with the maximum request size of the contract.
+func (s *SmartContract) parseRequest(data string, obj interface{}) error {
+	return DecodeRequest(data, obj, s.maxRequestSize())
+}
+
+func (s *SmartContract) maxRequestSize() int {
+	if s.MaxRequestSize <= 0 {
+		return DefaultMaxRequestSize
 	}
 
-	bytesData, err := json.Marshal(data)
-	if err != nil {
-		return "", err
+	return s.MaxRequestSize
+}
+
+// txTime returns the timestamp of the transaction proposal.
+// Unlike time.Now it is identical on every endorsing peer.
+func txTime(ctx contractapi.TransactionContextInterface) (time.Time, error) {
+	if tc, ok := ctx.(TransactionContextInterface); ok && !tc.GetTxTime().IsZero() {
+		return tc.GetTxTime(), nil
 	}
 
-	var bb bytes.Buffer
-	if err := json.Compact(&bb, bytesData); err != nil {
-		return "", err
+	ts, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return time.Time{}, err
 	}
 
-	h := sha256.New()
+	return ts.AsTime().UTC(), nil
+}
 
-	h.Write(bb.Bytes())
+// parseDate parses a date of a ledger record, stored in RFC3339 format.
+func parseDate(date string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, date)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q: %v", date, err)
+	}
 
-	return base64.StdEncoding.EncodeToString(h.Sum(nil)), nil
+	return t, nil
 }
 
-func ParseRequest(data string, obj interface{}) error {
-	if data == "" {
-		return errors.New("data is empty")
+// callerUserId returns the contract user id of the calling client,
+// taken from the user_id attribute of its enrollment certificate.
+func callerUserId(ctx contractapi.TransactionContextInterface) (string, error) {
+	userId, found, err := ctx.GetClientIdentity().GetAttributeValue("user_id")
+	if err != nil {
+		return "", fmt.Errorf("failed getting caller attributes: %v", err)
 	}
 
-	dataBytes, err := base64.RawStdEncoding.DecodeString(data)
-	if err != nil {
+	if !found || userId == "" {
+		return "", errors.New("caller certificate does not have a user_id attribute")
+	}
+
+	return userId, nil
+}
+
+// responseError converts validation errors into an error whose message is an ErrorResponse.
+// Any other error is returned unchanged.
+func responseError(err error) error {
+	var errs contract.ValidationErrors
+
+	switch v := err.(type) {
+	case contract.ValidationErrors:
+		errs = v
+	case *contract.ValidationError:
+		errs = contract.ValidationErrors{v}
+	default:
 		return err
 	}
 
-	if err := json.Unmarshal(dataBytes, obj); err != nil {
+	errJSON, jsonErr := json.Marshal(ErrorResponse{errs})
+	if jsonErr != nil {
 		return err
 	}
 
-	return nil
+	return errors.New(string(errJSON))
 }
@@ -0,0 +1,216 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/Subskribo-BV/dnn-fabric-chaincode/common/contract"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const immutableContractObjectType = "immutable"
+
+// ImmutableContractTransientKey is the key of the transient data carrying the immutable contract of a CreateAsset
+// request storing it in a private data collection, encoded as a request argument. Transient data is not recorded
+// on the channel, so the contract is only kept by the peers of the members of the collection.
+const ImmutableContractTransientKey = "immutable_contract"
+
+// StoreOptions requests that CreateAsset, or the activation of a contract entering consent through BeginConsent,
+// stores the immutable contract on ledger, gzip compressed, so later changes of state can reference it by
+// contract id and hash instead of sending it again.
+type StoreOptions struct {
+	Collection string `json:"collection,omitempty"` // private data collection to store it in, the world state if empty
+}
+
+// readPrivateImmutableContract fills in the immutable contract of a CreateAsset request storing it in a private
+// data collection from the transient data of the proposal, rather than from the request recorded on the channel.
+func (s *SmartContract) readPrivateImmutableContract(ctx contractapi.TransactionContextInterface, cc *NewAssetReq) error {
+	if cc.ImmutableContract.ContractHash != "" {
+		return fmt.Errorf("an immutable contract stored in a private data collection is sent in the transient data %q, not in the request", ImmutableContractTransientKey)
+	}
+
+	transient, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return fmt.Errorf("failed reading the transient data: %v", err)
+	}
+
+	data, ok := transient[ImmutableContractTransientKey]
+	if !ok {
+		return fmt.Errorf("an immutable contract stored in a private data collection must be sent in the transient data %q", ImmutableContractTransientKey)
+	}
+
+	if err := s.parseRequest(string(data), &cc.ImmutableContract); err != nil {
+		return fmt.Errorf("invalid transient immutable contract: %v", err)
+	}
+
+	return nil
+}
+
+// ContractStorage records where the immutable contract of a contract is stored on ledger.
+type ContractStorage struct {
+	Collection     string `json:"collection,omitempty" metadata:"collection,optional"` // private data collection, the world state if empty
+	Size           int    `json:"size"`                                                // bytes of the canonical JSON of the immutable contract
+	CompressedSize int    `json:"compressed_size"`
+}
+
+// storeImmutableContract stores the canonical JSON of the immutable contract of the asset gzip compressed,
+// and records its storage on the asset.
+func (s *SmartContract) storeImmutableContract(ctx contractapi.TransactionContextInterface, asset *Contract, ic *contract.ImmutableContract, opts *StoreOptions) error {
+	icJSON, err := json.Marshal(ic)
+	if err != nil {
+		return err
+	}
+
+	compressed, err := gzipBytes(icJSON)
+	if err != nil {
+		return err
+	}
+
+	key, err := immutableContractKey(ctx, asset.ContractId)
+	if err != nil {
+		return err
+	}
+
+	if opts.Collection == "" {
+		err = ctx.GetStub().PutState(key, compressed)
+	} else {
+		err = ctx.GetStub().PutPrivateData(opts.Collection, key, compressed)
+	}
+	if err != nil {
+		return fmt.Errorf("failed storing the immutable contract: %v", err)
+	}
+
+	asset.Storage = &ContractStorage{
+		Collection:     opts.Collection,
+		Size:           len(icJSON),
+		CompressedSize: len(compressed),
+	}
+
+	return nil
+}
+
+// loadImmutableContract returns the canonical JSON of the immutable contract stored for the asset,
+// or nil if it is not stored. The JSON is checked against the hash anchored for the contract.
+func (s *SmartContract) loadImmutableContract(ctx contractapi.TransactionContextInterface, asset *Contract) ([]byte, error) {
+	if asset.Storage == nil {
+		return nil, nil
+	}
+
+	key, err := immutableContractKey(ctx, asset.ContractId)
+	if err != nil {
+		return nil, err
+	}
+
+	var compressed []byte
+	if asset.Storage.Collection == "" {
+		compressed, err = ctx.GetStub().GetState(key)
+	} else {
+		compressed, err = ctx.GetStub().GetPrivateData(asset.Storage.Collection, key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed reading the stored immutable contract: %v", err)
+	}
+
+	// a private collection is only readable on the peers of its member organizations
+	if compressed == nil {
+		return nil, fmt.Errorf("the immutable contract of %d is not readable from the ledger of this peer", asset.ContractId)
+	}
+
+	icJSON, err := gunzip(compressed, asset.Storage.Size)
+	if err != nil {
+		return nil, fmt.Errorf("invalid stored immutable contract of %d: %v", asset.ContractId, err)
+	}
+
+	if len(icJSON) != asset.Storage.Size {
+		return nil, fmt.Errorf("stored immutable contract of %d is not of its recorded size", asset.ContractId)
+	}
+
+	hash, err := JsonHashS256(json.RawMessage(icJSON))
+	if err != nil {
+		return nil, err
+	}
+
+	if hash != asset.ContractHash {
+		return nil, fmt.Errorf("stored immutable contract of %d does not match its anchored hash", asset.ContractId)
+	}
+
+	return icJSON, nil
+}
+
+// resolveImmutableContract fills in the immutable contract of a change of state request referencing the contract
+// stored on ledger, that is setting StoredContract.
+// It reports whether the request can be checked; if not, the failure is added to errs.
+func (s *SmartContract) resolveImmutableContract(ctx contractapi.TransactionContextInterface, cc *VoidAssetReq, asset *Contract, errs *contract.ValidationErrors) (bool, error) {
+	if !cc.StoredContract {
+		return true, nil
+	}
+
+	return s.resolveStoredContract(ctx, &cc.ImmutableContract, "/immutable_contract", asset, errs)
+}
+
+// resolveStoredContract fills in ic, left out of the request at path, with the immutable contract stored for the asset.
+// It reports whether the request can be checked; if not, the failure is added to errs.
+func (s *SmartContract) resolveStoredContract(ctx contractapi.TransactionContextInterface, ic *contract.ImmutableContract, path string, asset *Contract, errs *contract.ValidationErrors) (bool, error) {
+	if ic.ContractHash != "" {
+		errs.Add(contract.CodeInvalid, path, "a request referencing the stored immutable contract must not include it")
+		return false, nil
+	}
+
+	icJSON, err := s.loadImmutableContract(ctx, asset)
+	if err != nil {
+		return false, err
+	}
+
+	if icJSON == nil {
+		errs.Add(contract.CodeRequired, path, fmt.Sprintf("the immutable contract of %d is not stored on ledger, the request must include it", asset.ContractId))
+		return false, nil
+	}
+
+	if err := json.Unmarshal(icJSON, ic); err != nil {
+		return false, fmt.Errorf("invalid stored immutable contract of %d: %v", asset.ContractId, err)
+	}
+
+	return true, nil
+}
+
+// ReadImmutableContract returns the canonical JSON of the immutable contract stored on ledger with the contract,
+// whose hash is the contract hash of the asset.
+func (s *SmartContract) ReadImmutableContract(ctx contractapi.TransactionContextInterface, id string) (string, error) {
+	asset, err := s.getAsset(ctx, id)
+	if err != nil {
+		return "", err
+	}
+
+	icJSON, err := s.loadImmutableContract(ctx, asset)
+	if err != nil {
+		return "", err
+	}
+
+	if icJSON == nil {
+		return "", fmt.Errorf("the immutable contract of %s is not stored on ledger", id)
+	}
+
+	return string(icJSON), nil
+}
+
+// deleteImmutableContract deletes the immutable contract stored for the asset, if any.
+func (s *SmartContract) deleteImmutableContract(ctx contractapi.TransactionContextInterface, asset *Contract) error {
+	if asset.Storage == nil {
+		return nil
+	}
+
+	key, err := immutableContractKey(ctx, asset.ContractId)
+	if err != nil {
+		return err
+	}
+
+	if asset.Storage.Collection == "" {
+		return ctx.GetStub().DelState(key)
+	}
+
+	return ctx.GetStub().DelPrivateData(asset.Storage.Collection, key)
+}
+
+func immutableContractKey(ctx contractapi.TransactionContextInterface, contractId int64) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(immutableContractObjectType, []string{fmt.Sprint(contractId)})
+}
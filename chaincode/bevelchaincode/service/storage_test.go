@@ -0,0 +1,262 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Subskribo-BV/dnn-fabric-chaincode/common/contract"
+	"github.com/Subskribo-BV/dnn-fabric-chaincode/common/ledgertest"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+// storeContract anchors the contract, storing its immutable contract on ledger with the options.
+// For a private data collection the immutable contract is sent in the transient data.
+func storeContract(t *testing.T, l *ledgertest.Ledger, s *SmartContract, b *ledgertest.ContractBuilder, opts *StoreOptions) (*contract.ImmutableContract, string) {
+	t.Helper()
+
+	ic, hash := buildContract(t, b)
+	req := NewAssetReq{ImmutableContract: *ic, ImmutableContractHash: hash, Store: opts}
+
+	var txOpts []ledgertest.TxOption
+	if opts.Collection != "" {
+		req.ImmutableContract = contract.ImmutableContract{}
+		txOpts = append(txOpts, ledgertest.WithTransient(map[string][]byte{ImmutableContractTransientKey: []byte(encode(t, ic))}))
+	}
+
+	data := encode(t, req)
+	if _, err := submit(t, l, func(ctx *ledgertest.TransactionContext) (*CreateAssetResponse, error) {
+		return s.CreateAsset(ctx, data)
+	}, txOpts...); err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+
+	return ic, hash
+}
+
+// referenceRequest returns the request to void, expire or release the contract referencing its stored immutable contract.
+func referenceRequest(t *testing.T, contractId int64, hash string) string {
+	t.Helper()
+
+	return encode(t, VoidAssetReq{
+		ImmutableContractHash: hash,
+		StoredContract:        true,
+		ContractId:            contractId,
+		PackageId:             1,
+		PackageHash:           hash,
+	})
+}
+
+func TestStoredImmutableContract(t *testing.T) {
+	l := testLedger(t)
+	s := new(SmartContract)
+
+	ic, hash := storeContract(t, l, s, ledgertest.NewContract(1001, testSealedOn), &StoreOptions{})
+
+	asset := readContract(t, l, s, 1001)
+	if asset.Storage == nil || asset.Storage.Collection != "" || asset.Storage.CompressedSize >= asset.Storage.Size {
+		t.Fatalf("unexpected storage: %+v", asset.Storage)
+	}
+
+	key, _ := shim.CreateCompositeKey(immutableContractObjectType, []string{"1001"})
+	if l.State(key) == nil {
+		t.Fatal("immutable contract not stored in the world state")
+	}
+
+	icJSON, err := s.ReadImmutableContract(l.NewTx(), "1001")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, _ := JsonHashS256(ic); !strings.Contains(icJSON, ic.ContractHash) || got != hash {
+		t.Errorf("unexpected immutable contract %s", icJSON)
+	}
+
+	// a reference by another hash is rejected
+	_, err = submit(t, l, func(ctx *ledgertest.TransactionContext) (*VoidResponse, error) {
+		return s.VoidAsset(ctx, referenceRequest(t, 1001, "other"))
+	})
+	assertResponseErrors(t, err, "mismatch ", "mismatch /immutable_contract_hash")
+
+	// a reference including the immutable contract is ambiguous
+	_, err = submit(t, l, func(ctx *ledgertest.TransactionContext) (*VoidResponse, error) {
+		return s.VoidAsset(ctx, encode(t, VoidAssetReq{
+			ImmutableContract:     *ic,
+			ImmutableContractHash: hash,
+			StoredContract:        true,
+			ContractId:            1001,
+			PackageId:             1,
+			PackageHash:           hash,
+		}))
+	})
+	assertResponseErrors(t, err, "invalid /immutable_contract")
+
+	// without stored_contract the request is checked as sent, not completed from the ledger
+	_, err = submit(t, l, func(ctx *ledgertest.TransactionContext) (*VoidResponse, error) {
+		return s.VoidAsset(ctx, encode(t, VoidAssetReq{
+			ImmutableContractHash: hash,
+			ContractId:            1001,
+			PackageId:             1,
+			PackageHash:           hash,
+		}))
+	})
+	assertResponseErrors(t, err, "mismatch ", "mismatch /contract_id")
+
+	if _, err := submit(t, l, func(ctx *ledgertest.TransactionContext) (*VoidResponse, error) {
+		return s.VoidAsset(ctx, referenceRequest(t, 1001, hash))
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if state := readContract(t, l, s, 1001).State; state != ContractStateVoided {
+		t.Errorf("state = %s, want %s", state, ContractStateVoided)
+	}
+
+	if _, err := submit(t, l, func(ctx *ledgertest.TransactionContext) (any, error) {
+		return nil, s.DeleteAsset(ctx, "1001")
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if l.State(key) != nil {
+		t.Error("immutable contract of a deleted asset still stored")
+	}
+}
+
+func TestPrivateImmutableContract(t *testing.T) {
+	l := testLedger(t)
+	s := new(SmartContract)
+
+	_, hash := storeContract(t, l, s, ledgertest.NewContract(1001, testSealedOn), &StoreOptions{Collection: "contracts"})
+
+	key, _ := shim.CreateCompositeKey(immutableContractObjectType, []string{"1001"})
+	if l.State(key) != nil {
+		t.Error("immutable contract of a private collection stored in the world state")
+	}
+
+	if _, err := s.ReadImmutableContract(l.NewTx(), "1001"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := submit(t, l, func(ctx *ledgertest.TransactionContext) (*ReleaseResponse, error) {
+		return s.ReleaseAsset(ctx, referenceRequest(t, 1001, hash))
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if state := readContract(t, l, s, 1001).State; state != ContractStateReleased {
+		t.Errorf("state = %s, want %s", state, ContractStateReleased)
+	}
+}
+
+func TestPrivateImmutableContractInRequest(t *testing.T) {
+	l := testLedger(t)
+	s := new(SmartContract)
+
+	ic, hash := buildContract(t, ledgertest.NewContract(1001, testSealedOn))
+	opts := &StoreOptions{Collection: "contracts"}
+
+	// the contract of a private collection is not recorded on the channel with the request
+	_, err := submit(t, l, func(ctx *ledgertest.TransactionContext) (*CreateAssetResponse, error) {
+		return s.CreateAsset(ctx, encode(t, NewAssetReq{ImmutableContract: *ic, ImmutableContractHash: hash, Store: opts}))
+	}, ledgertest.WithTransient(map[string][]byte{ImmutableContractTransientKey: []byte(encode(t, ic))}))
+	if err == nil || !strings.Contains(err.Error(), "not in the request") {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	_, err = submit(t, l, func(ctx *ledgertest.TransactionContext) (*CreateAssetResponse, error) {
+		return s.CreateAsset(ctx, encode(t, NewAssetReq{ImmutableContractHash: hash, Store: opts}))
+	})
+	if err == nil || !strings.Contains(err.Error(), "must be sent in the transient data") {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if exists, _ := s.AssetExists(l.NewTx(), "1001"); exists {
+		t.Error("contract anchored")
+	}
+}
+
+func TestStoredConsentContract(t *testing.T) {
+	l := testLedger(t)
+	s := new(SmartContract)
+
+	ic, _ := buildContract(t, ledgertest.NewContract(1001, testSealedOn))
+	data := encode(t, ConsentAssetReq{ContractBlock: ic.Contract, ContractBlockHash: ic.ContractHash, Store: &StoreOptions{}})
+
+	if _, err := submit(t, l, func(ctx *ledgertest.TransactionContext) (*ConsentResponse, error) {
+		return s.BeginConsent(ctx, data)
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range ic.ContractSignatures.Signatures {
+		if err := submitSignature(t, l, s, ic, i); err != nil {
+			t.Fatalf("signature %d: %v", i, err)
+		}
+	}
+
+	asset := readContract(t, l, s, 1001)
+	if asset.State != ContractStateActive || asset.Storage == nil {
+		t.Fatalf("unexpected contract: %+v", asset)
+	}
+
+	icJSON, err := s.ReadImmutableContract(l.NewTx(), "1001")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(icJSON, ic.ContractHash) {
+		t.Errorf("unexpected immutable contract %s", icJSON)
+	}
+
+	if _, err := submit(t, l, func(ctx *ledgertest.TransactionContext) (*VoidResponse, error) {
+		return s.VoidAsset(ctx, referenceRequest(t, 1001, asset.ContractHash))
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestImmutableContractNotStored(t *testing.T) {
+	l := testLedger(t)
+	s := new(SmartContract)
+
+	_, hash := createContract(t, l, s, ledgertest.NewContract(1001, testSealedOn))
+
+	_, err := submit(t, l, func(ctx *ledgertest.TransactionContext) (*ExpireResponse, error) {
+		return s.ExpireAsset(ctx, referenceRequest(t, 1001, hash))
+	})
+	assertResponseErrors(t, err, "required /immutable_contract")
+
+	_, err = submit(t, l, func(ctx *ledgertest.TransactionContext) (*VoidResponse, error) {
+		return s.VoidAsset(ctx, referenceRequest(t, 1002, hash))
+	})
+	assertResponseErrors(t, err, "not_found ")
+
+	if _, err := s.ReadImmutableContract(l.NewTx(), "1001"); err == nil || !strings.Contains(err.Error(), "not stored on ledger") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestTamperedImmutableContract(t *testing.T) {
+	l := testLedger(t)
+	s := new(SmartContract)
+
+	_, hash := storeContract(t, l, s, ledgertest.NewContract(1001, testSealedOn), &StoreOptions{})
+	other, _ := buildContract(t, ledgertest.NewContract(1002, testSealedOn))
+
+	// the stored contract replaced by another of the same size
+	asset := readContract(t, l, s, 1001)
+	ctx := l.NewTx()
+	if err := s.storeImmutableContract(ctx, asset, other, &StoreOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ctx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := submit(t, l, func(ctx *ledgertest.TransactionContext) (*VoidResponse, error) {
+		return s.VoidAsset(ctx, referenceRequest(t, 1001, hash))
+	})
+	if err == nil || !strings.Contains(err.Error(), "does not match its anchored hash") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
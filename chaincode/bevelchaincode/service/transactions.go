@@ -0,0 +1,84 @@
+package service
+
+import "sort"
+
+// transaction declares how a transaction of SmartContract is exposed and guarded.
+type transaction struct {
+	contract string       // named contract exposing the transaction
+	roles    []AccessRole // the caller must hold one of the roles, any authenticated caller if empty
+	request  func() any   // new request decoded from the first parameter, nil if the transaction takes no encoded request
+	event    string       // name of the event emitted for the contracts changed, ContractsChangedEvent if empty
+}
+
+// submit reports whether the transaction writes to the ledger. Its request is decoded before it runs,
+// and it is recorded in the audit trail.
+func (tx transaction) submit() bool {
+	return tx.contract != QueryContractName
+}
+
+func requestOf[T any]() func() any {
+	return func() any { return new(T) }
+}
+
+var (
+	serverRoles  = []AccessRole{RoleServerSubmitter}
+	releaseRoles = []AccessRole{RoleServerSubmitter, RoleNotaryOperator}
+	sweepRoles   = []AccessRole{RoleServerSubmitter, RolePlatformAdmin}
+	auditRoles   = []AccessRole{RoleAuditor, RolePlatformAdmin}
+	adminRoles   = []AccessRole{RolePlatformAdmin}
+)
+
+// transactions are the transactions of SmartContract. Every exported method of SmartContract is declared here.
+//
+// Transactions taking the contract user id of the caller from its certificate, such as signatures and void proposals,
+// are open to any authenticated caller; the transaction checks the user is a participant.
+var transactions = map[string]transaction{
+	"CreateAsset":             {contract: LifecycleContractName, roles: serverRoles, request: requestOf[NewAssetReq]()},
+	"AmendContract":           {contract: LifecycleContractName, roles: serverRoles, request: requestOf[AmendContractReq]()},
+	"BeginConsent":            {contract: LifecycleContractName, roles: serverRoles, request: requestOf[ConsentAssetReq]()},
+	"SubmitSignature":         {contract: LifecycleContractName, request: requestOf[SubmitSignatureReq]()},
+	"ExpireConsent":           {contract: LifecycleContractName, roles: serverRoles},
+	"ExtendSignatureDeadline": {contract: LifecycleContractName, request: requestOf[ExtendSignatureDeadlineReq]()},
+	"ProposeVoid":             {contract: LifecycleContractName, request: requestOf[ProposeVoidReq]()},
+	"ApproveVoid":             {contract: LifecycleContractName},
+	"RejectVoid":              {contract: LifecycleContractName},
+	"WithdrawVoidProposal":    {contract: LifecycleContractName},
+	"VoidAsset":               {contract: LifecycleContractName, roles: serverRoles, request: requestOf[VoidAssetReq]()},
+	"ExpireAsset":             {contract: LifecycleContractName, roles: serverRoles, request: requestOf[VoidAssetReq]()},
+	"ReleaseAsset":            {contract: LifecycleContractName, roles: releaseRoles, request: requestOf[VoidAssetReq]()},
+	"ActivateDueContracts":    {contract: LifecycleContractName, roles: sweepRoles},
+	"ExpireDueContracts":      {contract: LifecycleContractName, roles: sweepRoles, event: ContractsExpiredEvent},
+
+	"ReadAsset":             {contract: QueryContractName},
+	"AssetExists":           {contract: QueryContractName},
+	"GetAllAssets":          {contract: QueryContractName},
+	"GetContractLineage":    {contract: QueryContractName},
+	"ReadConsentStatus":     {contract: QueryContractName},
+	"ReadSignatureDeadline": {contract: QueryContractName},
+	"ReadVoidProposal":      {contract: QueryContractName},
+	"ReadContentSignatures": {contract: QueryContractName},
+	"ValidateContract":      {contract: QueryContractName},
+	"ReadImmutableContract": {contract: QueryContractName, roles: auditRoles},
+	"GetAuditRecords":       {contract: QueryContractName, roles: auditRoles},
+	"ReadConfig":            {contract: QueryContractName},
+	"GetConfigHistory":      {contract: QueryContractName, roles: auditRoles},
+	"WhoAmI":                {contract: QueryContractName},
+
+	"InitLedger":        {contract: AdminContractName, request: requestOf[InitLedgerReq]()},
+	"UpdateConfig":      {contract: AdminContractName, roles: adminRoles, request: requestOf[UpdateConfigReq]()},
+	"DeleteAsset":       {contract: AdminContractName, roles: adminRoles},
+	"PruneAuditRecords": {contract: AdminContractName, roles: adminRoles},
+}
+
+// contractFunctions returns the names of the transactions exposed by the named contract, in name order.
+func contractFunctions(contract string) []string {
+	functions := []string{}
+	for name, tx := range transactions {
+		if tx.contract == contract {
+			functions = append(functions, name)
+		}
+	}
+	sort.Strings(functions)
+
+	return functions
+}
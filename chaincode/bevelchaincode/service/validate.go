@@ -0,0 +1,294 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Subskribo-BV/dnn-fabric-chaincode/common/contract"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ValidationReport is the result of a dry run of a create, void, expire or release request.
+type ValidationReport struct {
+	Action     string                    `json:"action"`
+	ContractId int64                     `json:"contractId"`
+	State      string                    `json:"state"` // current state of the contract on ledger, empty if not anchored
+	Valid      bool                      `json:"valid"`
+	Errors     contract.ValidationErrors `json:"errors"`
+}
+
+// ValidateContract runs the validation of a create, void, expire or release request without writing state,
+// so the server can pre-flight a request through the chaincode before submitting it.
+// Intended to be evaluated, not submitted.
+func (s *SmartContract) ValidateContract(ctx contractapi.TransactionContextInterface, data string, action string) (*ValidationReport, error) {
+
+	report := &ValidationReport{
+		Action: action,
+	}
+
+	var errs contract.ValidationErrors
+
+	switch contract.Action(action) {
+	case contract.ActionCreate:
+		cc := new(NewAssetReq)
+		if err := s.parseRequest(data, cc); err != nil {
+			return nil, err
+		}
+
+		report.ContractId = cc.ImmutableContract.Contract.ContractID
+
+		pending, createErrs, err := s.checkCreate(ctx, cc)
+		if err != nil {
+			return nil, err
+		}
+
+		if pending != nil {
+			report.State = pending.State
+		}
+		errs = createErrs
+
+	case contract.ActionVoid, contract.ActionExpire, contract.ActionRelease:
+		cc := new(VoidAssetReq)
+		if err := s.parseRequest(data, cc); err != nil {
+			return nil, err
+		}
+
+		report.ContractId = cc.ContractId
+
+		asset, changeErrs, err := s.checkChange(ctx, cc, contract.Action(action))
+		if err != nil {
+			return nil, err
+		}
+
+		if asset != nil {
+			report.State = asset.State
+		}
+		errs = changeErrs
+
+	default:
+		return nil, fmt.Errorf("unknown action %q", action)
+	}
+
+	report.Valid = len(errs) == 0
+	report.Errors = errs
+
+	return report, nil
+}
+
+// checkCreate runs every check of CreateAsset without writing state.
+// Failed checks are returned as validation errors; err is only set if the checks could not be run.
+// If the contract entered consent on ledger, the pending contract is returned.
+// A contract stored in a private data collection is read from the transient data of the proposal.
+func (s *SmartContract) checkCreate(ctx contractapi.TransactionContextInterface, cc *NewAssetReq) (*Contract, contract.ValidationErrors, error) {
+	errs := contract.ValidationErrors{}
+
+	if cc.Store != nil && cc.Store.Collection != "" {
+		if err := s.readPrivateImmutableContract(ctx, cc); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	icHash, err := JsonHashS256(cc.ImmutableContract)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if icHash != cc.ImmutableContractHash {
+		errs.Add(contract.CodeMismatch, "", "invalid immutable contract hash")
+	}
+
+	contractIdStr := fmt.Sprint(cc.ImmutableContract.Contract.ContractID)
+
+	exists, err := s.AssetExists(ctx, contractIdStr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var pending *Contract
+	if exists {
+		if pending, err = s.ReadAsset(ctx, contractIdStr); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	rules, _, err := s.rules(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// a contract anchored when entering consent is activated under the rules it entered consent with
+	if pending != nil && pending.State == ContractStatePendingConsent {
+		if rules, err = s.rulesOf(ctx, pending); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	errs.Append("", cc.ImmutableContract.ValidateFor(contract.ActionCreate, rules))
+
+	if cc.ImmutableContract.Contract.Amends != nil {
+		errs.Add(contract.CodeInvalid, "/contract/amends", "an amendment is anchored through AmendContract")
+	}
+
+	if err := s.checkSignatureDeadline(ctx, &cc.ImmutableContract, &errs); err != nil {
+		return nil, nil, err
+	}
+
+	if pending == nil {
+		return nil, errs, nil
+	}
+
+	// a contract anchored when entering consent can be activated with its fully signed immutable contract
+	if pending.State != ContractStatePendingConsent {
+		errs.Add(contract.CodeState, "", fmt.Sprintf("the contract %s already exists", contractIdStr))
+		return pending, errs, nil
+	}
+
+	if pending.ContractHash != cc.ImmutableContract.ContractHash {
+		errs.Add(contract.CodeMismatch, "/contract_hash", "contract hash does not match the contract block anchored for consent")
+	}
+
+	return pending, errs, nil
+}
+
+// checkSignatureDeadline adds an error to errs for each signature of the immutable contract dated after its signature deadline.
+func (s *SmartContract) checkSignatureDeadline(ctx contractapi.TransactionContextInterface, ic *contract.ImmutableContract, errs *contract.ValidationErrors) error {
+	deadline, err := s.effectiveSignatureDeadline(ctx, &ic.Contract)
+	if err != nil || deadline == nil {
+		return err
+	}
+
+	for i, sp := range ic.ContractSignatures.Signatures {
+		if sp.ContractSignaturePackage.DateSigned.After(*deadline) {
+			errs.Add(contract.CodeOutOfRange, fmt.Sprintf("/contract_signatures/signatures/%d/contract_signature_package/date_signed", i),
+				fmt.Sprintf("signature by user id '%v' is dated after the signature deadline %v", sp.ContractSignaturePackage.UserId, deadline.Format(time.RFC3339)))
+		}
+	}
+
+	return nil
+}
+
+// checkChange runs every check of a void, expire or release without writing state.
+// Failed checks are returned as validation errors; err is only set if the checks could not be run.
+// The contract is returned if it is anchored on ledger.
+// A request setting StoredContract references the immutable contract stored on ledger.
+func (s *SmartContract) checkChange(ctx contractapi.TransactionContextInterface, cc *VoidAssetReq, action contract.Action) (*Contract, contract.ValidationErrors, error) {
+	errs := contract.ValidationErrors{}
+
+	contractIdStr := fmt.Sprint(cc.ContractId)
+
+	exists, err := s.AssetExists(ctx, contractIdStr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var asset *Contract
+	if exists {
+		if asset, err = s.ReadAsset(ctx, contractIdStr); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if cc.StoredContract {
+		if asset == nil {
+			errs.Add(contract.CodeNotFound, "", fmt.Sprintf("the asset %s does not exist", contractIdStr))
+			return nil, errs, nil
+		}
+
+		ok, err := s.resolveImmutableContract(ctx, cc, asset, &errs)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if !ok {
+			return asset, errs, nil
+		}
+	}
+
+	icHash, err := JsonHashS256(cc.ImmutableContract)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if icHash != cc.ImmutableContractHash {
+		errs.Add(contract.CodeMismatch, "", "invalid immutable contract hash")
+	}
+
+	// a contract is changed under the rules it was anchored with
+	var rules *contract.Rules
+	if asset != nil {
+		if rules, err = s.rulesOf(ctx, asset); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	errs.Append("", cc.ImmutableContract.ValidateFor(action, rules))
+
+	if asset == nil {
+		errs.Add(contract.CodeNotFound, "", fmt.Sprintf("the asset %s does not exist", contractIdStr))
+		return nil, errs, nil
+	}
+
+	// the request changes the contract anchored on ledger, not another version of it
+	if cc.ImmutableContract.Contract.ContractID != cc.ContractId {
+		errs.Add(contract.CodeMismatch, "/contract_id", "contract id does not match the contract of the immutable contract")
+	}
+
+	if cc.ImmutableContractHash != asset.ContractHash {
+		errs.Add(contract.CodeMismatch, "/immutable_contract_hash", "immutable contract hash does not match the contract anchored on ledger")
+	}
+
+	if msg := stateTransitionError(asset.State, action); msg != "" {
+		errs.Add(contract.CodeState, "", msg)
+	}
+
+	// the void of a contract requiring the consensus of its participants is proposed and approved by them
+	if action == contract.ActionVoid && cc.ImmutableContract.Contract.IsVoidableByParticipants() {
+		errs.Add(contract.CodeState, "/contract/contract_options/voidable_by_participants", "contract is voided by consensus of its participants, through ProposeVoid")
+	}
+
+	/*
+		todo: Validate that the change of state is allowed as per contract rules.
+		For example, if content is to be released, that if using a notary, then the notary is the caller (through a manged process), or if verifiers, then a common key is used.
+		If contract is to be voided, that voiding is allowed.
+		If to be set as expired, that the expiration data has been reached, etc.
+	*/
+
+	return asset, errs, nil
+}
+
+// stateTransitionError returns why a contract in the given state cannot be voided, expired or released,
+// or an empty string if it can.
+func stateTransitionError(state string, action contract.Action) string {
+	switch state {
+	case ContractStatePendingConsent, ContractStateConsentExpired:
+		return fmt.Sprintf("contract not instantiated, cannot %s", action)
+
+	case ContractStateScheduled:
+		if action != contract.ActionVoid {
+			return fmt.Sprintf("contract not yet effective, cannot %s", action)
+		}
+
+	case ContractStateVoided:
+		if action == contract.ActionVoid {
+			return "contract already voided"
+		}
+		return fmt.Sprintf("contract voided, cannot %s", action)
+
+	case ContractStateExpired:
+		if action == contract.ActionExpire {
+			return "contract already expired"
+		}
+		return fmt.Sprintf("contract expired, cannot %s", action)
+
+	case ContractStateReleased:
+		if action == contract.ActionRelease {
+			return "contract already released"
+		}
+		return fmt.Sprintf("contract released, cannot %s", action)
+
+	case ContractStateSuperseded:
+		return fmt.Sprintf("contract superseded by an amendment, cannot %s", action)
+	}
+
+	return ""
+}
@@ -0,0 +1,449 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Subskribo-BV/dnn-fabric-chaincode/common/contract"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	voidProposalObjectType = "voidproposal"
+	voidResponseObjectType = "voidresponse"
+
+	MaxVoidProposalDays = 30
+)
+
+type VoidProposalResponse struct {
+	ContractId int64  `json:"contractId"`
+	ProposalId string `json:"proposalId"`
+	State      string `json:"state"`
+	TxId       string `json:"txId"`
+}
+
+type VoidProposalDetail struct {
+	Proposal  VoidProposal         `json:"proposal"`
+	Responses []VoidResponseRecord `json:"responses"`
+}
+
+// ProposeVoid opens a proposal to void a contract by consensus of the participants.
+// Allowed only if the contract and its definition allow voiding by participants,
+// and only by a participant whose consensus is required. The proposer's approval is recorded with the proposal.
+func (s *SmartContract) ProposeVoid(ctx contractapi.TransactionContextInterface, data string) (*VoidProposalResponse, error) {
+
+	cc, err := decodeRequest[ProposeVoidReq](ctx, data)
+	if err != nil {
+		return nil, err
+	}
+
+	icHash, err := JsonHashS256(cc.ImmutableContract)
+	if err != nil {
+		return nil, err
+	}
+
+	if icHash != cc.ImmutableContractHash {
+		return nil, errors.New("invalid immutable contract hash")
+	}
+
+	if cc.DaysToApprove < 1 || cc.DaysToApprove > MaxVoidProposalDays {
+		return nil, fmt.Errorf("days to approve must be between 1 and %d", MaxVoidProposalDays)
+	}
+
+	block := &cc.ImmutableContract.Contract
+
+	if block.ContractID != cc.ContractId {
+		return nil, errors.New("contract id does not match immutable contract")
+	}
+
+	if !block.IsVoidableByParticipants() {
+		return nil, errors.New("contract is not voidable by participants")
+	}
+
+	contractIdStr := fmt.Sprint(cc.ContractId)
+
+	asset, err := s.ReadAsset(ctx, contractIdStr)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := s.rulesOf(ctx, asset)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cc.ImmutableContract.ValidateFor(contract.ActionVoid, rules); err != nil {
+		return nil, responseError(err)
+	}
+
+	if asset.ContractHash != icHash {
+		return nil, errors.New("immutable contract hash does not match the anchored contract")
+	}
+
+	if asset.State != ContractStateActive && asset.State != ContractStateScheduled {
+		return nil, fmt.Errorf("contract %s, cannot void", asset.State)
+	}
+
+	callerId, err := callerUserId(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	required := block.VoidConsensusParticipants()
+	if !containsString(required, callerId) {
+		return nil, fmt.Errorf("user id '%v' cannot propose to void the contract", callerId)
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.getVoidProposal(ctx, contractIdStr)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing != nil && existing.State == VoidProposalStateOpen {
+		expiresAt, err := parseDate(existing.ExpiresAt)
+		if err != nil {
+			return nil, err
+		}
+
+		if !now.After(expiresAt) {
+			return nil, fmt.Errorf("contract %d already has an open void proposal", asset.ContractId)
+		}
+	}
+
+	proposal := &VoidProposal{
+		ContractId:        asset.ContractId,
+		ProposalId:        ctx.GetStub().GetTxID(),
+		ProposedBy:        callerId,
+		Reason:            cc.Reason,
+		RequiredApprovers: required,
+		State:             VoidProposalStateOpen,
+		CreatedAt:         now.Format(time.RFC3339),
+		ExpiresAt:         now.AddDate(0, 0, int(cc.DaysToApprove)).Format(time.RFC3339),
+	}
+
+	if err := s.putVoidProposal(ctx, proposal); err != nil {
+		return nil, err
+	}
+
+	log.Println("void proposed:", proposal.ContractId, proposal.ProposalId, callerId)
+
+	return s.respondToVoidProposal(ctx, asset, proposal, callerId, true, now)
+}
+
+// ApproveVoid records the caller's approval of the open void proposal of a contract.
+// The contract is voided once all required participants have approved.
+func (s *SmartContract) ApproveVoid(ctx contractapi.TransactionContextInterface, id string) (*VoidProposalResponse, error) {
+	return s.answerVoidProposal(ctx, id, true)
+}
+
+// RejectVoid records the caller's rejection of the open void proposal of a contract, closing the proposal.
+func (s *SmartContract) RejectVoid(ctx contractapi.TransactionContextInterface, id string) (*VoidProposalResponse, error) {
+	return s.answerVoidProposal(ctx, id, false)
+}
+
+// WithdrawVoidProposal withdraws the open void proposal of a contract. Only the proposer can withdraw.
+func (s *SmartContract) WithdrawVoidProposal(ctx contractapi.TransactionContextInterface, id string) (*VoidProposalResponse, error) {
+
+	proposal, now, err := s.openVoidProposal(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	callerId, err := callerUserId(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if callerId != proposal.ProposedBy {
+		return nil, fmt.Errorf("user id '%v' did not propose to void the contract", callerId)
+	}
+
+	proposal.State = VoidProposalStateWithdrawn
+	proposal.ClosedAt = now.Format(time.RFC3339)
+
+	if err := s.putVoidProposal(ctx, proposal); err != nil {
+		return nil, err
+	}
+
+	return &VoidProposalResponse{
+		proposal.ContractId,
+		proposal.ProposalId,
+		proposal.State,
+		ctx.GetStub().GetTxID(),
+	}, nil
+}
+
+// ReadVoidProposal returns the latest void proposal of a contract and the responses recorded for it.
+// An open proposal past its expiry is reported as timed out.
+func (s *SmartContract) ReadVoidProposal(ctx contractapi.TransactionContextInterface, id string) (*VoidProposalDetail, error) {
+
+	proposal, err := s.getVoidProposal(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if proposal == nil {
+		return nil, fmt.Errorf("the contract %s does not have a void proposal", id)
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if proposal.State == VoidProposalStateOpen {
+		expiresAt, err := parseDate(proposal.ExpiresAt)
+		if err != nil {
+			return nil, err
+		}
+
+		if now.After(expiresAt) {
+			proposal.State = VoidProposalStateTimedOut
+		}
+	}
+
+	responses, err := s.getVoidResponses(ctx, proposal)
+	if err != nil {
+		return nil, err
+	}
+
+	return &VoidProposalDetail{
+		*proposal,
+		responses,
+	}, nil
+}
+
+func (s *SmartContract) answerVoidProposal(ctx contractapi.TransactionContextInterface, id string, approved bool) (*VoidProposalResponse, error) {
+
+	proposal, now, err := s.openVoidProposal(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	callerId, err := callerUserId(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if !containsString(proposal.RequiredApprovers, callerId) {
+		return nil, fmt.Errorf("user id '%v' is not required to approve voiding the contract", callerId)
+	}
+
+	asset, err := s.ReadAsset(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if asset.State != ContractStateActive && asset.State != ContractStateScheduled {
+		return nil, fmt.Errorf("contract %s, cannot void", asset.State)
+	}
+
+	return s.respondToVoidProposal(ctx, asset, proposal, callerId, approved, now)
+}
+
+// respondToVoidProposal records a participant's response and closes the proposal when rejected or fully approved,
+// voiding the contract in the latter case.
+func (s *SmartContract) respondToVoidProposal(ctx contractapi.TransactionContextInterface, asset *Contract, proposal *VoidProposal, userId string, approved bool, now time.Time) (*VoidProposalResponse, error) {
+
+	key, err := ctx.GetStub().CreateCompositeKey(voidResponseObjectType, []string{fmt.Sprint(proposal.ContractId), proposal.ProposalId, userId})
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+
+	if existing != nil {
+		return nil, fmt.Errorf("user id '%v' has already responded to the void proposal", userId)
+	}
+
+	responses, err := s.getVoidResponses(ctx, proposal)
+	if err != nil {
+		return nil, err
+	}
+
+	response := VoidResponseRecord{
+		ContractId: proposal.ContractId,
+		ProposalId: proposal.ProposalId,
+		UserId:     userId,
+		Approved:   approved,
+		Date:       now.Format(time.RFC3339),
+	}
+
+	responseJSON, err := json.Marshal(response)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ctx.GetStub().PutState(key, responseJSON); err != nil {
+		return nil, err
+	}
+
+	approvals := 1
+	for _, r := range responses {
+		if r.Approved {
+			approvals++
+		}
+	}
+
+	t := now.Format(time.RFC3339)
+
+	if !approved {
+		proposal.State = VoidProposalStateRejected
+		proposal.ClosedAt = t
+	} else if approvals == len(proposal.RequiredApprovers) {
+		proposal.State = VoidProposalStateApproved
+		proposal.ClosedAt = t
+
+		asset.State = ContractStateVoided
+		asset.UpdatedAt = t
+
+		asset.Changes = append(asset.Changes, Change{
+			PackageDate: t,
+			Action:      "void",
+			NewState:    asset.State,
+		})
+
+		if err := s.putAsset(ctx, asset); err != nil {
+			return nil, err
+		}
+
+		addEvent(ctx, asset, "void")
+
+		log.Println("contract voided by participants:", asset.ContractId, ctx.GetStub().GetTxID())
+	}
+
+	if err := s.putVoidProposal(ctx, proposal); err != nil {
+		return nil, err
+	}
+
+	return &VoidProposalResponse{
+		proposal.ContractId,
+		proposal.ProposalId,
+		proposal.State,
+		ctx.GetStub().GetTxID(),
+	}, nil
+}
+
+// openVoidProposal returns the open void proposal of a contract, failing if there is none or it has timed out.
+func (s *SmartContract) openVoidProposal(ctx contractapi.TransactionContextInterface, id string) (*VoidProposal, time.Time, error) {
+
+	proposal, err := s.getVoidProposal(ctx, id)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	if proposal == nil || proposal.State != VoidProposalStateOpen {
+		return nil, time.Time{}, fmt.Errorf("the contract %s does not have an open void proposal", id)
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	expiresAt, err := parseDate(proposal.ExpiresAt)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	if now.After(expiresAt) {
+		return nil, time.Time{}, errors.New("void proposal has timed out")
+	}
+
+	return proposal, now, nil
+}
+
+// closeVoidProposal closes the open void proposal of a contract, if any, in the given state.
+func (s *SmartContract) closeVoidProposal(ctx contractapi.TransactionContextInterface, contractId int64, state string, now time.Time) error {
+	proposal, err := s.getVoidProposal(ctx, fmt.Sprint(contractId))
+	if err != nil || proposal == nil || proposal.State != VoidProposalStateOpen {
+		return err
+	}
+
+	proposal.State = state
+	proposal.ClosedAt = now.Format(time.RFC3339)
+
+	return s.putVoidProposal(ctx, proposal)
+}
+
+func (s *SmartContract) getVoidProposal(ctx contractapi.TransactionContextInterface, id string) (*VoidProposal, error) {
+	key, err := ctx.GetStub().CreateCompositeKey(voidProposalObjectType, []string{id})
+	if err != nil {
+		return nil, err
+	}
+
+	proposalJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+
+	if proposalJSON == nil {
+		return nil, nil
+	}
+
+	var proposal VoidProposal
+	if err := json.Unmarshal(proposalJSON, &proposal); err != nil {
+		return nil, err
+	}
+
+	return &proposal, nil
+}
+
+func (s *SmartContract) putVoidProposal(ctx contractapi.TransactionContextInterface, proposal *VoidProposal) error {
+	key, err := ctx.GetStub().CreateCompositeKey(voidProposalObjectType, []string{fmt.Sprint(proposal.ContractId)})
+	if err != nil {
+		return err
+	}
+
+	proposalJSON, err := json.Marshal(proposal)
+	if err != nil {
+		return err
+	}
+
+	return ctx.GetStub().PutState(key, proposalJSON)
+}
+
+func (s *SmartContract) getVoidResponses(ctx contractapi.TransactionContextInterface, proposal *VoidProposal) ([]VoidResponseRecord, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(voidResponseObjectType, []string{fmt.Sprint(proposal.ContractId), proposal.ProposalId})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	responses := []VoidResponseRecord{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		var r VoidResponseRecord
+		if err := json.Unmarshal(queryResponse.Value, &r); err != nil {
+			return nil, err
+		}
+		responses = append(responses, r)
+	}
+
+	return responses, nil
+}
+
+func containsString(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
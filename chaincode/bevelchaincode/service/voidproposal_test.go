@@ -0,0 +1,184 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Subskribo-BV/dnn-fabric-chaincode/common/contract"
+	"github.com/Subskribo-BV/dnn-fabric-chaincode/common/ledgertest"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// voidableContract builds a contract which its two participants void by consensus.
+func voidableContract(id int64) *ledgertest.ContractBuilder {
+	return ledgertest.NewContract(id, testSealedOn).Edit(func(ic *contract.ImmutableContract) {
+		ic.Contract.ContractOptions.VoidableByParticipants = true
+		ic.Contract.Definition.Options.AllowVoidableByParticipants = true
+		for i := range ic.Contract.Participants {
+			ic.Contract.Participants[i].CanVoidContract = true
+		}
+	})
+}
+
+// asUser is the identity of the client of the contract user with the id.
+func asUser(userId string) ledgertest.TxOption {
+	return ledgertest.WithIdentity(ledgertest.NewClientIdentity("Org1MSP", userId, map[string]string{"user_id": userId}))
+}
+
+// proposeVoid proposes to void the contract as the user, returning the state of the proposal.
+func proposeVoid(t *testing.T, l *ledgertest.Ledger, s *SmartContract, ic *contract.ImmutableContract, hash string, userId string, days int64) (string, error) {
+	t.Helper()
+
+	data := encode(t, ProposeVoidReq{ImmutableContract: *ic, ImmutableContractHash: hash, ContractId: ic.Contract.ContractID, Reason: "settled", DaysToApprove: days})
+
+	res, err := submit(t, l, func(ctx *ledgertest.TransactionContext) (*VoidProposalResponse, error) {
+		return s.ProposeVoid(ctx, data)
+	}, asUser(userId))
+	if err != nil {
+		return "", err
+	}
+
+	return res.State, nil
+}
+
+// answerVoid runs ApproveVoid, RejectVoid or WithdrawVoidProposal on the contract as the user, returning the state of the proposal.
+func answerVoid(t *testing.T, l *ledgertest.Ledger, answer func(contractapi.TransactionContextInterface, string) (*VoidProposalResponse, error), id string, userId string) (string, error) {
+	t.Helper()
+
+	res, err := submit(t, l, func(ctx *ledgertest.TransactionContext) (*VoidProposalResponse, error) {
+		return answer(ctx, id)
+	}, asUser(userId))
+	if err != nil {
+		return "", err
+	}
+
+	return res.State, nil
+}
+
+func TestApproveVoidProposal(t *testing.T) {
+	l := testLedger(t)
+	s := new(SmartContract)
+
+	ic, hash := createContract(t, l, s, voidableContract(1001))
+
+	_, err := proposeVoid(t, l, s, ic, hash, "c102", 0)
+	assertErrorContains(t, err, "days to approve")
+
+	_, err = proposeVoid(t, l, s, ic, hash, "c999", 7)
+	assertErrorContains(t, err, "cannot propose to void")
+
+	other, otherHash := buildContract(t, voidableContract(1001).Edit(func(ic *contract.ImmutableContract) {
+		ic.Contract.ContractName = "Other"
+	}))
+	_, err = proposeVoid(t, l, s, other, otherHash, "c102", 7)
+	assertErrorContains(t, err, "does not match the anchored contract")
+
+	if state, err := proposeVoid(t, l, s, ic, hash, "c102", 7); err != nil || state != VoidProposalStateOpen {
+		t.Fatalf("propose: %q, %v", state, err)
+	}
+
+	_, err = proposeVoid(t, l, s, ic, hash, "c103", 7)
+	assertErrorContains(t, err, "already has an open void proposal")
+
+	_, err = answerVoid(t, l, s.ApproveVoid, "1001", "c102")
+	assertErrorContains(t, err, "already responded")
+
+	_, err = answerVoid(t, l, s.ApproveVoid, "1001", "c999")
+	assertErrorContains(t, err, "not required to approve")
+
+	_, err = answerVoid(t, l, s.WithdrawVoidProposal, "1001", "c103")
+	assertErrorContains(t, err, "did not propose")
+
+	if state, err := answerVoid(t, l, s.ApproveVoid, "1001", "c103"); err != nil || state != VoidProposalStateApproved {
+		t.Fatalf("approve: %q, %v", state, err)
+	}
+
+	if state := readContract(t, l, s, 1001).State; state != ContractStateVoided {
+		t.Errorf("state = %q, want %q", state, ContractStateVoided)
+	}
+
+	detail, err := s.ReadVoidProposal(l.NewTx(), "1001")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if detail.Proposal.ProposedBy != "c102" || detail.Proposal.ClosedAt == "" || len(detail.Responses) != 2 {
+		t.Errorf("unexpected proposal: %+v", detail)
+	}
+
+	_, err = answerVoid(t, l, s.RejectVoid, "1001", "c103")
+	assertErrorContains(t, err, "does not have an open void proposal")
+
+	_, err = proposeVoid(t, l, s, ic, hash, "c102", 7)
+	assertErrorContains(t, err, "cannot void")
+}
+
+func TestRejectVoidProposal(t *testing.T) {
+	l := testLedger(t)
+	s := new(SmartContract)
+
+	ic, hash := createContract(t, l, s, voidableContract(1001))
+
+	if _, err := proposeVoid(t, l, s, ic, hash, "c102", 7); err != nil {
+		t.Fatal(err)
+	}
+
+	if state, err := answerVoid(t, l, s.RejectVoid, "1001", "c103"); err != nil || state != VoidProposalStateRejected {
+		t.Fatalf("reject: %q, %v", state, err)
+	}
+
+	_, err := answerVoid(t, l, s.ApproveVoid, "1001", "c103")
+	assertErrorContains(t, err, "does not have an open void proposal")
+
+	if state := readContract(t, l, s, 1001).State; state != ContractStateActive {
+		t.Errorf("state = %q, want %q", state, ContractStateActive)
+	}
+
+	// a rejected proposal does not prevent another
+	if _, err := proposeVoid(t, l, s, ic, hash, "c103", 7); err != nil {
+		t.Fatal(err)
+	}
+
+	if state, err := answerVoid(t, l, s.WithdrawVoidProposal, "1001", "c103"); err != nil || state != VoidProposalStateWithdrawn {
+		t.Fatalf("withdraw: %q, %v", state, err)
+	}
+
+	_, err = answerVoid(t, l, s.WithdrawVoidProposal, "1001", "c103")
+	assertErrorContains(t, err, "does not have an open void proposal")
+
+	// an open proposal past its days to approve times out
+	if _, err := proposeVoid(t, l, s, ic, hash, "c102", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	l.Advance(48 * time.Hour)
+
+	_, err = answerVoid(t, l, s.ApproveVoid, "1001", "c103")
+	assertErrorContains(t, err, "timed out")
+
+	detail, err := s.ReadVoidProposal(l.NewTx(), "1001")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if detail.Proposal.State != VoidProposalStateTimedOut {
+		t.Errorf("proposal state = %q, want %q", detail.Proposal.State, VoidProposalStateTimedOut)
+	}
+
+	if _, err := proposeVoid(t, l, s, ic, hash, "c103", 7); err != nil {
+		t.Errorf("propose after time out: %v", err)
+	}
+}
+
+func TestProposeVoidNotVoidableByParticipants(t *testing.T) {
+	l := testLedger(t)
+	s := new(SmartContract)
+
+	ic, hash := createContract(t, l, s, ledgertest.NewContract(1001, testSealedOn))
+
+	_, err := proposeVoid(t, l, s, ic, hash, "c102", 7)
+	assertErrorContains(t, err, "not voidable by participants")
+
+	_, err = answerVoid(t, l, s.ApproveVoid, "1001", "c102")
+	assertErrorContains(t, err, "does not have an open void proposal")
+}